@@ -0,0 +1,117 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"errors"
+	"strings"
+)
+
+// CompileAllErrors constructs a Path from a string expression, as NewPath does, except that, if the expression has
+// syntax errors, it returns all of them rather than just the first, so that, for example, an editor can report each
+// of them. The first error is the one which NewPath returns. If there are no errors, CompileAllErrors returns the
+// Path and no errors and otherwise it returns a nil Path.
+//
+// After a syntax error, lexing resumes at the start of the next segment of the path, such as `.b` or `[0]`, which
+// is outside the brackets, quotes, and filter in which the error occurred. So an error which extends to the end of
+// the path, such as an unmatched `[`, is reported only once.
+func CompileAllErrors(path string, opts ...Option) (*Path, []error) {
+	path = trimWhitespace(path)
+	p, err := NewPath(path, opts...)
+	if err == nil {
+		return p, nil
+	}
+
+	errs := []error{}
+	l := lex("Path lexer", path)
+	l.requireExplicitRoot = newOptions(opts).requireExplicitRoot
+	for {
+		lexemes := lexemesOf(l)
+		lx := lexemes[len(lexemes)-1]
+		if lx.typ != lexemeError {
+			break
+		}
+		errs = append(errs, errors.New(lx.val))
+
+		from := l.pos
+		if from <= l.start {
+			from = l.start + 1
+		}
+		next := nextSegment(path, from)
+		if next < 0 {
+			break
+		}
+		l = resumeLexing(path, next)
+	}
+	if len(errs) == 0 {
+		// the error was not a syntax error, for example an array slice rejected by WithStrictSlices
+		errs = append(errs, err)
+	}
+	return nil, errs
+}
+
+// resumeLexing returns a lexer which lexes the given path from the given position, which is the start of a segment,
+// as if the path up to that position had been lexed successfully.
+func resumeLexing(path string, pos int) *lexer {
+	l := lex("Path lexer", path)
+	l.state = lexSubPath
+	l.pos = pos
+	l.start = pos
+	l.lastEmittedStart = pos
+	return l
+}
+
+// nextSegment returns the position of the first `.` or `[` at or after the given position in the given path which
+// starts a segment of the path, that is which is not escaped or within brackets, quotes, or a regular expression
+// literal, and is not the second `.` of a recursive descent. If there is no such position, nextSegment returns -1.
+func nextSegment(path string, from int) int {
+	depth := 0
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\':
+			i++ // the escaped character cannot start a segment
+
+		case depth > 0 && (c == '\'' || c == '"'):
+			// skip a quoted name or string literal
+			for i++; i < len(path) && path[i] != c; i++ {
+				if path[i] == '\\' {
+					i++
+				}
+			}
+
+		case c == '/' && strings.HasSuffix(strings.TrimRight(path[:i], " \t\n\r"), "~"):
+			// skip a regular expression literal, in a filter or selecting keys
+			class := false
+			for i++; i < len(path) && (class || path[i] != '/'); i++ {
+				switch path[i] {
+				case '\\':
+					i++
+				case '[':
+					class = true
+				case ']':
+					class = false
+				}
+			}
+
+		case c == '[':
+			if depth == 0 && i >= from {
+				return i
+			}
+			depth++
+
+		case c == ']':
+			if depth > 0 {
+				depth--
+			}
+
+		case c == '.' && depth == 0 && i >= from && (i == 0 || path[i-1] != '.'):
+			return i
+		}
+	}
+	return -1
+}
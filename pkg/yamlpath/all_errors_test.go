@@ -0,0 +1,129 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+)
+
+func TestCompileAllErrors(t *testing.T) {
+	cases := []struct {
+		name           string
+		path           string
+		opts           []yamlpath.Option
+		expectedErrors []string
+	}{
+		{
+			name: "valid path",
+			path: "$.a[?(@.b=~/[/]/)].c",
+		},
+		{
+			name: "two independent errors",
+			path: "$.a[x].b.c[y]",
+			expectedErrors: []string{
+				"invalid array index [x] before position 6: non-integer array index",
+				"invalid array index [y] before position 13: non-integer array index",
+			},
+		},
+		{
+			name: "errors in filters",
+			path: "$.a[?(@.b==)].c[?(@.d=~/[)]/ && @.e=~/(/)].f",
+			expectedErrors: []string{
+				`invalid filter term at position 11, following "=="`,
+				"invalid regular expression at position 37, following \"=~\": error parsing regexp: missing closing ): `(`",
+			},
+		},
+		{
+			name: "error after recursive descent",
+			path: "$..a[1:x]..b['c'][?(@.d==1x)]",
+			expectedErrors: []string{
+				"invalid array index [1:x] before position 9: non-integer array index",
+				`invalid number literal "1x" at position 25`,
+			},
+		},
+		{
+			name: "error extending to end of path",
+			path: "$.a[x].b['c].d[0]",
+			expectedErrors: []string{
+				"invalid array index [x] before position 6: non-integer array index",
+				`unmatched "'" at position 17, following ".b['c].d[0]"`,
+			},
+		},
+		{
+			name: "error after key regular expression",
+			path: "$.a[x].~/[.[]/[y]",
+			expectedErrors: []string{
+				"invalid array index [x] before position 6: non-integer array index",
+				"invalid array index [y] before position 17: non-integer array index",
+			},
+		},
+		{
+			name: "padded path",
+			path: "  $.a[x].b[y]\n",
+			expectedErrors: []string{
+				"invalid array index [x] before position 6: non-integer array index",
+				"invalid array index [y] before position 11: non-integer array index",
+			},
+		},
+		{
+			name: "single error",
+			path: "$.a.b[",
+			expectedErrors: []string{
+				`unmatched [ at position 6, following ".b["`,
+			},
+		},
+		{
+			name: "error in filter structure",
+			path: "$.a[?(@.b && )].c",
+			expectedErrors: []string{
+				`invalid filter "@.b &&": missing second operand for binary operator &&`,
+			},
+		},
+		{
+			name: "missing explicit root",
+			path: "a[x]",
+			opts: []yamlpath.Option{yamlpath.WithRequireExplicitRoot()},
+			expectedErrors: []string{
+				`path "a[x]" does not start with $`,
+				"invalid array index [x] before position 4: non-integer array index",
+			},
+		},
+		{
+			name: "inverted slice",
+			path: "$.a[5:2]",
+			opts: []yamlpath.Option{yamlpath.WithStrictSlices()},
+			expectedErrors: []string{
+				"invalid array index [5:2]: slice 5:2 is empty since its start is greater than its end and its step is positive",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, errs := yamlpath.CompileAllErrors(tc.path, tc.opts...)
+			_, err := yamlpath.NewPath(tc.path, tc.opts...)
+			if len(tc.expectedErrors) == 0 {
+				require.Empty(t, errs)
+				require.NoError(t, err)
+				require.NotNil(t, p)
+				require.Equal(t, tc.path, p.String())
+				return
+			}
+			require.Nil(t, p)
+
+			messages := []string{}
+			for _, e := range errs {
+				messages = append(messages, e.Error())
+			}
+			require.Equal(t, tc.expectedErrors, messages)
+			require.EqualError(t, err, tc.expectedErrors[0], "first error differs from that of NewPath")
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// FindAnchors applies the Path to the given root node and returns the nodes which declare an anchor, such as
+// `&base`, among the matches and their descendants. If any names are given, only nodes which declare an anchor with
+// one of those names are returned. So, for example, applying the path `$` finds every anchor in a document.
+//
+// The nodes are returned in document order within each match and each node is returned only once. Aliases are not
+// followed, so an anchor whose definition is reached only through an alias is not returned. See FindAliases.
+func (p *Path) FindAnchors(root *yaml.Node, names ...string) ([]*yaml.Node, error) {
+	return p.findDescendants(root, func(n *yaml.Node) bool {
+		return n.Anchor != "" && hasName(n.Anchor, names)
+	})
+}
+
+// FindAliases applies the Path to the given root node and returns the alias nodes, such as `*base`, among the
+// matches and their descendants. If any names are given, only aliases of anchors with one of those names are
+// returned. The anchored node of an alias is its Alias field.
+//
+// The nodes are returned in document order within each match and each node is returned only once. See FindAnchors.
+func (p *Path) FindAliases(root *yaml.Node, names ...string) ([]*yaml.Node, error) {
+	return p.findDescendants(root, func(n *yaml.Node) bool {
+		return n.Kind == yaml.AliasNode && hasName(n.Value, names)
+	})
+}
+
+// findDescendants returns the nodes satisfying the given predicate among the matches of the Path and their
+// descendants, without following aliases.
+func (p *Path) findDescendants(root *yaml.Node, accept func(*yaml.Node) bool) ([]*yaml.Node, error) {
+	results, err := p.Find(root)
+	if err != nil {
+		return nil, err
+	}
+
+	found := []*yaml.Node{}
+	seen := map[*yaml.Node]bool{}
+	var visit func(n *yaml.Node)
+	visit = func(n *yaml.Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		if accept(n) {
+			found = append(found, n)
+		}
+		for _, c := range n.Content {
+			visit(c)
+		}
+	}
+	for _, r := range results {
+		visit(r)
+	}
+	return found, nil
+}
+
+// hasName returns true if and only if there are no names or the given name is one of them.
+func hasName(name string, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
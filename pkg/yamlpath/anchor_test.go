@@ -0,0 +1,147 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+const anchorsDocument = `---
+defaults:
+  resources: &resources
+    cpu: 1
+  image: &image nginx
+services:
+- name: web
+  image: *image
+  resources: *resources
+- name: worker
+  image: *image
+  env: &env {mode: batch}
+- name: cron
+  resources: *resources
+  env: *env
+`
+
+func TestFindAnchors(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(anchorsDocument), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		names           []string
+		expectedStrings []string
+	}{
+		{
+			name:            "all anchors",
+			path:            "$",
+			expectedStrings: []string{"&resources\ncpu: 1\n", "&image nginx\n", "&env {mode: batch}\n"},
+		},
+		{
+			name:            "anchors with given name",
+			path:            "$",
+			names:           []string{"image"},
+			expectedStrings: []string{"&image nginx\n"},
+		},
+		{
+			name:            "anchors with several names",
+			path:            "$",
+			names:           []string{"env", "resources", "nosuch"},
+			expectedStrings: []string{"&resources\ncpu: 1\n", "&env {mode: batch}\n"},
+		},
+		{
+			name:            "anchors in matches",
+			path:            "$.services[*]",
+			expectedStrings: []string{"&env {mode: batch}\n"},
+		},
+		{
+			name:            "anchored match",
+			path:            "$.defaults.image",
+			expectedStrings: []string{"&image nginx\n"},
+		},
+		{
+			name:            "overlapping matches",
+			path:            "$..*",
+			expectedStrings: []string{"&resources\ncpu: 1\n", "&image nginx\n", "&env {mode: batch}\n"},
+		},
+		{
+			name:            "no anchors",
+			path:            "$.services[0].name",
+			expectedStrings: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.FindAnchors(&n, tc.names...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindAliases(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(anchorsDocument), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		path          string
+		names         []string
+		expectedLines []int
+	}{
+		{
+			name:          "all aliases",
+			path:          "$",
+			expectedLines: []int{8, 9, 11, 14, 15},
+		},
+		{
+			name:          "aliases of given anchor",
+			path:          "$",
+			names:         []string{"resources"},
+			expectedLines: []int{9, 14},
+		},
+		{
+			name:          "aliases in matches",
+			path:          "$.services[?(@.name=='cron')]",
+			names:         []string{"env", "image"},
+			expectedLines: []int{15},
+		},
+		{
+			name:          "no aliases",
+			path:          "$.defaults",
+			expectedLines: []int{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.FindAliases(&n, tc.names...)
+			require.NoError(t, err)
+			lines := []int{}
+			for _, a := range actual {
+				require.Equal(t, yaml.AliasNode, a.Kind)
+				require.Equal(t, a.Value, a.Alias.Anchor)
+				lines = append(lines, a.Line)
+			}
+			require.Equal(t, tc.expectedLines, lines)
+		})
+	}
+}
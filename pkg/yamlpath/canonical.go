@@ -0,0 +1,142 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// String returns the canonical form of the Path. Equivalent paths, such as `a.b`, `$.a['b']`, and `$["a"].b`,
+// have the same canonical form and constructing a Path from the canonical form produces an equivalent Path.
+//
+// The canonical form always starts with an explicit root `$`, uses dot notation for child names consisting
+// only of letters, digits, `_`, and `-` and single-quoted bracket notation for other child names, and
+// normalises array subscripts by removing whitespace, redundant signs, and a redundant step of 1.
+func (p *Path) String() string {
+	return canonical(lexAll(p.expr))
+}
+
+// Equal returns true if and only if the Path is equivalent to the other Path, that is if both have the same
+// canonical form (see String) and were constructed with the same options. For example, `$.a['b']` is equal
+// to `$['a'].b`.
+func (p *Path) Equal(other *Path) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.String() == other.String() && *p.opts == *other.opts
+}
+
+// canonical returns the canonical form of the given lexemes.
+func canonical(lexemes []lexeme) string {
+	var s strings.Builder
+	for _, lx := range lexemes {
+		switch lx.typ {
+		case lexemeError:
+			return lx.val // should not happen, since the Path was constructed successfully
+
+		case lexemeDotChild:
+			s.WriteString(canonicalChild(unescape(strings.TrimPrefix(lx.val, dot))))
+
+		case lexemeUndottedChild:
+			s.WriteString(canonicalChild(unescape(lx.val)))
+
+		case lexemeBracketChild:
+			s.WriteString(canonicalBracketChild(bracketChildNamesOf(lx.val)))
+
+		case lexemeArraySubscript:
+			s.WriteString(leftBracket + canonicalSubscript(strings.TrimSuffix(strings.TrimPrefix(lx.val, leftBracket), rightBracket)) + rightBracket)
+
+		case lexemePropertyName:
+			childName := strings.TrimSuffix(strings.TrimPrefix(lx.val, dot), propertyName)
+			s.WriteString(canonicalChild(unescape(childName)) + propertyName)
+
+		case lexemeBracketPropertyName:
+			s.WriteString(canonicalBracketChild(bracketChildNamesOf(strings.TrimSuffix(lx.val, propertyName))) + propertyName)
+
+		case lexemeFilterAnd, lexemeFilterOr, lexemeFilterBetween, lexemeFilterBetweenAnd, lexemeFilterModulo:
+			s.WriteString(" " + lx.val + " ")
+
+		default:
+			s.WriteString(lx.val)
+		}
+	}
+	return s.String()
+}
+
+// bracketChildNamesOf returns the unquoted child names of a bracket child lexeme value such as `['a',"b"]`.
+func bracketChildNamesOf(val string) []string {
+	childNames := strings.TrimSpace(val)
+	childNames = strings.TrimSuffix(strings.TrimPrefix(childNames, leftBracket), rightBracket)
+	return bracketChildNames(strings.TrimSpace(childNames))
+}
+
+func canonicalChild(childName string) string {
+	if childName == "*" || isPlainChildName(childName) {
+		return dot + childName
+	}
+	return canonicalBracketChild([]string{childName})
+}
+
+func canonicalBracketChild(childNames []string) string {
+	if len(childNames) == 1 && isPlainChildName(childNames[0]) {
+		return dot + childNames[0]
+	}
+	quoted := []string{}
+	for _, c := range childNames {
+		c = strings.ReplaceAll(c, `\`, `\\`)
+		c = strings.ReplaceAll(c, "'", `\'`)
+		quoted = append(quoted, "'"+c+"'")
+	}
+	return leftBracket + strings.Join(quoted, ",") + rightBracket
+}
+
+// isPlainChildName returns true if and only if the given child name may be written unambiguously in dot notation.
+func isPlainChildName(childName string) bool {
+	if childName == "" {
+		return false
+	}
+	for _, r := range childName {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalSubscript returns the canonical form of an array subscript, such as `1:3`, without its brackets.
+func canonicalSubscript(subscript string) string {
+	members := []string{}
+	for _, m := range strings.Split(subscript, ",") {
+		m = strings.TrimSpace(m)
+		if m == "*" {
+			members = append(members, m)
+			continue
+		}
+		parts := strings.Split(m, ":")
+		for i, part := range parts {
+			parts[i] = canonicalInteger(strings.TrimSpace(part))
+		}
+		if len(parts) == 3 && (parts[2] == "" || parts[2] == "1") {
+			parts = parts[:2]
+		}
+		members = append(members, strings.Join(parts, ":"))
+	}
+	return strings.Join(members, ",")
+}
+
+func canonicalInteger(s string) string {
+	if s == "" {
+		return s
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s // should not happen, since the lexer validates array subscripts
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"github.com/dprotaso/go-yit"
+	"gopkg.in/yaml.v3"
+)
+
+// NodeComments holds the comments attached to a node matched by a Path.
+type NodeComments struct {
+	Node        *yaml.Node
+	HeadComment string
+	LineComment string
+	FootComment string
+}
+
+// FindComments is like Find except that it returns the comments attached to each matching node.
+//
+// The YAML parser attaches the comments preceding and following an entry of a mapping to the key of the entry
+// rather than to its value, so the comments of a matching value in a mapping include any comments attached to its key.
+func (p *Path) FindComments(node *yaml.Node) ([]NodeComments, error) {
+	results, err := p.Find(node)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := mappingKeys(node)
+	comments := []NodeComments{}
+	for _, r := range results {
+		c := NodeComments{
+			Node:        r,
+			HeadComment: r.HeadComment,
+			LineComment: r.LineComment,
+			FootComment: r.FootComment,
+		}
+		if key, ok := keys[r]; ok {
+			if c.HeadComment == "" {
+				c.HeadComment = key.HeadComment
+			}
+			if c.LineComment == "" {
+				c.LineComment = key.LineComment
+			}
+			if c.FootComment == "" {
+				c.FootComment = key.FootComment
+			}
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+// mappingKeys returns a map from each value of a mapping in the given node, or its descendants, to the value's key.
+func mappingKeys(node *yaml.Node) map[*yaml.Node]*yaml.Node {
+	keys := map[*yaml.Node]*yaml.Node{}
+	it := yit.FromNode(node).RecurseNodes()
+	for n, ok := it(); ok; n, ok = it() {
+		if n.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			keys[n.Content[i+1]] = n.Content[i]
+		}
+	}
+	return keys
+}
@@ -6,7 +6,14 @@
 
 package yamlpath
 
-import "strconv"
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type comparison int
 
@@ -73,10 +80,17 @@ func compareFloat64(lhs, rhs float64) comparison {
 	return compareEqual
 }
 
-// compareNodeValues compares two values each of which may be a string, integer, or float
+// compareNodeValues compares two values each of which may be a string, integer, or float. Numbers are compared
+// numerically, whatever their notation, but NaN is incomparable, even with itself, as is a number whose value cannot
+// be parsed, such as that of the node `!!int abc`.
 func compareNodeValues(lhs, rhs typedValue) comparison {
 	if lhs.typ.isNumeric() && rhs.typ.isNumeric() {
-		return compareFloat64(mustParseFloat64(lhs.val), mustParseFloat64(rhs.val))
+		l, lok := parseFloat64(lhs.val)
+		r, rok := parseFloat64(rhs.val)
+		if !lok || !rok || math.IsNaN(l) || math.IsNaN(r) {
+			return compareIncomparable
+		}
+		return compareFloat64(l, r)
 	}
 	if (lhs.typ != stringValueType && !lhs.typ.isNumeric()) || (rhs.typ != stringValueType && !rhs.typ.isNumeric()) {
 		panic("invalid type of value passed to compareNodeValues") // should never happen
@@ -84,10 +98,58 @@ func compareNodeValues(lhs, rhs typedValue) comparison {
 	return compareStrings(lhs.val, rhs.val)
 }
 
-func mustParseFloat64(s string) float64 {
+// parseFloat64 parses a number literal or the value of a YAML integer or float, including those in notations which
+// strconv.ParseFloat does not accept, such as 0x1F, 0o17, 1_000, and .inf, and returns the number and true or, if the
+// value is not a number, false. A decimal integer with a leading zero, such as 010, is parsed as a decimal. An integer
+// too large for an int64, such as 0xFFFFFFFFFFFFFFFF, is parsed, possibly with some loss of precision.
+func parseFloat64(s string) (float64, bool) {
 	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		panic("invalid numeric value " + s) // should never happen
+	if err == nil || errors.Is(err, strconv.ErrRange) {
+		return f, true // a number which is out of range is infinite
+	}
+	var i big.Int
+	if _, ok := i.SetString(s, 0); ok {
+		var f big.Float
+		v, _ := f.SetInt(&i).Float64()
+		return v, true
+	}
+	switch strings.ToLower(strings.TrimPrefix(s, "+")) {
+	case ".inf":
+		return math.Inf(1), true
+	case "-.inf":
+		return math.Inf(-1), true
+	case ".nan":
+		return math.NaN(), true
+	}
+	return 0, false
+}
+
+// timestampLayouts are the layouts of the strings which parseTimestamp recognises.
+var timestampLayouts = []string{
+	time.RFC3339Nano, // including RFC 3339 without fractional seconds
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseTimestamp parses a date or a date and time in one of the layouts of YAML timestamps and RFC 3339. A time
+// without a time zone is taken to be UTC.
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
 	}
-	return f
+	return time.Time{}, false
+}
+
+func compareTimes(lhs, rhs time.Time) comparison {
+	if lhs.Before(rhs) {
+		return compareLessThan
+	}
+	if lhs.After(rhs) {
+		return compareGreaterThan
+	}
+	return compareEqual
 }
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// DeepCopyNode returns a copy of the given node and, recursively, its content, so that the copy may be modified, for
+// example using the nodes returned by Find or passed to the visitor of Walk, without affecting the given node. The
+// copy has the same kind, style, tag, value, anchor, comments, and position as the given node. DeepCopyNode returns
+// nil if the given node is nil.
+//
+// A node which occurs more than once in the given node is copied only once, so the copy has the same structure. An
+// alias node in the copy refers to the copy of its anchored node, which is also copied if it is not part of the given
+// node.
+func DeepCopyNode(n *yaml.Node) *yaml.Node {
+	return deepCopyNode(n, map[*yaml.Node]*yaml.Node{})
+}
+
+// deepCopyNode copies the given node using the given copies of nodes which have already been copied.
+func deepCopyNode(n *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if c, ok := copies[n]; ok {
+		return c
+	}
+
+	c := &yaml.Node{}
+	*c = *n
+	copies[n] = c
+	if n.Content != nil {
+		c.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			c.Content[i] = deepCopyNode(child, copies)
+		}
+	}
+	c.Alias = deepCopyNode(n.Alias, copies)
+	return c
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDeepCopyNode(t *testing.T) {
+	y := `# head comment
+base: &base
+  image: nginx # line comment
+  ports: [80, 443]
+services:
+- name: web
+  <<: *base
+- name: !custom "api"
+  config: |
+    literal
+    text
+# foot comment
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	c := yamlpath.DeepCopyNode(&n)
+	require.Equal(t, &n, c)
+	requireDistinct(t, &n, c)
+
+	expected, err := yaml.Marshal(&n)
+	require.NoError(t, err)
+	actual, err := yaml.Marshal(c)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+
+	// the alias of the copy refers to the copy of the anchored node
+	p, err := yamlpath.NewPath("$.base")
+	require.NoError(t, err)
+	base, err := p.Find(c)
+	require.NoError(t, err)
+	require.Len(t, base, 1)
+	alias := c.Content[0].Content[3].Content[0].Content[3]
+	require.Equal(t, yaml.AliasNode, alias.Kind)
+	require.Same(t, base[0], alias.Alias)
+
+	// modifying the copy does not affect the original
+	p, err = yamlpath.NewPath("$.services[*].name")
+	require.NoError(t, err)
+	names, err := p.Find(c)
+	require.NoError(t, err)
+	for _, name := range names {
+		name.Value = "changed"
+	}
+	base[0].Content = base[0].Content[:2]
+	actual, err = yaml.Marshal(&n)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+
+	require.Nil(t, yamlpath.DeepCopyNode(nil))
+}
+
+func TestDeepCopyNodeAliasOutsideNode(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{a: &x {b: 1}, c: [*x, *x]}`), &n)
+	require.NoError(t, err)
+
+	seq := n.Content[0].Content[3]
+	c := yamlpath.DeepCopyNode(seq)
+	require.Equal(t, seq, c)
+	requireDistinct(t, seq, c)
+	require.Same(t, c.Content[0].Alias, c.Content[1].Alias)
+}
+
+// requireDistinct checks that no node reachable from the given duplicate is also reachable from the given original.
+func requireDistinct(t *testing.T, original, duplicate *yaml.Node) {
+	nodes := map[*yaml.Node]bool{}
+	var collect func(n *yaml.Node)
+	collect = func(n *yaml.Node) {
+		if n == nil || nodes[n] {
+			return
+		}
+		nodes[n] = true
+		for _, c := range n.Content {
+			collect(c)
+		}
+		collect(n.Alias)
+	}
+	collect(original)
+
+	var check func(n *yaml.Node, seen map[*yaml.Node]bool)
+	check = func(n *yaml.Node, seen map[*yaml.Node]bool) {
+		if n == nil || seen[n] {
+			return
+		}
+		seen[n] = true
+		require.False(t, nodes[n], "copy shares node %v with original", n)
+		for _, c := range n.Content {
+			check(c, seen)
+		}
+		check(n.Alias, seen)
+	}
+	check(duplicate, map[*yaml.Node]bool{})
+}
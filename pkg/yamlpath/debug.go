@@ -0,0 +1,91 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DebugString returns an indented, human-readable description of how the Path was parsed, for diagnosing why a
+// path behaves unexpectedly and for including in bug reports. Each line consists of the kind of a token, as returned
+// by Tokenize, followed by its quoted text.
+//
+// The root and each segment of the Path appear on a line of their own. A filter is followed by its parse tree,
+// indented, with each operator followed by its operands, indented further, and each `@`, `@^`, `$`, or binding term
+// followed by the segments of its subpath, so that, for example, `$[?(@.a == 1 || @.b)]` produces:
+//
+//	Root "$"
+//	FilterBegin "[?("
+//	  FilterOr "||"
+//	    FilterEquality "=="
+//	      FilterAt "@"
+//	        DotChild ".a"
+//	      FilterIntegerLiteral "1"
+//	    FilterAt "@"
+//	      DotChild ".b"
+//	FilterEnd ")]"
+//
+// The format of the description is intended for people rather than programs and may change between releases.
+func (p *Path) DebugString() string {
+	var s strings.Builder
+	debugLexemes(&s, lexAll(p.expr), 0)
+	return s.String()
+}
+
+// debugLexemes writes a description of the given path lexemes, indented to the given depth, parsing and describing
+// the lexemes of each filter.
+func debugLexemes(s *strings.Builder, lexemes []lexeme, depth int) {
+	for i := 0; i < len(lexemes); i++ {
+		lx := lexemes[i]
+		switch lx.typ {
+		case lexemeIdentity, lexemeEOF:
+			continue
+
+		case lexemeFilterBegin, lexemeRecursiveFilterBegin:
+			debugLexeme(s, lx, depth)
+			filterLexemes := []lexeme{}
+			filterNestingLevel := 1
+		f:
+			for i++; i < len(lexemes); i++ {
+				switch lexemes[i].typ {
+				case lexemeFilterBegin, lexemeRecursiveFilterBegin:
+					filterNestingLevel++
+				case lexemeFilterEnd:
+					filterNestingLevel--
+					if filterNestingLevel == 0 {
+						break f
+					}
+				}
+				filterLexemes = append(filterLexemes, lexemes[i])
+			}
+			debugFilterNode(s, newFilterNode(filterLexemes), depth+1)
+			if i < len(lexemes) {
+				debugLexeme(s, lexemes[i], depth)
+			}
+
+		default:
+			debugLexeme(s, lx, depth)
+		}
+	}
+}
+
+// debugFilterNode writes a description of the given filter parse tree, indented to the given depth.
+func debugFilterNode(s *strings.Builder, n *filterNode, depth int) {
+	if n == nil {
+		return
+	}
+	debugLexeme(s, n.lexeme, depth)
+	debugLexemes(s, n.subpath, depth+1)
+	for _, c := range n.children {
+		debugFilterNode(s, c, depth+1)
+	}
+}
+
+func debugLexeme(s *strings.Builder, lx lexeme, depth int) {
+	fmt.Fprintf(s, "%s%s %q\n", strings.Repeat("  ", depth), tokenKindOf(lx.typ), lx.val)
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+)
+
+func TestDebugString(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{
+			name:     "segments",
+			path:     "a..b[0]['c']",
+			expected: "Root \"$\"\nUndottedChild \"a\"\nRecursiveDescent \"..b\"\nArraySubscript \"[0]\"\nBracketChild \"['c']\"\n",
+		},
+		{
+			name: "filter grouping",
+			path: "$.x[?(@.a == 1 || @.b && !(@.c))].y",
+			expected: `Root "$"
+DotChild ".x"
+FilterBegin "[?("
+  FilterOr "||"
+    FilterEquality "=="
+      FilterAt "@"
+        DotChild ".a"
+      FilterIntegerLiteral "1"
+    FilterAnd "&&"
+      FilterAt "@"
+        DotChild ".b"
+      FilterNot "!"
+        FilterAt "@"
+          DotChild ".c"
+FilterEnd ")]"
+DotChild ".y"
+`,
+		},
+		{
+			name: "nested filter and function call",
+			path: "$[?(lower($.n) =~ /a/i && @.x[?(@.y > 2)])]",
+			expected: `Root "$"
+FilterBegin "[?("
+  FilterAnd "&&"
+    FilterMatchesRegularExpression "=~"
+      FilterFunctionCall "lower("
+        Root "$"
+          DotChild ".n"
+      FilterRegularExpressionLiteral "/a/i"
+    FilterAt "@"
+      DotChild ".x"
+      FilterBegin "[?("
+        FilterGreaterThan ">"
+          FilterAt "@"
+            DotChild ".y"
+          FilterIntegerLiteral "2"
+      FilterEnd ")]"
+FilterEnd ")]"
+`,
+		},
+		{
+			name:     "relative path",
+			path:     "@.a",
+			expected: "FilterAt \"@\"\nDotChild \".a\"\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var p *yamlpath.Path
+			var err error
+			if tc.path[0] == '@' {
+				p, err = yamlpath.NewRelativePath(tc.path)
+			} else {
+				p, err = yamlpath.NewPath(tc.path)
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, p.DebugString())
+		})
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/dprotaso/go-yit"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrMaxDepthExceeded is returned by Find when a recursive descent would visit a node nested more deeply than
+// the maximum depth. See WithMaxDepth.
+var ErrMaxDepthExceeded = errors.New("maximum depth exceeded")
+
+// ErrTypeMismatch is returned by Find when a child name is applied to a node which is not a mapping or an array
+// subscript is applied to a node which is not a sequence. See WithStrictTypes. It is also returned by FindScalar
+// when the match is not a scalar.
+var ErrTypeMismatch = errors.New("node type mismatch")
+
+// evaluation holds the state of a single application of a Path to a YAML node. Unlike options, an evaluation is
+// never shared by concurrent applications of the same Path.
+type evaluation struct {
+	ctx      context.Context
+	bindings map[string]*yaml.Node     // the nodes referred to by names such as $params in filters
+	regexps  map[string]*regexp.Regexp // the regular expressions referred to by names such as $pattern in filters
+	compiled map[string]*regexp.Regexp // the regular expressions compiled by the evaluation, by source
+	shared   *yaml.Node                // if not nil, the node referred to by $ in filters rather than the root
+	index    int                       // the index of the current node in the sequence being filtered, or -1
+	warnings *[]Warning                // if not nil, the warnings about the evaluation, which are recorded by warn
+	parents  map[*yaml.Node]*yaml.Node // the parent of each node below the root, computed when first needed
+	steps    int                       // the number of steps since the context was last checked
+	err      error                     // the first error which occurred, after which evaluation stops
+}
+
+// contextCheckInterval is the number of steps of an evaluation between checks of its context.
+const contextCheckInterval = 1000
+
+func newEvaluation(ctx context.Context) *evaluation {
+	return &evaluation{
+		ctx:   ctx,
+		index: -1,
+	}
+}
+
+// step is called for each node visited by the evaluation and periodically checks whether the context of the
+// evaluation is done. It returns false if and only if the evaluation has failed, in which case the caller should
+// stop visiting nodes.
+func (e *evaluation) step() bool {
+	if e.err != nil {
+		return false
+	}
+	e.steps++
+	if e.steps >= contextCheckInterval && e.ctx != nil {
+		e.steps = 0
+		if err := e.ctx.Err(); err != nil {
+			e.fail(err)
+			return false
+		}
+	}
+	return true
+}
+
+// ancestor returns the ancestor of the given node the given number of generations above it in the document with the
+// given root or, if there is no such ancestor, nil. A node reached only through an alias has no ancestor.
+func (e *evaluation) ancestor(node, root *yaml.Node, generations int) *yaml.Node {
+	if e.parents == nil {
+		e.parents = map[*yaml.Node]*yaml.Node{}
+		var walk func(n *yaml.Node)
+		walk = func(n *yaml.Node) {
+			for _, c := range n.Content {
+				if _, seen := e.parents[c]; !seen {
+					e.parents[c] = n
+					walk(c)
+				}
+			}
+		}
+		walk(root)
+	}
+	for i := 0; i < generations && node != nil; i++ {
+		node = e.parents[node]
+	}
+	return node
+}
+
+// key returns the key of the given node in its parent, in the document with the given root, if the parent is a mapping
+// and the node is one of its values, and otherwise nil.
+func (e *evaluation) key(node, root *yaml.Node) *yaml.Node {
+	parent := e.ancestor(node, root, 1)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 1; i < len(parent.Content); i += 2 {
+		if parent.Content[i] == node {
+			return parent.Content[i-1]
+		}
+	}
+	return nil
+}
+
+// compile compiles the given regular expression, or returns the result of compiling it earlier in the evaluation, so
+// that a regular expression bound to a name is compiled just once however many nodes it is matched against.
+func (e *evaluation) compile(expr string) (*regexp.Regexp, error) {
+	if re, ok := e.compiled[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if e.compiled == nil {
+		e.compiled = map[string]*regexp.Regexp{}
+	}
+	e.compiled[expr] = re
+	return re, nil
+}
+
+// warn records the given warning, unless the same warning has already been recorded, if the evaluation records
+// warnings. See FindWithWarnings.
+func (e *evaluation) warn(w Warning) {
+	if e.warnings == nil {
+		return
+	}
+	for _, recorded := range *e.warnings {
+		if recorded == w {
+			return
+		}
+	}
+	*e.warnings = append(*e.warnings, w)
+}
+
+func (e *evaluation) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// recurseNodes returns an iterator over the given node and all its descendants, in the same order as yit's
+// RecurseNodes, except that the evaluation fails if there is a descendant more than maxDepth levels below the
+// given node.
+func recurseNodes(node *yaml.Node, maxDepth int, e *evaluation) yit.Iterator {
+	type entry struct {
+		node  *yaml.Node
+		depth int
+	}
+	stack := []entry{{node, 0}}
+
+	return func() (*yaml.Node, bool) {
+		if len(stack) == 0 || !e.step() {
+			return nil, false
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if len(top.node.Content) > 0 && top.depth >= maxDepth {
+			e.fail(fmt.Errorf("%w: recursive descent exceeded depth %d", ErrMaxDepthExceeded, maxDepth))
+			return nil, false
+		}
+		// push the children in reverse so they are visited in order
+		for i := len(top.node.Content) - 1; i >= 0; i-- {
+			stack = append(stack, entry{top.node.Content[i], top.depth + 1})
+		}
+		return top.node, true
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dprotaso/go-yit"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRecurseNodes(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{a: [1, {b: 2}], c: {d: [3, [4]]}, e: 5}`), &n)
+	require.NoError(t, err)
+
+	// recurseNodes visits the nodes in the same order as yit
+	e := &evaluation{}
+	require.Equal(t, yit.FromNode(&n).RecurseNodes().ToArray(), recurseNodes(&n, defaultMaxDepth, e).ToArray())
+	require.NoError(t, e.err)
+}
+
+func TestRecurseNodesMaxDepth(t *testing.T) {
+	cases := []struct {
+		name     string
+		yamlDoc  string
+		maxDepth int
+		ok       bool
+	}{
+		{
+			name:     "scalar within limit",
+			yamlDoc:  `1`,
+			maxDepth: 1, // document, scalar
+			ok:       true,
+		},
+		{
+			name:     "scalar beyond limit",
+			yamlDoc:  `1`,
+			maxDepth: 0,
+			ok:       false,
+		},
+		{
+			name:     "within limit",
+			yamlDoc:  `[[[1]]]`,
+			maxDepth: 4, // document, sequence, sequence, sequence, scalar
+			ok:       true,
+		},
+		{
+			name:     "beyond limit",
+			yamlDoc:  `[[[1]]]`,
+			maxDepth: 3,
+			ok:       false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var n yaml.Node
+			err := yaml.Unmarshal([]byte(tc.yamlDoc), &n)
+			require.NoError(t, err)
+			e := &evaluation{}
+			recurseNodes(&n, tc.maxDepth, e).ToArray()
+			if tc.ok {
+				require.NoError(t, e.err)
+			} else {
+				require.True(t, errors.Is(e.err, ErrMaxDepthExceeded))
+			}
+		})
+	}
+}
+
+func TestEvaluationContext(t *testing.T) {
+	var doc strings.Builder
+	for i := 0; i < 10*contextCheckInterval; i++ {
+		fmt.Fprintf(&doc, "- {a: %d, b: [%d]}\n", i, i)
+	}
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(doc.String()), &n)
+	require.NoError(t, err)
+
+	p, err := NewPath("$..*[?(@.a>=0)]")
+	require.NoError(t, err)
+
+	// an evaluation with a live context visits every node
+	e := newEvaluation(context.Background())
+	require.Len(t, p.find(&n, &n, e), 10*contextCheckInterval)
+	require.NoError(t, e.err)
+
+	// an evaluation whose context is done stops after at most contextCheckInterval steps
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e = newEvaluation(ctx)
+	results := p.find(&n, &n, e)
+	require.Equal(t, context.Canceled, e.err)
+	require.Less(t, len(results), contextCheckInterval)
+}
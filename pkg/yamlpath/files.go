@@ -0,0 +1,99 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileError is an error reading or parsing one of the files given to FindInFiles.
+type FileError struct {
+	Filename string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	return e.Filename + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors is the error returned by FindInFiles when some of the files cannot be read or parsed.
+type FileErrors []*FileError
+
+func (e FileErrors) Error() string {
+	messages := []string{}
+	for _, fe := range e {
+		messages = append(messages, fe.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FindInFiles constructs a Path from the given string expression and options, as NewPath does, reads and parses each
+// of the given YAML files, and applies the Path to each document in each file. It returns the matches in each file, in the order
+// of the documents in the file, keyed by the name of the file. A file containing no document has no matches.
+//
+// A file which cannot be read or parsed, or to which the Path cannot be applied, is skipped and has no entry in the
+// result, so that a single bad file does not prevent the others from being searched. If any file is skipped,
+// FindInFiles returns the matches in the other files together with an error of type FileErrors, which has a
+// FileError for each skipped file in the order in which the files were given. If the path is invalid, FindInFiles
+// returns only the error.
+func FindInFiles(path string, filenames []string, opts ...Option) (map[string][]*yaml.Node, error) {
+	p, err := NewPath(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string][]*yaml.Node{}
+	var fileErrors FileErrors
+	for _, filename := range filenames {
+		matches, err := p.findInFile(filename)
+		if err != nil {
+			fileErrors = append(fileErrors, &FileError{Filename: filename, Err: err})
+			continue
+		}
+		results[filename] = matches
+	}
+	if len(fileErrors) > 0 {
+		return results, fileErrors
+	}
+	return results, nil
+}
+
+// findInFile applies the Path to each document in the given YAML file and returns the matches.
+func (p *Path) findInFile(filename string) ([]*yaml.Node, error) {
+	// ioutil.ReadFile rather than os.ReadFile, which requires Go 1.16, since the module supports Go 1.13
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*yaml.Node{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return matches, nil
+			}
+			return nil, err
+		}
+		results, err := p.Find(&doc)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, results...)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFindInFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yamlpath")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0o600))
+		return filename
+	}
+	deployment := write("deployment.yaml", `---
+kind: Deployment
+spec:
+  replicas: 2
+---
+kind: Service
+---
+kind: Deployment
+spec:
+  replicas: 3
+`)
+	single := write("single.yaml", "kind: Deployment\nspec: {replicas: 1}\n")
+	empty := write("empty.yaml", "")
+	other := write("other.yaml", "kind: ConfigMap\n")
+	invalid := write("invalid.yaml", "kind: Deployment\nspec: [\n")
+	partlyInvalid := write("partly-invalid.yaml", "spec: {replicas: 4}\n---\n: : :\n  - [\n")
+	missing := filepath.Join(dir, "missing.yaml")
+
+	t.Run("matches keyed by filename", func(t *testing.T) {
+		results, err := yamlpath.FindInFiles("$.spec.replicas", []string{deployment, single, empty, other})
+		require.NoError(t, err)
+
+		actual := map[string][]string{}
+		for filename, matches := range results {
+			actual[filename] = encodeNodes(t, matches)
+		}
+		require.Equal(t, map[string][]string{
+			deployment: {"2\n", "3\n"},
+			single:     {"1\n"},
+			empty:      {},
+			other:      {},
+		}, actual)
+	})
+
+	t.Run("bad files are skipped", func(t *testing.T) {
+		results, err := yamlpath.FindInFiles("$.spec.replicas", []string{invalid, single, missing, partlyInvalid})
+		require.Error(t, err)
+
+		require.Equal(t, []string{single}, keysOf(results))
+		require.Equal(t, []string{"1\n"}, encodeNodes(t, results[single]))
+
+		var fileErrors yamlpath.FileErrors
+		require.True(t, errors.As(err, &fileErrors))
+		require.Len(t, fileErrors, 3)
+		require.Equal(t, invalid, fileErrors[0].Filename)
+		require.EqualError(t, fileErrors[0].Err, "yaml: line 2: did not find expected node content")
+		require.Equal(t, missing, fileErrors[1].Filename)
+		require.True(t, errors.Is(fileErrors[1], os.ErrNotExist))
+		require.Equal(t, partlyInvalid, fileErrors[2].Filename)
+		require.Contains(t, err.Error(), invalid+": yaml: line 2: did not find expected node content; ")
+	})
+
+	t.Run("path which fails", func(t *testing.T) {
+		results, err := yamlpath.FindInFiles("$[?(@.kind == $unbound)]", []string{deployment})
+		require.EqualError(t, err, deployment+": no binding for $unbound")
+		require.Empty(t, results)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		results, err := yamlpath.FindInFiles("spec.replicas", []string{single})
+		require.NoError(t, err)
+		require.Equal(t, []string{"1\n"}, encodeNodes(t, results[single]))
+
+		results, err = yamlpath.FindInFiles("spec.replicas", []string{single}, yamlpath.WithRequireExplicitRoot())
+		require.EqualError(t, err, `path "spec.replicas" does not start with $`)
+		require.Nil(t, results)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		results, err := yamlpath.FindInFiles("$.a[", []string{deployment})
+		require.EqualError(t, err, `unmatched [ at position 4, following ".a["`)
+		require.Nil(t, results)
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		results, err := yamlpath.FindInFiles("$", nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string][]*yaml.Node{}, results)
+	})
+}
+
+func keysOf(m map[string][]*yaml.Node) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -8,53 +8,78 @@ package yamlpath
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-type filter func(node, root *yaml.Node) bool
+type filter func(node, root *yaml.Node, e *evaluation) bool
 
-func newFilter(n *filterNode) filter {
+func newFilter(n *filterNode, o *options) filter {
 	if n == nil {
 		return never
 	}
 
 	switch n.lexeme.typ {
-	case lexemeFilterAt, lexemeRoot:
-		path := pathFilterScanner(n)
-		return func(node, root *yaml.Node) bool {
-			return len(path(node, root)) > 0
+	case lexemeFilterAt, lexemeFilterParent, lexemeRoot, lexemeFilterBinding:
+		// an existence filter, possibly containing nested filters, is true if and only if its path matches
+		// something other than just a boolean false
+		path := pathFilterScanner(n, o)
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			return truthy(path(node, root, e))
 		}
 
-	case lexemeFilterEquality, lexemeFilterInequality,
+	case lexemeFilterEquality, lexemeFilterInequality, lexemeFilterEqualityIgnoringCase,
 		lexemeFilterGreaterThan, lexemeFilterGreaterThanOrEqual,
 		lexemeFilterLessThan, lexemeFilterLessThanOrEqual:
-		return comparisonFilter(n)
+		return comparisonFilter(n, o)
+
+	case lexemeFilterBetween:
+		return betweenFilter(n, o)
 
 	case lexemeFilterMatchesRegularExpression:
-		return matchRegularExpression(n)
+		return matchRegularExpression(n, o)
+
+	case lexemeFilterNotMatchesRegularExpression:
+		f := matchRegularExpression(n, o)
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			return !f(node, root, e)
+		}
 
 	case lexemeFilterNot:
-		f := newFilter(n.children[0])
-		return func(node, root *yaml.Node) bool {
-			return !f(node, root)
+		f := newFilter(n.children[0], o)
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			return !f(node, root, e)
 		}
 
 	case lexemeFilterOr:
-		f1 := newFilter(n.children[0])
-		f2 := newFilter(n.children[1])
-		return func(node, root *yaml.Node) bool {
-			return f1(node, root) || f2(node, root)
+		f1 := newFilter(n.children[0], o)
+		f2 := newFilter(n.children[1], o)
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			return f1(node, root, e) || f2(node, root, e) // f2 is not evaluated if f1 is true
 		}
 
 	case lexemeFilterAnd:
-		f1 := newFilter(n.children[0])
-		f2 := newFilter(n.children[1])
-		return func(node, root *yaml.Node) bool {
-			return f1(node, root) && f2(node, root)
+		f1 := newFilter(n.children[0], o)
+		f2 := newFilter(n.children[1], o)
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			return f1(node, root, e) && f2(node, root, e) // f2 is not evaluated if f1 is false
+		}
+
+	case lexemeFilterFunctionCall:
+		// a function call used as a predicate is true if and only if it returns true
+		call := functionCallScanner(n, o)
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			for _, v := range call(node, root, e) {
+				if v.typ == booleanValueType && equalBooleans(v.val, "true") {
+					return true
+				}
+			}
+			return false
 		}
 
 	case lexemeFilterBooleanLiteral:
@@ -62,7 +87,7 @@ func newFilter(n *filterNode) filter {
 		if err != nil {
 			panic(err) // should not happen
 		}
-		return func(node, root *yaml.Node) bool {
+		return func(node, root *yaml.Node, e *evaluation) bool {
 			return b
 		}
 
@@ -71,11 +96,52 @@ func newFilter(n *filterNode) filter {
 	}
 }
 
-func never(node, root *yaml.Node) bool {
+func never(node, root *yaml.Node, e *evaluation) bool {
 	return false
 }
 
-func comparisonFilter(n *filterNode) filter {
+func comparisonFilter(n *filterNode, o *options) filter {
+	if n.children[1] != nil && n.children[1].isArrayLiteral() {
+		return arrayLiteralFilter(n, o)
+	}
+	return nodeToFilter(n, o, valueComparer(n.lexeme, o))
+}
+
+// betweenFilter returns a filter which is true if and only if the values of the first child of the given range test
+// are greater than or equal to the values of its second child and less than or equal to the values of its third child,
+// compared as the ordering operators >= and <= compare them.
+//
+// If the first child is a relative path containing a recursive descent, the range test is instead true if and only if
+// some value of the first child lies within the range.
+func betweenFilter(n *filterNode, o *options) filter {
+	subject := newFilterScanner(n.children[0], o)
+	lowerBound := newFilterScanner(n.children[1], o)
+	upperBound := newFilterScanner(n.children[2], o)
+	greaterThanOrEqual := valueComparer(lexeme{typ: lexemeFilterGreaterThanOrEqual, val: operatorGreaterThanOrEqual.String()}, o)
+	lessThanOrEqual := valueComparer(lexeme{typ: lexemeFilterLessThanOrEqual, val: operatorLessThanOrEqual.String()}, o)
+	if n.children[0].isDescendantPath() {
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			for _, v := range subject(node, root, e) {
+				value := func(*yaml.Node, *yaml.Node, *evaluation) []typedValue {
+					return []typedValue{v}
+				}
+				if scannersToFilter(value, lowerBound, greaterThanOrEqual)(node, root, e) &&
+					scannersToFilter(value, upperBound, lessThanOrEqual)(node, root, e) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	lower := scannersToFilter(subject, lowerBound, greaterThanOrEqual)
+	upper := scannersToFilter(subject, upperBound, lessThanOrEqual)
+	return func(node, root *yaml.Node, e *evaluation) bool {
+		return lower(node, root, e) && upper(node, root, e)
+	}
+}
+
+// valueComparer returns a function which compares two values using the given comparison operator.
+func valueComparer(operator lexeme, o *options) func(l, r typedValue) bool {
 	compare := func(b bool) bool {
 		var c comparison
 		if b {
@@ -83,9 +149,21 @@ func comparisonFilter(n *filterNode) filter {
 		} else {
 			c = compareIncomparable
 		}
-		return n.lexeme.comparator()(c)
+		return operator.comparator()(c)
 	}
-	return nodeToFilter(n, func(l, r typedValue) bool {
+	return func(l, r typedValue) bool {
+		if o.timeComparison {
+			if lt, ok := l.timestamp(); ok {
+				if rt, ok := r.timestamp(); ok {
+					return operator.comparator()(compareTimes(lt, rt))
+				}
+			}
+		}
+		if operator.typ.isOrdering() {
+			l, r = coerceOrdered(l, r)
+		} else if o.numericCoercion {
+			l, r = coerceNumeric(l, r)
+		}
 		if !l.typ.compatibleWith(r.typ) {
 			return compare(false)
 		}
@@ -96,27 +174,80 @@ func comparisonFilter(n *filterNode) filter {
 		case nullValueType:
 			return compare(equalNulls(l.val, r.val))
 
+		case unknownValueType:
+			return compare(equalNodes(l.node, r.node))
+
+		case stringValueType:
+			if operator.typ == lexemeFilterEqualityIgnoringCase {
+				return compare(strings.EqualFold(l.val, r.val))
+			}
+			return operator.comparator()(compareNodeValues(l, r))
+
 		default:
-			return n.lexeme.comparator()(compareNodeValues(l, r))
+			return operator.comparator()(compareNodeValues(l, r))
+		}
+	}
+}
+
+// arrayLiteralFilter returns a filter which compares the nodes matched by the left hand side of the given comparison
+// with the array literal on its right hand side using `==` or `!=`. A node is equal to the array literal if and only if
+// it is a sequence whose elements are equal, in order, to the elements of the array literal. As for other comparisons,
+// the filter is true if and only if the comparison is true for every node and there is at least one node.
+func arrayLiteralFilter(n *filterNode, o *options) filter {
+	elements, err := arrayLiteralElements(n.children[1].lexeme.val)
+	if err != nil {
+		panic(err) // should not happen, lexer should have detected errors
+	}
+	lhs := argumentScanner(n.children[0], o)
+	equal := valueComparer(lexeme{typ: lexemeFilterEquality, val: filterEquality}, o)
+	return func(node, root *yaml.Node, e *evaluation) bool {
+		match := false
+		for _, l := range lhs(node, root, e) {
+			if equalToArrayLiteral(l.node, elements, equal) != (n.lexeme.typ == lexemeFilterEquality) {
+				return false
+			}
+			match = true
+		}
+		return match
+	}
+}
+
+// equalToArrayLiteral returns true if and only if the given node is a sequence whose elements are equal, according
+// to the given function, to the given elements of an array literal.
+func equalToArrayLiteral(node *yaml.Node, elements []typedValue, equal func(l, r typedValue) bool) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) != len(elements) {
+		return false
+	}
+	for i, c := range node.Content {
+		if !equal(typedValueOfNode(c), elements[i]) {
+			return false
 		}
-	})
+	}
+	return true
 }
 
 var x, y typedValue
 
 func init() {
-	x = typedValue{stringValueType, "x"}
-	y = typedValue{stringValueType, "y"}
+	x = typedValue{typ: stringValueType, val: "x"}
+	y = typedValue{typ: stringValueType, val: "y"}
 }
 
-func nodeToFilter(n *filterNode, accept func(typedValue, typedValue) bool) filter {
-	lhsPath := newFilterScanner(n.children[0])
-	rhsPath := newFilterScanner(n.children[1])
-	return func(node, root *yaml.Node) (result bool) {
-		// perform a set-wise comparison of the values in each path
+func nodeToFilter(n *filterNode, o *options, accept func(typedValue, typedValue) bool) filter {
+	lhsPath, rhsPath := newFilterScanner(n.children[0], o), newFilterScanner(n.children[1], o)
+	if n.children[0].isDescendantPath() || n.children[1].isDescendantPath() {
+		return anyPairFilter(lhsPath, rhsPath, accept)
+	}
+	return scannersToFilter(lhsPath, rhsPath, accept)
+}
+
+func scannersToFilter(lhsPath, rhsPath filterScanner, accept func(typedValue, typedValue) bool) filter {
+	return func(node, root *yaml.Node, e *evaluation) (result bool) {
+		// perform a set-wise comparison of the values in each path, which is false, even for !=, if either path
+		// produces no values
 		match := false
-		for _, l := range lhsPath(node, root) {
-			for _, r := range rhsPath(node, root) {
+		for _, l := range lhsPath(node, root, e) {
+			for _, r := range rhsPath(node, root, e) {
 				if !accept(l, r) {
 					return false
 				}
@@ -127,6 +258,22 @@ func nodeToFilter(n *filterNode, accept func(typedValue, typedValue) bool) filte
 	}
 }
 
+// anyPairFilter returns a filter which, unlike the set-wise comparison of scannersToFilter, is true if and only if some
+// pair of values produced by the given scanners is accepted. It is used when either side of a comparison is a relative
+// path containing a recursive descent, such as @..name, which may match any number of nodes at any depth.
+func anyPairFilter(lhsPath, rhsPath filterScanner, accept func(typedValue, typedValue) bool) filter {
+	return func(node, root *yaml.Node, e *evaluation) bool {
+		for _, l := range lhsPath(node, root, e) {
+			for _, r := range rhsPath(node, root, e) {
+				if accept(l, r) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
 func equalBooleans(l, r string) bool {
 	// Note: the YAML parser and our JSONPath lexer both rule out invalid boolean literals such as tRue.
 	return strings.EqualFold(l, r)
@@ -137,54 +284,164 @@ func equalNulls(l, r string) bool {
 	return true
 }
 
+// equalNodes returns true if and only if the given nodes, which are mappings, sequences, or scalars of types other than
+// those of filter literals, have the same kind and tag and either the same value or equal content, in the same order.
+// An alias is equal only to an alias of the same node.
+func equalNodes(l, r *yaml.Node) bool {
+	if l == nil || r == nil {
+		return l == r
+	}
+	if l.Kind != r.Kind || l.ShortTag() != r.ShortTag() || l.Value != r.Value || l.Alias != r.Alias ||
+		len(l.Content) != len(r.Content) {
+		return false
+	}
+	for i, c := range l.Content {
+		if !equalNodes(c, r.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // filterScanner is a function that returns a slice of typed values from either a filter literal or a path expression
 // which refers to either the current node or the root node. It is used in filter comparisons.
-type filterScanner func(node, root *yaml.Node) []typedValue
+type filterScanner func(node, root *yaml.Node, e *evaluation) []typedValue
 
-func emptyScanner(*yaml.Node, *yaml.Node) []typedValue {
+func emptyScanner(*yaml.Node, *yaml.Node, *evaluation) []typedValue {
 	return []typedValue{}
 }
 
-func newFilterScanner(n *filterNode) filterScanner {
+func newFilterScanner(n *filterNode, o *options) filterScanner {
 	switch {
 	case n == nil:
 		return emptyScanner
 
 	case n.isItemFilter():
-		return pathFilterScanner(n)
+		return pathFilterScanner(n, o)
 
 	case n.isLiteral():
 		return literalFilterScanner(n)
 
+	case n.lexeme.typ == lexemeFilterIndex:
+		return indexFilterScanner
+
+	case n.lexeme.typ == lexemeFilterKey:
+		return keyFilterScanner
+
+	case n.lexeme.typ == lexemeFilterModulo:
+		return moduloFilterScanner(n, o)
+
+	case n.lexeme.typ == lexemeFilterFunctionCall:
+		return functionCallScanner(n, o)
+
 	default:
 		return emptyScanner
 	}
 }
 
-func pathFilterScanner(n *filterNode) filterScanner {
-	var at bool
+// truthy returns true if and only if the given values, produced by the path of an existence filter, are non-empty and
+// not just a boolean false, so that `@.enabled` and `$.globalDisabled` may be used as switches.
+func truthy(values []typedValue) bool {
+	if len(values) == 1 && values[0].typ == booleanValueType {
+		return !equalBooleans(values[0].val, "false")
+	}
+	return len(values) > 0
+}
+
+func pathFilterScanner(n *filterNode, o *options) filterScanner {
+	path := pathNodeScanner(n, o)
+	return func(node, root *yaml.Node, e *evaluation) []typedValue {
+		return values(path(node, root, e), nil)
+	}
+}
+
+// sizeProperty is the child name which, at the end of a path in a filter, produces the size of each node matched by
+// the rest of the path, as the built-in length function does, unless the node is a mapping with a child named size.
+const sizeProperty = ".size"
+
+// pathNodeScanner returns a function which returns the nodes matched by a path expression which refers to the
+// current node, an ancestor of the current node, the root node, or a named binding.
+func pathNodeScanner(n *filterNode, o *options) func(node, root *yaml.Node, e *evaluation) []*yaml.Node {
 	switch n.lexeme.typ {
-	case lexemeFilterAt:
-		at = true
-	case lexemeRoot:
-		at = false
+	case lexemeFilterAt, lexemeFilterParent, lexemeRoot, lexemeFilterBinding:
 	default:
 		panic("false precondition")
 	}
+	lexemes := n.subpath
+	if last := len(lexemes) - 1; last >= 0 && lexemes[last].typ == lexemeDotChild && lexemes[last].val == sizeProperty {
+		scanner := pathNodeScanner(&filterNode{lexeme: n.lexeme, subpath: lexemes[:last]}, o)
+		return func(node, root *yaml.Node, e *evaluation) []*yaml.Node {
+			sizes := []*yaml.Node{}
+			for _, m := range scanner(node, root, e) {
+				sizes = append(sizes, sizeNodes(m)...)
+			}
+			return sizes
+		}
+	}
+
 	subpath := ""
-	for _, lexeme := range n.subpath {
+	for _, lexeme := range lexemes {
 		subpath += lexeme.val
 	}
-	path, err := NewPath(subpath)
+	path, err := compile(subpath, lenient(o))
 	if err != nil {
-		return emptyScanner
+		return func(node, root *yaml.Node, e *evaluation) []*yaml.Node {
+			return []*yaml.Node{}
+		}
+	}
+	bindingName := strings.TrimPrefix(n.lexeme.val, root)
+	generations := strings.Count(n.lexeme.val, filterParent)
+	return func(node, root *yaml.Node, e *evaluation) []*yaml.Node {
+		switch n.lexeme.typ {
+		case lexemeFilterAt:
+			return path.find(node, root, e)
+
+		case lexemeFilterParent:
+			ancestor := e.ancestor(node, root, generations)
+			if ancestor == nil {
+				return []*yaml.Node{}
+			}
+			return path.find(ancestor, root, e)
+
+		case lexemeFilterBinding:
+			binding, ok := e.bindings[bindingName]
+			if !ok || binding == nil {
+				e.fail(fmt.Errorf("no binding for %s", n.lexeme.val))
+				return []*yaml.Node{}
+			}
+			if binding.Kind == yaml.DocumentNode && len(binding.Content) > 0 {
+				binding = binding.Content[0]
+			}
+			return path.find(binding, root, e)
+
+		default:
+			if e.shared != nil {
+				return path.find(e.shared, e.shared, e)
+			}
+			return path.find(root, root, e)
+		}
 	}
-	return func(node, root *yaml.Node) []typedValue {
-		if at {
-			return values(path.Find(node))
+}
+
+// sizeNodes returns the children named size of the given node, if it is a mapping with such children, or otherwise
+// an integer node holding the size of the given node, if it has one. See sizeOf.
+func sizeNodes(node *yaml.Node) []*yaml.Node {
+	if node.Kind == yaml.MappingNode {
+		children := []*yaml.Node{}
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == strings.TrimPrefix(sizeProperty, dot) {
+				children = append(children, node.Content[i+1])
+			}
+		}
+		if len(children) > 0 {
+			return children
 		}
-		return values(path.Find(root))
 	}
+	size, ok := sizeOf(node)
+	if !ok {
+		return []*yaml.Node{}
+	}
+	return []*yaml.Node{IntValue(size).node}
 }
 
 type valueType int
@@ -197,19 +454,34 @@ const (
 	booleanValueType
 	nullValueType
 	regularExpressionValueType
+	timestampValueType
 )
 
 func (vt valueType) isNumeric() bool {
 	return vt == intValueType || vt == floatValueType
 }
 
+// compatibleWith returns true if and only if values of the two types may be compared. Timestamps are not compatible
+// with each other, since they are compared only with WithTimeComparison.
 func (vt valueType) compatibleWith(vt2 valueType) bool {
-	return vt.isNumeric() && vt2.isNumeric() || vt == vt2 || vt == stringValueType && vt2 == regularExpressionValueType
+	return vt.isNumeric() && vt2.isNumeric() || vt == vt2 && vt != timestampValueType ||
+		vt == stringValueType && vt2 == regularExpressionValueType
 }
 
 type typedValue struct {
-	typ valueType
-	val string
+	typ  valueType
+	val  string
+	node *yaml.Node     // the node whose value this is, if any
+	re   *regexp.Regexp // the compiled regular expression, if the value is a regular expression
+}
+
+// timestamp returns the time represented by a string or YAML timestamp value and true or, if the value does not
+// represent a time, false.
+func (v typedValue) timestamp() (time.Time, bool) {
+	if v.typ != stringValueType && v.typ != timestampValueType {
+		return time.Time{}, false
+	}
+	return parseTimestamp(v.val)
 }
 
 const (
@@ -218,6 +490,7 @@ const (
 	strTag   = "!!str"
 	intTag   = "!!int"
 	floatTag = "!!float"
+	timeTag  = "!!timestamp"
 )
 
 func typedValueOfNode(node *yaml.Node) typedValue {
@@ -238,12 +511,16 @@ func typedValueOfNode(node *yaml.Node) typedValue {
 
 		case floatTag:
 			t = floatValueType
+
+		case timeTag:
+			t = timestampValueType
 		}
 	}
 
 	return typedValue{
-		typ: t,
-		val: node.Value,
+		typ:  t,
+		val:  node.Value,
+		node: node,
 	}
 }
 
@@ -254,6 +531,58 @@ func newTypedValue(t valueType, v string) typedValue {
 	}
 }
 
+// coerceNumeric returns the given values except that, if one is numeric and the other is a string which parses
+// as a number, the string is replaced by the corresponding numeric value.
+func coerceNumeric(l, r typedValue) (typedValue, typedValue) {
+	return coerceStringToNumeric(l, r), coerceStringToNumeric(r, l)
+}
+
+func coerceStringToNumeric(v, other typedValue) typedValue {
+	if v.typ != stringValueType || !other.typ.isNumeric() {
+		return v
+	}
+	if n, ok := numericValueOf(v); ok {
+		return n
+	}
+	return v
+}
+
+// coerceOrdered returns the given operands of an ordering comparison except that strings which parse as numbers are
+// replaced by the corresponding numeric values, provided that the other operand is numeric or is a string which also
+// parses as a number. Other strings are left alone.
+func coerceOrdered(l, r typedValue) (typedValue, typedValue) {
+	if l.typ == stringValueType && r.typ == stringValueType {
+		ln, lok := numericValueOf(l)
+		rn, rok := numericValueOf(r)
+		if lok && rok {
+			return ln, rn
+		}
+		return l, r
+	}
+	return coerceNumeric(l, r)
+}
+
+// numericValueOf returns the numeric value represented by the given string value and true or, if the string does not
+// parse as a finite number, false.
+func numericValueOf(v typedValue) (typedValue, bool) {
+	s := strings.TrimSpace(v.val)
+	if _, err := strconv.Atoi(s); err == nil {
+		return typedValueOfInt(s), true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return typedValueOfFloat(s), true
+	}
+	return v, false
+}
+
+func newRegularExpressionValue(re *regexp.Regexp) typedValue {
+	return typedValue{
+		typ: regularExpressionValueType,
+		val: re.String(),
+		re:  re,
+	}
+}
+
 func typedValueOfString(s string) typedValue {
 	return newTypedValue(stringValueType, s)
 }
@@ -279,19 +608,160 @@ func values(nodes []*yaml.Node, err error) []typedValue {
 
 func literalFilterScanner(n *filterNode) filterScanner {
 	v := n.lexeme.literalValue()
-	return func(node, root *yaml.Node) []typedValue {
+	return func(node, root *yaml.Node, e *evaluation) []typedValue {
 		return []typedValue{v}
 	}
 }
 
-func matchRegularExpression(parseTree *filterNode) filter {
-	return nodeToFilter(parseTree, stringMatchesRegularExpression)
+// indexFilterScanner returns the index of the current node in the sequence being filtered or, if the current node
+// is not an element of a sequence being filtered, no value.
+func indexFilterScanner(node, root *yaml.Node, e *evaluation) []typedValue {
+	if e.index < 0 {
+		return []typedValue{}
+	}
+	return []typedValue{typedValueOfInt(strconv.Itoa(e.index))}
+}
+
+// keyFilterScanner returns the key of the current node, as a string, if the current node is a value in a mapping and
+// otherwise no value.
+func keyFilterScanner(node, root *yaml.Node, e *evaluation) []typedValue {
+	key := e.key(node, root)
+	if key == nil {
+		return []typedValue{}
+	}
+	return []typedValue{typedValueOfString(key.Value)}
+}
+
+// moduloFilterScanner returns the remainder of dividing each integer value of the first child of the given node by
+// the integer literal which is the second child of the node. Values other than integers are omitted.
+func moduloFilterScanner(n *filterNode, o *options) filterScanner {
+	dividend := newFilterScanner(n.children[0], o)
+	divisor := 0
+	if d := n.children[1]; d != nil && d.lexeme.typ == lexemeFilterIntegerLiteral {
+		divisor, _ = strconv.Atoi(d.lexeme.val)
+	}
+	return func(node, root *yaml.Node, e *evaluation) []typedValue {
+		v := []typedValue{}
+		if divisor == 0 {
+			return v
+		}
+		for _, d := range dividend(node, root, e) {
+			if d.typ != intValueType {
+				continue
+			}
+			i, err := strconv.Atoi(d.val)
+			if err != nil {
+				continue
+			}
+			v = append(v, typedValueOfInt(strconv.Itoa(i%divisor)))
+		}
+		return v
+	}
+}
+
+func matchRegularExpression(parseTree *filterNode, o *options) filter {
+	rhsPath := regularExpressionScanner(parseTree.children[1], o)
+	if !parseTree.children[0].isItemFilter() {
+		return scannersToFilter(newFilterScanner(parseTree.children[0], o), rhsPath, stringMatchesRegularExpression)
+	}
+
+	lhsPath := pathNodeScanner(parseTree.children[0], o)
+	if parseTree.children[0].isDescendantPath() {
+		return func(node, root *yaml.Node, e *evaluation) bool {
+			// as for other comparisons, match if some node in the path matches
+			for _, l := range lhsPath(node, root, e) {
+				for _, r := range rhsPath(node, root, e) {
+					if nodeMatchesRegularExpression(l, r) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+	}
+	return func(node, root *yaml.Node, e *evaluation) bool {
+		// perform a set-wise match of the nodes in the path, as for other comparisons
+		match := false
+		for _, l := range lhsPath(node, root, e) {
+			for _, r := range rhsPath(node, root, e) {
+				if !nodeMatchesRegularExpression(l, r) {
+					return false
+				}
+				match = true
+			}
+		}
+		return match
+	}
+}
+
+// regularExpressionScanner returns a scanner for the regular expression on the right hand side of a match. This is
+// either a regular expression literal or a binding, such as $pattern, to a compiled regular expression or to nodes
+// whose string values are regular expressions. If a bound value is not a valid regular expression, the evaluation
+// fails. With WithAnchoredRegex, each regular expression is anchored to match only an entire string.
+func regularExpressionScanner(n *filterNode, o *options) filterScanner {
+	scanner := unanchoredRegularExpressionScanner(n, o)
+	if !o.anchoredRegex {
+		return scanner
+	}
+	return func(node, root *yaml.Node, e *evaluation) []typedValue {
+		v := []typedValue{}
+		for _, re := range scanner(node, root, e) {
+			if re.typ == regularExpressionValueType {
+				anchored, err := e.compile(`\A(?:` + re.val + `)\z`)
+				if err != nil {
+					panic(err) // should not happen, since the unanchored regular expression is valid
+				}
+				re = newRegularExpressionValue(anchored)
+			}
+			v = append(v, re)
+		}
+		return v
+	}
+}
+
+func unanchoredRegularExpressionScanner(n *filterNode, o *options) filterScanner {
+	if n == nil || n.lexeme.typ != lexemeFilterBinding {
+		return newFilterScanner(n, o)
+	}
+	bound := newFilterScanner(n, o)
+	name := strings.TrimPrefix(n.lexeme.val, root)
+	return func(node, root *yaml.Node, e *evaluation) []typedValue {
+		if re, ok := e.regexps[name]; ok && re != nil && len(n.subpath) == 0 {
+			return []typedValue{newRegularExpressionValue(re)}
+		}
+		v := []typedValue{}
+		for _, b := range bound(node, root, e) {
+			if b.typ != stringValueType {
+				continue // cannot match anything
+			}
+			re, err := e.compile(b.val)
+			if err != nil {
+				e.fail(fmt.Errorf("invalid regular expression bound to %s: %s", n.lexeme.val, err))
+				return []typedValue{}
+			}
+			v = append(v, newRegularExpressionValue(re))
+		}
+		return v
+	}
+}
+
+// nodeMatchesRegularExpression returns true if and only if the given node is a string which matches the given
+// regular expression or is a sequence with at least one string element which matches the regular expression.
+func nodeMatchesRegularExpression(n *yaml.Node, expr typedValue) bool {
+	if n.Kind == yaml.SequenceNode {
+		for _, c := range n.Content {
+			if stringMatchesRegularExpression(typedValueOfNode(c), expr) {
+				return true
+			}
+		}
+		return false
+	}
+	return stringMatchesRegularExpression(typedValueOfNode(n), expr)
 }
 
 func stringMatchesRegularExpression(s, expr typedValue) bool {
 	if s.typ != stringValueType || expr.typ != regularExpressionValueType {
 		return false // can't compare types so return false
 	}
-	re, _ := regexp.Compile(expr.val) // regex already compiled during lexing
-	return re.Match([]byte(s.val))
+	return expr.re.MatchString(s.val)
 }
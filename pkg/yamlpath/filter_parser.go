@@ -6,15 +6,24 @@
 
 package yamlpath
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 /*
    filterNode represents a node of a filter expression parse tree. Each node is labelled with a lexeme.
 
-   Terminal nodes have one of the following lexemes: root, lexemeFilterAt, lexemeFilterIntegerLiteral,
-   lexemeFilterFloatLiteral, lexemeFilterStringLiteral, lexemeFilterBooleanLiteral.
-   root and lexemeFilterAt nodes also have a slice of lexemes representing the subpath of `$`` or `@``,
-   respectively.
+   Terminal nodes have one of the following lexemes: root, lexemeFilterAt, lexemeFilterParent, lexemeFilterBinding,
+   lexemeFilterIntegerLiteral, lexemeFilterFloatLiteral, lexemeFilterStringLiteral, lexemeFilterBooleanLiteral.
+   root, lexemeFilterAt, lexemeFilterParent, and lexemeFilterBinding nodes also have a slice of lexemes representing
+   the subpath of `$``, `@``, `@^``, or `$name``, respectively.
+
+   A function call, such as `lower(@.name)`, is represented as a node with lexeme lexemeFilterFunctionCall and a
+   child for each argument.
 
-   Non-terminal nodes represent either basic filters (simpler predicates of one or two terminal
+   Other non-terminal nodes represent either basic filters (simpler predicates of one or two terminal
    nodes) or filter expressions (more complex predicates of basic filters). A filter existence expression
    is represented as a terminal node with lexemeFilterAt or (less commonly) root.
 
@@ -65,7 +74,7 @@ package yamlpath
 */
 type filterNode struct {
 	lexeme   lexeme
-	subpath  []lexeme // empty unless lexeme is root or lexemeFilterAt
+	subpath  []lexeme // empty unless lexeme is root, lexemeFilterAt, lexemeFilterParent, or lexemeFilterBinding
 	children []*filterNode
 }
 
@@ -74,7 +83,21 @@ func newFilterNode(lexemes []lexeme) *filterNode {
 }
 
 func (n *filterNode) isItemFilter() bool {
-	return n.lexeme.typ == lexemeFilterAt || n.lexeme.typ == lexemeRoot
+	return n.lexeme.typ == lexemeFilterAt || n.lexeme.typ == lexemeFilterParent || n.lexeme.typ == lexemeRoot || n.lexeme.typ == lexemeFilterBinding
+}
+
+// isDescendantPath returns true if and only if the node is a path relative to the current node, or its parent, which
+// contains a recursive descent, such as @..name.
+func (n *filterNode) isDescendantPath() bool {
+	if n == nil || (n.lexeme.typ != lexemeFilterAt && n.lexeme.typ != lexemeFilterParent) {
+		return false
+	}
+	for _, lx := range n.subpath {
+		if lx.typ == lexemeRecursiveDescent {
+			return true
+		}
+	}
+	return false
 }
 
 func (n *filterNode) isLiteral() bool {
@@ -97,16 +120,30 @@ func (n *filterNode) isNumericLiteral() bool {
 	return n.lexeme.typ == lexemeFilterFloatLiteral || n.lexeme.typ == lexemeFilterIntegerLiteral
 }
 
+// isNumericTerm returns true if and only if the node is @index or a modulo, which produce numbers rather than nodes.
+func (n *filterNode) isNumericTerm() bool {
+	return n.lexeme.typ == lexemeFilterIndex || n.lexeme.typ == lexemeFilterModulo
+}
+
 func (n *filterNode) isRegularExpressionLiteral() bool {
 	return n.lexeme.typ == lexemeFilterRegularExpressionLiteral
 }
 
+func (n *filterNode) isArrayLiteral() bool {
+	return n.lexeme.typ == lexemeFilterArrayLiteral
+}
+
 // parser holds the state of the filter expression parser.
 type parser struct {
 	input []lexeme      // the lexemes being scanned
 	pos   int           // current position in the input
 	stack []*filterNode // parser stack
 	tree  *filterNode   // parse tree
+	err   error         // first semantic error detected, if any
+
+	// whether a call of a function which is not registered is an error, rather than being reported only when the
+	// call is evaluated, since the function may be registered after the path is constructed
+	checkFuncs bool
 }
 
 // newParser creates a new parser for the input slice of lexemes.
@@ -131,6 +168,13 @@ func (p *parser) pop() *filterNode {
 	return element
 }
 
+// errorf records a semantic error unless one has already been recorded. Parsing continues regardless.
+func (p *parser) errorf(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
 // nextLexeme returns the next item from the input.
 // The caller must peek to ensure there is more input before calling nextLexeme.
 func (p *parser) nextLexeme() lexeme {
@@ -152,6 +196,9 @@ func (p *parser) parse() *filterNode {
 		return nil
 	}
 	p.expression()
+	if n := p.peek(); n.typ != lexemeEOF {
+		p.errorf("unexpected %q in filter", n.val)
+	}
 	return p.tree
 }
 
@@ -166,6 +213,9 @@ func (p *parser) expression() {
 func (p *parser) or() {
 	n := p.nextLexeme()
 	p.conjunction()
+	if p.tree == nil {
+		p.errorf("missing second operand for binary operator %s", n.val)
+	}
 	p.tree = &filterNode{
 		lexeme:  n,
 		subpath: []lexeme{},
@@ -187,6 +237,9 @@ func (p *parser) conjunction() {
 func (p *parser) and() {
 	n := p.nextLexeme()
 	p.basicFilter()
+	if p.tree == nil {
+		p.errorf("missing second operand for binary operator %s", n.val)
+	}
 	p.tree = &filterNode{
 		lexeme:  n,
 		subpath: []lexeme{},
@@ -204,6 +257,9 @@ func (p *parser) basicFilter() {
 	case lexemeFilterNot:
 		p.nextLexeme()
 		p.basicFilter()
+		if p.tree == nil {
+			p.errorf("missing operand for unary operator %s", n.val)
+		}
 		p.tree = &filterNode{
 			lexeme:  n,
 			subpath: []lexeme{},
@@ -216,14 +272,51 @@ func (p *parser) basicFilter() {
 	case lexemeFilterOpenBracket:
 		p.nextLexeme()
 		p.expression()
+		if p.tree == nil {
+			p.errorf("missing filter expression inside parentheses")
+		}
 		if p.peek().typ == lexemeFilterCloseBracket {
 			p.nextLexeme()
+		} else {
+			p.errorf("missing %s", filterCloseBracket)
 		}
 		return
 	}
 
 	p.filterTerm()
+	operated := p.peek().typ.isComparisonOrMatch() || p.peek().typ == lexemeFilterBetween
+	if p.tree != nil && p.tree.isLiteral() && !p.tree.isBooleanLiteral() && !operated {
+		p.errorf("literal %s cannot be used as a filter predicate", p.tree.lexeme.val)
+	}
+	if p.tree != nil && p.tree.isNumericTerm() && !operated {
+		p.errorf("numeric term cannot be used as a filter predicate")
+	}
+	if p.tree != nil && p.tree.lexeme.typ == lexemeFilterKey && !operated {
+		p.errorf("key term cannot be used as a filter predicate")
+	}
 	n = p.peek()
+	if n.typ == lexemeFilterBetween {
+		p.nextLexeme()
+		subject := p.tree
+		p.filterTerm()
+		lower := p.tree
+		if p.peek().typ == lexemeFilterBetweenAnd {
+			p.nextLexeme()
+		} else {
+			p.errorf("missing %s after lower bound of %s", filterBetweenAnd, filterBetween)
+		}
+		p.filterTerm()
+		p.tree = &filterNode{
+			lexeme:  n,
+			subpath: []lexeme{},
+			children: []*filterNode{
+				subject,
+				lower,
+				p.tree,
+			},
+		}
+		return
+	}
 	if n.typ.isComparisonOrMatch() {
 		p.nextLexeme()
 		filterTerm := p.tree
@@ -246,7 +339,7 @@ func (p *parser) filterTerm() {
 	case lexemeEOF, lexemeError:
 		p.tree = nil
 
-	case lexemeFilterAt, lexemeRoot:
+	case lexemeFilterAt, lexemeFilterParent, lexemeRoot, lexemeFilterBinding:
 		p.nextLexeme()
 		subpath := []lexeme{}
 		filterNestingLevel := 1
@@ -254,7 +347,8 @@ func (p *parser) filterTerm() {
 		for {
 			s := p.peek()
 			switch s.typ {
-			case lexemeIdentity, lexemeDotChild, lexemeBracketChild, lexemeRecursiveDescent, lexemeArraySubscript:
+			case lexemeIdentity, lexemeDotChild, lexemeBracketChild, lexemeRecursiveDescent, lexemeArraySubscript, lexemeTag,
+				lexemeKeyRegularExpression, lexemeFirstOrLastElement:
 
 			case lexemeFilterBegin:
 				filterNestingLevel++
@@ -284,12 +378,70 @@ func (p *parser) filterTerm() {
 		}
 
 	case lexemeFilterIntegerLiteral, lexemeFilterFloatLiteral, lexemeFilterStringLiteral, lexemeFilterBooleanLiteral,
-		lexemeFilterNullLiteral, lexemeFilterRegularExpressionLiteral:
+		lexemeFilterNullLiteral, lexemeFilterRegularExpressionLiteral, lexemeFilterArrayLiteral, lexemeFilterIndex, lexemeFilterKey:
 		p.nextLexeme()
 		p.tree = &filterNode{
 			lexeme:   n,
 			subpath:  []lexeme{},
 			children: []*filterNode{},
 		}
+
+	case lexemeFilterFunctionCall:
+		p.nextLexeme()
+		p.functionCall(n)
+	}
+
+	if m := p.peek(); m.typ == lexemeFilterModulo && p.tree != nil {
+		p.nextLexeme()
+		dividend := p.tree
+		p.filterTerm()
+		if p.tree == nil || p.tree.lexeme.typ != lexemeFilterIntegerLiteral {
+			p.errorf("missing integer literal after %s", m.val)
+		} else if v, err := strconv.Atoi(p.tree.lexeme.val); err == nil && v == 0 {
+			p.errorf("integer literal after %s must be non-zero", m.val)
+		}
+		p.tree = &filterNode{
+			lexeme:  m,
+			subpath: []lexeme{},
+			children: []*filterNode{
+				dividend,
+				p.tree,
+			},
+		}
+	}
+}
+
+// functionCall consumes the arguments of a function call, whose name and open parenthesis have already been consumed,
+// and sets the call as the parser's tree.
+func (p *parser) functionCall(n lexeme) {
+	name := strings.TrimSuffix(n.val, filterOpenBracket)
+	if p.checkFuncs && lookupFilterFunc(name) == nil {
+		p.errorf("unknown filter function %s", name)
+	}
+	args := []*filterNode{}
+	if p.peek().typ == lexemeFilterCloseBracket {
+		p.nextLexeme()
+	} else {
+		for {
+			p.filterTerm()
+			if p.tree == nil {
+				p.errorf("missing argument of function %s", name)
+			}
+			args = append(args, p.tree)
+			if p.peek().typ != lexemeFilterArgumentSeparator {
+				break
+			}
+			p.nextLexeme()
+		}
+		if p.peek().typ == lexemeFilterCloseBracket {
+			p.nextLexeme()
+		} else {
+			p.errorf("missing %s after arguments of function %s", filterCloseBracket, name)
+		}
+	}
+	p.tree = &filterNode{
+		lexeme:   n,
+		subpath:  []lexeme{},
+		children: args,
 	}
 }
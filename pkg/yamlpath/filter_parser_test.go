@@ -637,6 +637,136 @@ func TestNewFilterNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "negated existence && existence filter",
+			lexemes: []lexeme{
+				{typ: lexemeFilterNot, val: "!"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFilterAnd, val: "&&"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".b"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterAnd, val: "&&"},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme:  lexeme{typ: lexemeFilterNot, val: "!"},
+						subpath: []lexeme{},
+						children: []*filterNode{
+							{
+								lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+								subpath: []lexeme{
+									{typ: lexemeDotChild, val: ".a"},
+								},
+								children: []*filterNode{},
+							},
+						},
+					},
+					{
+						lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+						subpath: []lexeme{
+							{typ: lexemeDotChild, val: ".b"},
+						},
+						children: []*filterNode{},
+					},
+				},
+			},
+		},
+		{
+			name: "negated parenthesised disjunction",
+			lexemes: []lexeme{
+				{typ: lexemeFilterNot, val: "!"},
+				{typ: lexemeFilterOpenBracket, val: "("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFilterOr, val: "||"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterNot, val: "!"},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme:  lexeme{typ: lexemeFilterOr, val: "||"},
+						subpath: []lexeme{},
+						children: []*filterNode{
+							{
+								lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+								subpath: []lexeme{
+									{typ: lexemeDotChild, val: ".a"},
+								},
+								children: []*filterNode{},
+							},
+							{
+								lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+								subpath: []lexeme{
+									{typ: lexemeDotChild, val: ".b"},
+								},
+								children: []*filterNode{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "array literal comparison",
+			lexemes: []lexeme{
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterArrayLiteral, val: "['a','b']"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterEquality, val: "=="},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+						subpath: []lexeme{
+							{typ: lexemeDotChild, val: ".tags"},
+						},
+						children: []*filterNode{},
+					},
+					{
+						lexeme:   lexeme{typ: lexemeFilterArrayLiteral, val: "['a','b']"},
+						subpath:  []lexeme{},
+						children: []*filterNode{},
+					},
+				},
+			},
+		},
+		{
+			name: "regular expression non-match filter on path",
+			lexemes: []lexeme{
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterNotMatchesRegularExpression, val: "!~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/.*/"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterNotMatchesRegularExpression, val: "!~"},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+						subpath: []lexeme{
+							{typ: lexemeDotChild, val: ".child"},
+						},
+						children: []*filterNode{},
+					},
+					{
+						lexeme:   lexeme{typ: lexemeFilterRegularExpressionLiteral, val: "/.*/"},
+						subpath:  []lexeme{},
+						children: []*filterNode{},
+					},
+				},
+			},
+		},
 		{
 			name: "regular expression match filter on path",
 			lexemes: []lexeme{
@@ -664,6 +794,43 @@ func TestNewFilterNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "modulo of index compared with integer",
+			lexemes: []lexeme{
+				{typ: lexemeFilterIndex, val: "@index"},
+				{typ: lexemeFilterModulo, val: "%"},
+				{typ: lexemeFilterIntegerLiteral, val: "2"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterIntegerLiteral, val: "0"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterEquality, val: "=="},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme:  lexeme{typ: lexemeFilterModulo, val: "%"},
+						subpath: []lexeme{},
+						children: []*filterNode{
+							{
+								lexeme:   lexeme{typ: lexemeFilterIndex, val: "@index"},
+								subpath:  []lexeme{},
+								children: []*filterNode{},
+							},
+							{
+								lexeme:   lexeme{typ: lexemeFilterIntegerLiteral, val: "2"},
+								subpath:  []lexeme{},
+								children: []*filterNode{},
+							},
+						},
+					},
+					{
+						lexeme:   lexeme{typ: lexemeFilterIntegerLiteral, val: "0"},
+						subpath:  []lexeme{},
+						children: []*filterNode{},
+					},
+				},
+			},
+		},
 		{
 			name: "incomplete term (edge case, garbage in garbage out)",
 			lexemes: []lexeme{
@@ -712,6 +879,114 @@ func TestNewFilterNode(t *testing.T) {
 			},
 			expected: nil,
 		},
+		{
+			name: "function call compared with string literal",
+			lexemes: []lexeme{
+				{typ: lexemeFilterFunctionCall, val: "lower("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: "'admin'"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterEquality, val: "=="},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme:  lexeme{typ: lexemeFilterFunctionCall, val: "lower("},
+						subpath: []lexeme{},
+						children: []*filterNode{
+							{
+								lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+								subpath: []lexeme{
+									{typ: lexemeDotChild, val: ".name"},
+								},
+								children: []*filterNode{},
+							},
+						},
+					},
+					{
+						lexeme:   lexeme{typ: lexemeFilterStringLiteral, val: "'admin'"},
+						subpath:  []lexeme{},
+						children: []*filterNode{},
+					},
+				},
+			},
+		},
+		{
+			name: "function calls on both sides of comparison",
+			lexemes: []lexeme{
+				{typ: lexemeFilterFunctionCall, val: "length("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterFunctionCall, val: "length("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterGreaterThan, val: ">"},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme:  lexeme{typ: lexemeFilterFunctionCall, val: "length("},
+						subpath: []lexeme{},
+						children: []*filterNode{
+							{
+								lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+								subpath: []lexeme{
+									{typ: lexemeDotChild, val: ".a"},
+								},
+								children: []*filterNode{},
+							},
+						},
+					},
+					{
+						lexeme:  lexeme{typ: lexemeFilterFunctionCall, val: "length("},
+						subpath: []lexeme{},
+						children: []*filterNode{
+							{
+								lexeme: lexeme{typ: lexemeFilterAt, val: "@"},
+								subpath: []lexeme{
+									{typ: lexemeDotChild, val: ".b"},
+								},
+								children: []*filterNode{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "function call with several arguments used as a predicate",
+			lexemes: []lexeme{
+				{typ: lexemeFilterFunctionCall, val: "f("},
+				{typ: lexemeFilterFunctionCall, val: "g("},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+			},
+			expected: &filterNode{
+				lexeme:  lexeme{typ: lexemeFilterFunctionCall, val: "f("},
+				subpath: []lexeme{},
+				children: []*filterNode{
+					{
+						lexeme:   lexeme{typ: lexemeFilterFunctionCall, val: "g("},
+						subpath:  []lexeme{},
+						children: []*filterNode{},
+					},
+					{
+						lexeme:   lexeme{typ: lexemeFilterIntegerLiteral, val: "1"},
+						subpath:  []lexeme{},
+						children: []*filterNode{},
+					},
+				},
+			},
+		},
 	}
 
 	focussed := false
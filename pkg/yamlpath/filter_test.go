@@ -7,6 +7,7 @@
 package yamlpath
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -54,6 +55,62 @@ price: 8.95
 `,
 			match: false,
 		},
+		{
+			name:   "nested existence filter, full chain present",
+			filter: "@.metadata.labels.app",
+			yamlDoc: `---
+metadata:
+  labels:
+    app: web
+`,
+			match: true,
+		},
+		{
+			name:   "nested existence filter, null leaf",
+			filter: "@.metadata.labels.app",
+			yamlDoc: `---
+metadata:
+  labels:
+    app: null
+`,
+			match: true,
+		},
+		{
+			name:   "nested existence filter, missing leaf",
+			filter: "@.metadata.labels.app",
+			yamlDoc: `---
+metadata:
+  labels:
+    tier: backend
+`,
+			match: false,
+		},
+		{
+			name:   "nested existence filter, missing intermediate mapping",
+			filter: "@.metadata.labels.app",
+			yamlDoc: `---
+metadata:
+  annotations: {}
+`,
+			match: false,
+		},
+		{
+			name:   "nested existence filter, scalar intermediate",
+			filter: "@.metadata.labels.app",
+			yamlDoc: `---
+metadata:
+  labels: app
+`,
+			match: false,
+		},
+		{
+			name:   "negated nested existence filter, missing intermediate mapping",
+			filter: "!@.metadata.labels.app",
+			yamlDoc: `---
+metadata: {}
+`,
+			match: true,
+		},
 		{
 			name:   "numeric comparison filter, match",
 			filter: "@.price>8.90",
@@ -424,6 +481,47 @@ y: b
 `,
 			match: false,
 		},
+		{
+			name:   "string comparison filter, path to empty literal, match",
+			filter: "@.x=='' && @.y==\"\" && ''==@.x",
+			yamlDoc: `---
+x: ''
+y: ""
+`,
+			match: true,
+		},
+		{
+			name:   "string comparison filter, path to empty literal, no match",
+			filter: "@.x==''",
+			yamlDoc: `---
+x: a
+`,
+			match: false,
+		},
+		{
+			name:   "string comparison filter, null to empty literal, no match",
+			filter: "@.x==''",
+			yamlDoc: `---
+x:
+`,
+			match: false,
+		},
+		{
+			name:   "string comparison filter, empty sequence to empty literal, no match",
+			filter: "@.x==''",
+			yamlDoc: `---
+x: []
+`,
+			match: false,
+		},
+		{
+			name:   "string inequality filter, path to empty literal, match",
+			filter: "@.x!=''",
+			yamlDoc: `---
+x: a
+`,
+			match: true,
+		},
 		{
 			name:    "comparison filter, string literal to numeric literal, no match",
 			filter:  "'x'==7",
@@ -605,6 +703,63 @@ y:
 `,
 			match: false,
 		},
+		{
+			name:   "nested existence filter, match",
+			filter: "@.y[?(@.z)]",
+			yamlDoc: `---
+y:
+- w: 2
+- z: 1
+`,
+			match: true,
+		},
+		{
+			name:   "nested existence filter, no match",
+			filter: "@.y[?(@.z)]",
+			yamlDoc: `---
+y:
+- w: 2
+- v: 1
+`,
+			match: false,
+		},
+		{
+			name:   "nested existence filter, empty collection, no match",
+			filter: "@.y[?(@.z)]",
+			yamlDoc: `---
+y: []
+`,
+			match: false,
+		},
+		{
+			name:   "nested comparison filter used as existence filter, match",
+			filter: "@.y[?(@.z>1)]",
+			yamlDoc: `---
+y:
+- z: 1
+- z: 2
+`,
+			match: true,
+		},
+		{
+			name:   "nested comparison filter used as existence filter, no match",
+			filter: "@.y[?(@.z>2)]",
+			yamlDoc: `---
+y:
+- z: 1
+- z: 2
+`,
+			match: false,
+		},
+		{
+			name:   "negated nested existence filter",
+			filter: "!@.y[?(@.z)]",
+			yamlDoc: `---
+y:
+- w: 2
+`,
+			match: true,
+		},
 		{
 			name:   "filter involving root on right, match",
 			filter: "@.price==$.price",
@@ -633,6 +788,200 @@ price: 8.95
 `,
 			match: true,
 		},
+		{
+			name:   "nested root path on right, match",
+			filter: "@.ref==$.definitions.base.id",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "nested root path on left, match",
+			filter: "$.definitions.base.id==@.ref",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "nested root path on right, no match",
+			filter: "@.ref==$.definitions.list[0].id",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: false,
+		},
+		{
+			name:   "nested root path with index on left, match",
+			filter: "$.definitions.list[1].id==@.ref",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "nested root path with negative index and bracket child on right, match",
+			filter: "@.ref==$['definitions'].list[-1]['id']",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "nested root path with filter on right, match",
+			filter: "@.ref==$.definitions.list[?(@.id=='b1')].id",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "root path with recursive descent on left, match",
+			filter: "$..base.id==@.ref",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "recursive descent, deep match",
+			filter: "@..name=='x'",
+			yamlDoc: `---
+a:
+  name: y
+  b:
+    c:
+      name: x
+`,
+			match: true,
+		},
+		{
+			name:   "recursive descent, no match",
+			filter: "@..name=='x'",
+			yamlDoc: `---
+a:
+  name: y
+  b:
+  - name: z
+`,
+			match: false,
+		},
+		{
+			name:   "recursive descent matching regular expression, deep match",
+			filter: "@..name=~/^x/",
+			yamlDoc: `---
+a:
+  name: y
+  b:
+    name: xyz
+`,
+			match: true,
+		},
+		{
+			name:   "root path ending in recursive descent, no match",
+			filter: "@.ref==$..id",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: false,
+		},
+		{
+			name:   "nested root paths on both sides, match",
+			filter: "$.definitions.base.id==$.definitions.list[1].id",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: true,
+		},
+		{
+			name:   "nested root paths on both sides, no match",
+			filter: "$.definitions.base.id==$.definitions.list[0].id",
+			yamlDoc: `---
+ref: b1
+`,
+			rootDoc: `---
+definitions:
+  base:
+    id: b1
+  list:
+  - id: a0
+  - id: b1
+`,
+			match: false,
+		},
 		{
 			name:   "negated existence filter, no match",
 			filter: "!@.category",
@@ -664,85 +1013,527 @@ c: x
 			match: true,
 		},
 		{
-			name:   "regular expression filter at path, match",
-			filter: "@.category=~/ref.*ce/",
+			name:   "negated parenthesised existence filter",
+			filter: "!(@.a)",
 			yamlDoc: `---
-category: reference
-author: Nigel Rees
-title: Sayings of the Century
-price: 8.95
+a: x
 `,
-			match: true,
+			match: false,
 		},
 		{
-			name:   "regular expression filter at path, no match",
-			filter: "@.category=~/.*x/",
+			name:   "negated root existence filter, root path present",
+			filter: "!($.globalDisabled)",
 			yamlDoc: `---
-category: reference
-author: Nigel Rees
-title: Sayings of the Century
-price: 8.95
+a: x
+`,
+			rootDoc: `---
+globalDisabled: true
 `,
 			match: false,
 		},
 		{
-			name:   "regular expression filter root path, match",
-			filter: "$.category=~/ref.*ce/",
+			name:   "negated root existence filter, root path absent",
+			filter: "!($.globalDisabled)",
+			yamlDoc: `---
+a: x
+`,
 			rootDoc: `---
-category: reference
-author: Nigel Rees
-title: Sayings of the Century
-price: 8.95
+other: true
 `,
 			match: true,
 		},
 		{
-			name:   "regular expression filter root path, no match",
-			filter: "$.category=~/.*x/",
+			name:   "negated root existence filter, root path false",
+			filter: "!$.globalDisabled",
+			yamlDoc: `---
+a: x
+`,
 			rootDoc: `---
-category: reference
-author: Nigel Rees
-title: Sayings of the Century
-price: 8.95
+globalDisabled: false
+`,
+			match: true,
+		},
+		{
+			name:   "root existence filter, root path false",
+			filter: "$.globalDisabled",
+			yamlDoc: `---
+a: x
+`,
+			rootDoc: `---
+globalDisabled: false
 `,
 			match: false,
 		},
 		{
-			name:   "literal boolean predicate",
-			filter: "true",
+			name:   "root existence filter, root path string false",
+			filter: "$.globalDisabled",
+			yamlDoc: `---
+a: x
+`,
 			rootDoc: `---
-- 1
+globalDisabled: 'false'
 `,
 			match: true,
 		},
 		{
-			name:   "boolean expression involving literals",
-			filter: "!false",
-			rootDoc: `---
-- 1
+			name:   "relative existence filter, path false",
+			filter: "@.a",
+			yamlDoc: `---
+a: false
+`,
+			match: false,
+		},
+		{
+			name:   "negated relative existence filter, path false",
+			filter: "!@.a",
+			yamlDoc: `---
+a: false
 `,
 			match: true,
 		},
-	}
-
-	focussed := false
-	for _, tc := range cases {
-		if tc.focus {
-			focussed = true
-			break
-		}
-	}
-
-	for _, tc := range cases {
-		if focussed && !tc.focus {
-			continue
-		}
-		t.Run(tc.name, func(t *testing.T) {
+		{
+			name:   "relative existence filter, path true",
+			filter: "@.a",
+			yamlDoc: `---
+a: true
+`,
+			match: true,
+		},
+		{
+			name:   "relative existence filter, path string false",
+			filter: "@.a",
+			yamlDoc: `---
+a: 'false'
+`,
+			match: true,
+		},
+		{
+			name:   "relative existence filter, path several false",
+			filter: "@.a[*]",
+			yamlDoc: `---
+a: [false, false]
+`,
+			match: true,
+		},
+		{
+			name:   "doubly negated root existence filter",
+			filter: "!(!($.globalDisabled))",
+			yamlDoc: `---
+a: x
+`,
+			rootDoc: `---
+globalDisabled: true
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression filter at path, match",
+			filter: "@.category=~/ref.*ce/",
+			yamlDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression filter at path, no match",
+			filter: "@.category=~/.*x/",
+			yamlDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression filter root path, match",
+			filter: "$.category=~/ref.*ce/",
+			rootDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression filter root path, no match",
+			filter: "$.category=~/.*x/",
+			rootDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression non-match filter at path, match",
+			filter: "@.category!~/.*x/",
+			yamlDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression non-match filter at path, no match",
+			filter: "@.category!~/ref.*ce/",
+			yamlDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression non-match filter root path, match",
+			filter: "$.category!~/.*x/",
+			rootDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression non-match filter root path, no match",
+			filter: "$.category!~/ref.*ce/",
+			rootDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression non-match filter at missing path, match",
+			filter: "@.nosuch!~/.*/",
+			yamlDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression non-match filter on non-string, match",
+			filter: "@.price!~/8/",
+			yamlDoc: `---
+category: reference
+author: Nigel Rees
+title: Sayings of the Century
+price: 8.95
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression filter on sequence, match",
+			filter: "@.tags=~/^prod-/",
+			yamlDoc: `---
+tags: [dev-1, prod-2, 3]
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression filter on sequence, no match",
+			filter: "@.tags=~/^prod-/",
+			yamlDoc: `---
+tags: [dev-1, staging-2]
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression filter on sequence of non-strings, no match",
+			filter: "@.tags=~/1/",
+			yamlDoc: `---
+tags: [1, true, {a: 1}]
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression filter on empty sequence, no match",
+			filter: "@.tags=~/.*/",
+			yamlDoc: `---
+tags: []
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression filter on scalar alongside sequence, match",
+			filter: "@.tags=~/^prod-/ && @.name=~/^prod-/",
+			yamlDoc: `---
+name: prod-x
+tags: [dev-1, prod-2]
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression filter on scalar, no match",
+			filter: "@.name=~/^prod-/",
+			yamlDoc: `---
+name: dev-x
+tags: [prod-2]
+`,
+			match: false,
+		},
+		{
+			name:   "regular expression non-match filter on sequence, match",
+			filter: "@.tags!~/^prod-/",
+			yamlDoc: `---
+tags: [dev-1, staging-2]
+`,
+			match: true,
+		},
+		{
+			name:   "regular expression non-match filter on sequence, no match",
+			filter: "@.tags!~/^prod-/",
+			yamlDoc: `---
+tags: [dev-1, prod-2, 3]
+`,
+			match: false,
+		},
+		{
+			name:   "array literal equality, exact match",
+			filter: "@.tags==['a','b']",
+			yamlDoc: `---
+tags: [a, b]
+`,
+			match: true,
+		},
+		{
+			name:   "array literal equality, different order",
+			filter: "@.tags==['a','b']",
+			yamlDoc: `---
+tags: [b, a]
+`,
+			match: false,
+		},
+		{
+			name:   "array literal equality, fewer elements",
+			filter: "@.tags==['a','b']",
+			yamlDoc: `---
+tags: [a]
+`,
+			match: false,
+		},
+		{
+			name:   "array literal equality, more elements",
+			filter: "@.tags==['a','b']",
+			yamlDoc: `---
+tags: [a, b, c]
+`,
+			match: false,
+		},
+		{
+			name:   "array literal equality, different types",
+			filter: "@.v==[1, 2.5, true, null, '3']",
+			yamlDoc: `---
+v: [1, 2.5, true, ~, 3]
+`,
+			match: false,
+		},
+		{
+			name:   "array literal equality, mixed types",
+			filter: "@.v==[1, 2.5, true, null, '3']",
+			yamlDoc: `---
+v: [1, 2.50, true, ~, "3"]
+`,
+			match: true,
+		},
+		{
+			name:   "empty array literal equality",
+			filter: "@.tags==[]",
+			yamlDoc: `---
+tags: []
+`,
+			match: true,
+		},
+		{
+			name:   "array literal equality with scalar",
+			filter: "@.tags==['a']",
+			yamlDoc: `---
+tags: a
+`,
+			match: false,
+		},
+		{
+			name:   "array literal equality with missing path",
+			filter: "@.tags==['a']",
+			yamlDoc: `---
+name: a
+`,
+			match: false,
+		},
+		{
+			name:   "array literal inequality, different order",
+			filter: "@.tags!=['a','b']",
+			yamlDoc: `---
+tags: [b, a]
+`,
+			match: true,
+		},
+		{
+			name:   "array literal inequality, exact match",
+			filter: "@.tags!=['a','b']",
+			yamlDoc: `---
+tags: [a, b]
+`,
+			match: false,
+		},
+		{
+			name:   "array literal inequality with missing path",
+			filter: "@.tags!=['a']",
+			yamlDoc: `---
+name: a
+`,
+			match: false,
+		},
+		{
+			name:   "between, in range",
+			filter: "@.port between 1024 and 65535",
+			yamlDoc: `---
+port: 8080
+`,
+			match: true,
+		},
+		{
+			name:   "between, below range",
+			filter: "@.port between 1024 and 65535",
+			yamlDoc: `---
+port: 1023
+`,
+			match: false,
+		},
+		{
+			name:   "between, above range",
+			filter: "@.port between 1024 and 65535",
+			yamlDoc: `---
+port: 65536
+`,
+			match: false,
+		},
+		{
+			name:   "between, lower bound",
+			filter: "@.port between 1024 and 65535",
+			yamlDoc: `---
+port: 1024
+`,
+			match: true,
+		},
+		{
+			name:   "between, upper bound",
+			filter: "@.port between 1024 and 65535",
+			yamlDoc: `---
+port: 65535
+`,
+			match: true,
+		},
+		{
+			name:   "between float bounds",
+			filter: "@.ratio between -0.5 and 0.5",
+			yamlDoc: `---
+ratio: 0.25
+`,
+			match: true,
+		},
+		{
+			name:   "between path bounds, in range",
+			filter: "@.port between @.min and @.max",
+			yamlDoc: `---
+port: 80
+min: 1
+max: 100
+`,
+			match: true,
+		},
+		{
+			name:   "between path bounds, out of range",
+			filter: "@.port between @.min and @.max",
+			yamlDoc: `---
+port: 180
+min: 1
+max: 100
+`,
+			match: false,
+		},
+		{
+			name:   "between with missing bound",
+			filter: "@.port between @.min and 100",
+			yamlDoc: `---
+port: 80
+`,
+			match: false,
+		},
+		{
+			name:   "between with missing path",
+			filter: "@.port between 1 and 100",
+			yamlDoc: `---
+name: a
+`,
+			match: false,
+		},
+		{
+			name:   "between non-numeric value",
+			filter: "@.port between 1 and 100",
+			yamlDoc: `---
+port: http
+`,
+			match: false,
+		},
+		{
+			name:   "negated between",
+			filter: "!(@.port between 1024 and 65535)",
+			yamlDoc: `---
+port: 80
+`,
+			match: true,
+		},
+		{
+			name:   "literal boolean predicate",
+			filter: "true",
+			rootDoc: `---
+- 1
+`,
+			match: true,
+		},
+		{
+			name:   "boolean expression involving literals",
+			filter: "!false",
+			rootDoc: `---
+- 1
+`,
+			match: true,
+		},
+	}
+
+	focussed := false
+	for _, tc := range cases {
+		if tc.focus {
+			focussed = true
+			break
+		}
+	}
+
+	for _, tc := range cases {
+		if focussed && !tc.focus {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
 			n := unmarshalDoc(t, tc.yamlDoc)
 			root := unmarshalDoc(t, tc.rootDoc)
 
 			parseTree := parseFilterString(tc.filter)
-			match := newFilter(parseTree)(n, root)
+			match := newFilter(parseTree, newOptions(nil))(n, root, &evaluation{})
 			require.Equal(t, tc.match, match)
 		})
 	}
@@ -752,6 +1543,253 @@ price: 8.95
 	}
 }
 
+func TestFilterPrecedence(t *testing.T) {
+	cases := []struct {
+		filter   string
+		expected func(a, b, c bool) bool
+	}{
+		{"!@.a && @.b", func(a, b, c bool) bool { return !a && b }},
+		{"@.a && !@.b", func(a, b, c bool) bool { return a && !b }},
+		{"!(@.a || @.b)", func(a, b, c bool) bool { return !(a || b) }},
+		{"!(@.a && @.b)", func(a, b, c bool) bool { return !(a && b) }},
+		{"!@.a || @.b", func(a, b, c bool) bool { return !a || b }},
+		{"!!@.a", func(a, b, c bool) bool { return a }},
+		{"@.a || @.b && @.c", func(a, b, c bool) bool { return a || (b && c) }},
+		{"@.a && @.b || @.c", func(a, b, c bool) bool { return (a && b) || c }},
+		{"(@.a || @.b) && @.c", func(a, b, c bool) bool { return (a || b) && c }},
+		{"!@.a || !@.b && @.c", func(a, b, c bool) bool { return !a || (!b && c) }},
+		{"!(@.a || @.b) && !@.c", func(a, b, c bool) bool { return !(a || b) && !c }},
+		{"@.a && !(@.b || !@.c)", func(a, b, c bool) bool { return a && !(b || !c) }},
+	}
+
+	for _, tc := range cases {
+		f := newFilter(parseFilterString(tc.filter), newOptions(nil))
+		for _, a := range []bool{false, true} {
+			for _, b := range []bool{false, true} {
+				for _, c := range []bool{false, true} {
+					t.Run(fmt.Sprintf("%s with a=%v b=%v c=%v", tc.filter, a, b, c), func(t *testing.T) {
+						doc := "x: 0\n"
+						if a {
+							doc += "a: 1\n"
+						}
+						if b {
+							doc += "b: 1\n"
+						}
+						if c {
+							doc += "c: 1\n"
+						}
+						n := unmarshalDoc(t, doc)
+						node := n.Content[0]
+						require.Equal(t, tc.expected(a, b, c), f(node, n, &evaluation{}))
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestFilterGroupedComparisons(t *testing.T) {
+	// each of a, b, and c is true if and only if the corresponding comparison or existence test is true
+	cases := []struct {
+		filter   string
+		expected func(a, b, c bool) bool
+	}{
+		{"(@.a > 1) && (@.b < 10) || @.override", func(a, b, c bool) bool { return (a && b) || c }},
+		{"(@.a > 1) && ((@.b < 10) || @.override)", func(a, b, c bool) bool { return a && (b || c) }},
+		{"@.a > 1 && (@.b < 10 || @.override)", func(a, b, c bool) bool { return a && (b || c) }},
+		{"(@.a > 1 || @.b < 10) && @.override", func(a, b, c bool) bool { return (a || b) && c }},
+		{"@.a > 1 || (@.b < 10 && @.override)", func(a, b, c bool) bool { return a || (b && c) }},
+		{"((@.a > 1)) && (((@.b < 10)))", func(a, b, c bool) bool { return a && b }},
+		{"!(@.a > 1 && @.b < 10) || @.override", func(a, b, c bool) bool { return !(a && b) || c }},
+		{"!((@.a > 1) || !(@.b < 10)) && !@.override", func(a, b, c bool) bool { return !(a || !b) && !c }},
+		{"(@.a > 1 && (@.b < 10 || (@.override && @.a > 1)))", func(a, b, c bool) bool { return a && (b || (c && a)) }},
+	}
+
+	for _, tc := range cases {
+		f := newFilter(parseFilterString(tc.filter), newOptions(nil))
+		for _, a := range []bool{false, true} {
+			for _, b := range []bool{false, true} {
+				for _, c := range []bool{false, true} {
+					t.Run(fmt.Sprintf("%s with a=%v b=%v c=%v", tc.filter, a, b, c), func(t *testing.T) {
+						aValue, bValue := 0, 20
+						if a {
+							aValue = 2
+						}
+						if b {
+							bValue = 5
+						}
+						doc := fmt.Sprintf("a: %d\nb: %d\n", aValue, bValue)
+						if c {
+							doc += "override: true\n"
+						}
+						n := unmarshalDoc(t, doc)
+						node := n.Content[0]
+						require.Equal(t, tc.expected(a, b, c), f(node, n, &evaluation{}))
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestFilterCrossTypeComparisons(t *testing.T) {
+	// equal, less, and greater are the results of @.a == @.b, @.a < @.b, and @.a > @.b, from which the results of the
+	// other comparisons follow, so that values which cannot be compared are unequal and are never ordered
+	cases := []struct {
+		name                 string
+		a, b                 string
+		equal, less, greater bool
+	}{
+		{name: "equal integers", a: "1", b: "1", equal: true},
+		{name: "ordered integers", a: "1", b: "2", less: true},
+		{name: "integer and float", a: "2", b: "1.5", greater: true},
+		{name: "equal integer and float", a: "1", b: "1.0", equal: true},
+		{name: "hexadecimal and decimal integers", a: "0x10", b: "16", equal: true},
+		{name: "octal and decimal integers", a: "0o17", b: "16", less: true},
+		{name: "integer with separators", a: "1_000", b: "999", greater: true},
+		{name: "integer too large for int64", a: "0xFFFFFFFFFFFFFFFF", b: "1", greater: true},
+		{name: "integers too large for int64", a: "0xFFFFFFFFFFFFFFFF", b: "18446744073709551615", equal: true},
+		{name: "large octal and binary integers", a: "0o1777777777777777777777", b: "0b1111111111111111111111111111111111111111111111111111111111111111", equal: true},
+		{name: "invalid tagged integer and integer", a: "!!int abc", b: "1"},
+		{name: "invalid tagged integer and itself", a: "!!int abc", b: "!!int abc"},
+		{name: "invalid tagged float and float", a: "!!float abc", b: "1.5"},
+		{name: "infinity and float", a: ".inf", b: "1e300", greater: true},
+		{name: "negative infinity and integer", a: "-.inf", b: "-1", less: true},
+		{name: "NaN and itself", a: ".nan", b: ".nan"},
+		{name: "NaN and integer", a: ".nan", b: "1"},
+		{name: "equal strings", a: "x", b: "x", equal: true},
+		{name: "different strings", a: "x", b: "y"},
+		{name: "numeric string and integer", a: "'2'", b: "10", less: true},
+		{name: "non-numeric string and integer", a: "x", b: "1"},
+		{name: "integer and boolean", a: "1", b: "true"},
+		{name: "equal booleans", a: "true", b: "true", equal: true},
+		{name: "different booleans", a: "true", b: "false"},
+		{name: "integer and null", a: "0", b: "null"},
+		{name: "nulls", a: "null", b: "~", equal: true},
+		{name: "string and null", a: "''", b: "null"},
+		{name: "integer and sequence", a: "[1]", b: "1"},
+		{name: "equal mappings", a: "{x: 1}", b: "{x: 1}", equal: true},
+		{name: "different mappings", a: "{x: 1}", b: "{x: 2}"},
+		{name: "mappings in different orders", a: "{x: 1, y: 2}", b: "{y: 2, x: 1}"},
+		{name: "equal sequences", a: "[1, [x]]", b: "[1, [x]]", equal: true},
+		{name: "empty mapping and sequence", a: "{}", b: "[]"},
+		{name: "equal custom scalars", a: "!c x", b: "!c x", equal: true},
+		{name: "different custom tags", a: "!c x", b: "!d x"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := unmarshalDoc(t, fmt.Sprintf("a: %s\nb: %s\n", tc.a, tc.b))
+			node := n.Content[0]
+			expected := map[string]bool{
+				"==": tc.equal,
+				"!=": !tc.equal,
+				"<":  tc.less,
+				"<=": tc.less || tc.equal,
+				">":  tc.greater,
+				">=": tc.greater || tc.equal,
+			}
+			for operator, match := range expected {
+				f := newFilter(parseFilterString("@.a "+operator+" @.b"), newOptions(nil))
+				require.Equal(t, match, f(node, n, &evaluation{}), "@.a %s @.b", operator)
+			}
+		})
+	}
+}
+
+func TestFilterUnparseableNumbers(t *testing.T) {
+	// a number which cannot be parsed is incomparable, so that every comparison with it is false, except !=
+	n := unmarshalDoc(t, "big: 0xFFFFFFFFFFFFFFFF\nint: !!int abc\nfloat: !!float abc\n")
+	node := n.Content[0]
+	cases := []struct {
+		filter string
+		match  bool
+	}{
+		{filter: "@.big > 1", match: true},
+		{filter: "@.big between 1 and 1e20", match: true},
+		{filter: "@.int == 1"},
+		{filter: "@.int != 1", match: true},
+		{filter: "@.int > 1"},
+		{filter: "@.int between 0 and 1"},
+		{filter: "@.float == 1.5"},
+		{filter: "@.float < 1.5"},
+		{filter: "@.float between 0 and 2"},
+		{filter: "@.int == @.float"},
+	}
+	for _, tc := range cases {
+		f := newFilter(parseFilterString(tc.filter), newOptions(nil))
+		require.Equal(t, tc.match, f(node, n, &evaluation{}), tc.filter)
+	}
+}
+
+func TestFilterMissingOperands(t *testing.T) {
+	// a comparison with an operand which produces no values is false, whatever the operator, so that neither
+	// @.a == @.b nor @.a != @.b is true unless both a and b are present, whereas the negation of either is true
+	cases := []struct {
+		name           string
+		doc            string
+		equal, unequal bool // the results of @.a == @.b and @.a != @.b
+	}{
+		{name: "both present and equal", doc: "a: 1\nb: 1\n", equal: true},
+		{name: "both present and unequal", doc: "a: 1\nb: 2\n", unequal: true},
+		{name: "missing left", doc: "b: 1\n"},
+		{name: "missing right", doc: "a: 1\n"},
+		{name: "both missing", doc: "c: 1\n"},
+		{name: "null is not missing", doc: "a: null\nb: 1\n", unequal: true},
+		{name: "nulls are not missing", doc: "a: null\nb: null\n", equal: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := unmarshalDoc(t, tc.doc)
+			node := n.Content[0]
+			expected := map[string]bool{
+				"@.a == @.b":    tc.equal,
+				"@.a != @.b":    tc.unequal,
+				"!(@.a == @.b)": !tc.equal,
+				"!(@.a != @.b)": !tc.unequal,
+			}
+			for filter, match := range expected {
+				f := newFilter(parseFilterString(filter), newOptions(nil))
+				require.Equal(t, match, f(node, n, &evaluation{}), filter)
+			}
+		})
+	}
+}
+
+func TestFilterShortCircuit(t *testing.T) {
+	// the right hand operand refers to an unbound name, so evaluating it causes the evaluation to fail
+	cases := []struct {
+		filter            string
+		expected          bool
+		evaluatesOperand2 bool
+	}{
+		{"@.a && $unbound..*", false, false},
+		{"@.x && $unbound..*", false, true},
+		{"@.x || $unbound..*", true, false},
+		{"@.a || $unbound..*", false, true},
+		{"!@.x && $unbound..*", false, false},
+		{"@.x || @.a && $unbound..*", true, false},
+		{"(@.a || @.x) || $unbound..*", true, false},
+		{"@.a && (@.x || $unbound..*)", false, false},
+		{"@.x==1 && $unbound..*", false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.filter, func(t *testing.T) {
+			n := unmarshalDoc(t, "x: 0\ny: {z: [1, 2]}\n")
+			f := newFilter(parseFilterString(tc.filter), newOptions(nil))
+			e := newEvaluation(context.Background())
+			require.Equal(t, tc.expected, f(n.Content[0], n, e))
+			if tc.evaluatesOperand2 {
+				require.EqualError(t, e.err, "no binding for $unbound")
+			} else {
+				require.NoError(t, e.err)
+			}
+		})
+	}
+}
+
 func unmarshalDoc(t *testing.T, doc string) *yaml.Node {
 	var n yaml.Node
 	err := yaml.Unmarshal([]byte(doc), &n)
@@ -0,0 +1,399 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Value is an argument or the result of a function called in a filter. See RegisterFilterFunc.
+//
+// The zero Value is no value at all. A function which returns the zero Value produces no value, so that, for
+// example, a comparison with the result of the function is false.
+type Value struct {
+	node *yaml.Node
+}
+
+// NodeValue returns a Value holding the given node, which may be a mapping or a sequence as well as a scalar.
+func NodeValue(node *yaml.Node) Value {
+	return Value{node: node}
+}
+
+// StringValue returns a Value holding the given string.
+func StringValue(s string) Value {
+	return scalarValue(strTag, s)
+}
+
+// IntValue returns a Value holding the given integer.
+func IntValue(i int) Value {
+	return scalarValue(intTag, strconv.Itoa(i))
+}
+
+// FloatValue returns a Value holding the given floating point number.
+func FloatValue(f float64) Value {
+	return scalarValue(floatTag, strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// BoolValue returns a Value holding the given boolean.
+func BoolValue(b bool) Value {
+	return scalarValue(boolTag, strconv.FormatBool(b))
+}
+
+// NullValue returns a Value holding null.
+func NullValue() Value {
+	return scalarValue(nullTag, "null")
+}
+
+func scalarValue(tag, value string) Value {
+	return Value{node: &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   tag,
+		Value: value,
+	}}
+}
+
+// Node returns the node held by the Value or, for the zero Value, nil.
+func (v Value) Node() *yaml.Node {
+	return v.node
+}
+
+// AsString returns the string held by the Value and true or, if the Value does not hold a string, "" and false.
+func (v Value) AsString() (string, bool) {
+	if !v.hasTag(strTag) {
+		return "", false
+	}
+	return v.node.Value, true
+}
+
+// AsInt returns the integer held by the Value and true or, if the Value does not hold an integer, 0 and false.
+func (v Value) AsInt() (int, bool) {
+	var i int
+	if !v.hasTag(intTag) || v.node.Decode(&i) != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// AsFloat returns the number held by the Value and true or, if the Value does not hold an integer or floating
+// point number, 0 and false.
+func (v Value) AsFloat() (float64, bool) {
+	var f float64
+	if !v.hasTag(intTag) && !v.hasTag(floatTag) || v.node.Decode(&f) != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// AsBool returns the boolean held by the Value and true or, if the Value does not hold a boolean, false and false.
+func (v Value) AsBool() (bool, bool) {
+	var b bool
+	if !v.hasTag(boolTag) || v.node.Decode(&b) != nil {
+		return false, false
+	}
+	return b, true
+}
+
+func (v Value) hasTag(tag string) bool {
+	return v.node != nil && v.node.Kind == yaml.ScalarNode && v.node.ShortTag() == tag
+}
+
+// valueOfTypedValue returns a Value holding the given literal or numeric value of a filter. A regular expression is
+// passed to a function as a string.
+func valueOfTypedValue(v typedValue) Value {
+	switch v.typ {
+	case stringValueType, regularExpressionValueType:
+		return scalarValue(strTag, v.val)
+
+	case intValueType:
+		return scalarValue(intTag, v.val)
+
+	case floatValueType:
+		return scalarValue(floatTag, v.val)
+
+	case booleanValueType:
+		return scalarValue(boolTag, v.val)
+
+	case nullValueType:
+		return scalarValue(nullTag, v.val)
+
+	case timestampValueType:
+		return scalarValue(timeTag, v.val)
+
+	default:
+		return Value{}
+	}
+}
+
+// sizeOf returns the size of the given node, which is the number of characters of a scalar, the number of elements
+// of a sequence, or the number of entries of a mapping, and true or, if the node has no size, 0 and false.
+func sizeOf(node *yaml.Node) (int, bool) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return utf8.RuneCountInString(node.Value), true
+
+	case yaml.SequenceNode:
+		return len(node.Content), true
+
+	case yaml.MappingNode:
+		return len(node.Content) / 2, true
+
+	default:
+		return 0, false
+	}
+}
+
+// length is the built-in filter function which returns the size of its argument. See sizeOf.
+func length(args []Value) (Value, error) {
+	if len(args) != 1 {
+		return Value{}, fmt.Errorf("expected 1 argument but got %d", len(args))
+	}
+	size, ok := sizeOf(args[0].node)
+	if !ok {
+		return Value{}, nil
+	}
+	return IntValue(size), nil
+}
+
+// typeOf returns the JSON Schema type of the given node, which is "object" for a mapping, "array" for a sequence, and
+// "number", "boolean", "null", or "string" for a scalar, according to its tag, and true or, if the node has no such
+// type, "" and false. A scalar with a tag other than those of numbers, booleans, and null, such as a timestamp, is a
+// string.
+func typeOf(node *yaml.Node) (string, bool) {
+	if node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node == nil {
+		return "", false
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object", true
+
+	case yaml.SequenceNode:
+		return "array", true
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case intTag, floatTag:
+			return "number", true
+
+		case boolTag:
+			return "boolean", true
+
+		case nullTag:
+			return "null", true
+
+		default:
+			return "string", true
+		}
+
+	default:
+		return "", false
+	}
+}
+
+// typeFunc is the built-in filter function named type which returns the JSON Schema type of its argument. See typeOf.
+func typeFunc(args []Value) (Value, error) {
+	if len(args) != 1 {
+		return Value{}, fmt.Errorf("expected 1 argument but got %d", len(args))
+	}
+	t, ok := typeOf(args[0].node)
+	if !ok {
+		return Value{}, nil
+	}
+	return StringValue(t), nil
+}
+
+// stringOf returns the value of the given node and true if the node is a scalar whose JSON Schema type is "string"
+// (see typeOf) or, otherwise, "" and false.
+func stringOf(node *yaml.Node) (string, bool) {
+	if t, ok := typeOf(node); !ok || t != "string" {
+		return "", false
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node.Value, true
+}
+
+// stringPredicate returns a built-in filter function which returns the result of the given predicate of its two
+// arguments if both are strings (see stringOf) and otherwise returns no value.
+func stringPredicate(predicate func(s, t string) bool) filterFunc {
+	return func(args []Value) (Value, error) {
+		if len(args) != 2 {
+			return Value{}, fmt.Errorf("expected 2 arguments but got %d", len(args))
+		}
+		s, ok := stringOf(args[0].node)
+		if !ok {
+			return Value{}, nil
+		}
+		t, ok := stringOf(args[1].node)
+		if !ok {
+			return Value{}, nil
+		}
+		return BoolValue(predicate(s, t)), nil
+	}
+}
+
+// filterFunc is a function which may be called in a filter. See RegisterFilterFunc.
+type filterFunc func(args []Value) (Value, error)
+
+var (
+	filterFuncsMutex sync.RWMutex
+	filterFuncs      = map[string]filterFunc{
+		"length":     length,
+		"type":       typeFunc,
+		"startsWith": stringPredicate(strings.HasPrefix),
+		"endsWith":   stringPredicate(strings.HasSuffix),
+	}
+)
+
+// RegisterFilterFunc makes a function available, under the given name, to the filters of all paths. For example,
+// after registering a function named lower which converts a string to lower case, the path
+// `$[?(lower(@.name)=='admin')]` matches the elements of a sequence whose name is "admin" in any case.
+//
+// The arguments of a call are filter terms, such as `@.name`, `$params.x`, `@index`, literals, and other function
+// calls. Since a term may have any number of values, the function is called once for each combination of the values
+// of its arguments and the call produces the results of those calls. If an argument has no values, the function is
+// not called and the call produces no values. A call used as a filter predicate, such as `$[?(isEmpty(@.items))]`,
+// is true if and only if one of the results is the boolean true.
+//
+// If the function returns an error, the evaluation of the path fails with an error wrapping the function's error.
+//
+// The function length is built in: it returns the number of characters of a scalar, the number of elements of a
+// sequence, or the number of entries of a mapping, so `$[?(length(@.name) < 3)]` matches the elements of a sequence
+// whose name is shorter than three characters. A child named size at the end of a path in a filter produces the same
+// values, so `$[?(@.name.size < 3)]` is equivalent, except that `.size` selects the child named size of a mapping
+// which has one.
+//
+// The function type is also built in: it returns the JSON Schema type of its argument, which is "object" for a
+// mapping, "array" for a sequence, and "number", "boolean", "null", or "string" for a scalar, according to its tag,
+// so `$..[?(type(@)=='object')]` matches every mapping.
+//
+// The functions startsWith and endsWith are also built in: each returns true if and only if its first argument
+// starts or, respectively, ends with its second argument, so `$[?(endsWith(@.name, '.yaml'))]` matches the elements
+// of a sequence whose name ends with ".yaml". Each returns no value unless both arguments are strings, which include
+// the scalars whose type is "string", such as timestamps, but not numbers. A built-in function may be replaced by
+// registering another function with the same name.
+//
+// A name consists of a letter or "_" followed by any number of letters, digits, and "_". RegisterFilterFunc panics
+// if the name is not valid or fn is nil. Registering a function under a name which is already registered replaces
+// the previous function. RegisterFilterFunc is typically called from an init function.
+func RegisterFilterFunc(name string, fn func(args []Value) (Value, error)) {
+	if !isFilterFuncName(name) {
+		panic(fmt.Sprintf("yamlpath: invalid filter function name %q", name))
+	}
+	if fn == nil {
+		panic(fmt.Sprintf("yamlpath: nil filter function %s", name))
+	}
+	filterFuncsMutex.Lock()
+	defer filterFuncsMutex.Unlock()
+	filterFuncs[name] = fn
+}
+
+// lookupFilterFunc returns the function registered under the given name or, if there is no such function, nil.
+func lookupFilterFunc(name string) filterFunc {
+	filterFuncsMutex.RLock()
+	defer filterFuncsMutex.RUnlock()
+	return filterFuncs[name]
+}
+
+func isFilterFuncName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// functionCallScanner returns a scanner for the results of the function call represented by the given node.
+func functionCallScanner(n *filterNode, o *options) filterScanner {
+	name := strings.TrimSuffix(n.lexeme.val, filterOpenBracket)
+	args := []func(node, root *yaml.Node, e *evaluation) []Value{}
+	for _, c := range n.children {
+		args = append(args, argumentScanner(c, o))
+	}
+	return func(node, root *yaml.Node, e *evaluation) []typedValue {
+		fn := lookupFilterFunc(name)
+		if fn == nil {
+			e.fail(fmt.Errorf("unknown filter function %s", name))
+			return []typedValue{}
+		}
+
+		argValues := [][]Value{}
+		for _, arg := range args {
+			argValues = append(argValues, arg(node, root, e))
+		}
+
+		v := []typedValue{}
+		var call func(prefix []Value) bool
+		call = func(prefix []Value) bool {
+			if len(prefix) < len(argValues) {
+				for _, a := range argValues[len(prefix)] {
+					if !call(append(prefix[:len(prefix):len(prefix)], a)) {
+						return false
+					}
+				}
+				return true
+			}
+			result, err := fn(prefix)
+			if err != nil {
+				e.fail(fmt.Errorf("filter function %s: %w", name, err))
+				return false
+			}
+			if result.node != nil {
+				v = append(v, typedValueOfNode(result.node))
+			}
+			return true
+		}
+		if !call([]Value{}) {
+			return []typedValue{}
+		}
+		return v
+	}
+}
+
+// argumentScanner returns a function which returns the values of an argument of a function call. An argument which
+// is a path produces the matching nodes, including mappings and sequences.
+func argumentScanner(n *filterNode, o *options) func(node, root *yaml.Node, e *evaluation) []Value {
+	if n == nil {
+		return func(node, root *yaml.Node, e *evaluation) []Value {
+			return []Value{}
+		}
+	}
+	if n.isItemFilter() {
+		path := pathNodeScanner(n, o)
+		return func(node, root *yaml.Node, e *evaluation) []Value {
+			v := []Value{}
+			for _, m := range path(node, root, e) {
+				v = append(v, NodeValue(m))
+			}
+			return v
+		}
+	}
+	scanner := newFilterScanner(n, o)
+	return func(node, root *yaml.Node, e *evaluation) []Value {
+		v := []Value{}
+		for _, t := range scanner(node, root, e) {
+			if a := valueOfTypedValue(t); a.node != nil {
+				v = append(v, a)
+			}
+		}
+		return v
+	}
+}
@@ -0,0 +1,555 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	yamlpath.RegisterFilterFunc("lower", func(args []yamlpath.Value) (yamlpath.Value, error) {
+		if len(args) != 1 {
+			return yamlpath.Value{}, errors.New("expected one argument")
+		}
+		s, ok := args[0].AsString()
+		if !ok {
+			return yamlpath.Value{}, nil
+		}
+		return yamlpath.StringValue(strings.ToLower(s)), nil
+	})
+	yamlpath.RegisterFilterFunc("hasPrefix", func(args []yamlpath.Value) (yamlpath.Value, error) {
+		s, _ := args[0].AsString()
+		prefix, _ := args[1].AsString()
+		return yamlpath.BoolValue(strings.HasPrefix(s, prefix)), nil
+	})
+	yamlpath.RegisterFilterFunc("always_fails", func(args []yamlpath.Value) (yamlpath.Value, error) {
+		return yamlpath.Value{}, errors.New("oops")
+	})
+}
+
+func TestRegisterFilterFunc(t *testing.T) {
+	y := `---
+users:
+- name: Admin
+  roles: [write, read]
+- name: guest
+  roles: [read]
+- name: ADMIN
+- name: 42
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+		expectedError   string
+	}{
+		{
+			name:            "function of path compared with string literal",
+			path:            "$.users[?(lower(@.name)=='admin')].name",
+			expectedStrings: []string{"Admin\n", "ADMIN\n"},
+		},
+		{
+			name:            "function on right hand side of comparison",
+			path:            "$.users[?('guest'==lower(@.name))].name",
+			expectedStrings: []string{"guest\n"},
+		},
+		{
+			name:            "function of literal",
+			path:            "$.users[?(@.name==lower('GUEST'))].name",
+			expectedStrings: []string{"guest\n"},
+		},
+		{
+			name:            "nested function calls",
+			path:            "$.users[?(length(lower(@.name))==5)].name",
+			expectedStrings: []string{"Admin\n", "guest\n", "ADMIN\n"},
+		},
+		{
+			name:            "function of sequence",
+			path:            "$.users[?(length(@.roles) > 1)].name",
+			expectedStrings: []string{"Admin\n"},
+		},
+		{
+			name:            "function with several arguments used as a predicate",
+			path:            "$.users[?(hasPrefix(@.name, 'gu'))].name",
+			expectedStrings: []string{"guest\n"},
+		},
+		{
+			name:            "function combined with other filters",
+			path:            "$.users[?(@.roles && !hasPrefix(lower(@.name), 'gu'))].name",
+			expectedStrings: []string{"Admin\n"},
+		},
+		{
+			name:            "function returning no value",
+			path:            "$.users[?(lower(@.name)=='42')].name",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "missing argument value",
+			path:            "$.users[?(length(@.nosuch)==0)].name",
+			expectedStrings: []string{},
+		},
+		{
+			name:          "function returning an error",
+			path:          "$.users[?(always_fails(@.name))]",
+			expectedError: "filter function always_fails: oops",
+		},
+		{
+			name:          "unknown function",
+			path:          "$.users[?(nosuch(@.name))]",
+			expectedError: "unknown filter function nosuch",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFilterFuncOperands(t *testing.T) {
+	y := `---
+- {n: 1, a: [x, y], b: [x, z], name: Admin, alias: ADMIN}
+- {n: 2, a: [x], b: [y, z], name: guest, alias: visitor}
+- {n: 3, a: [x, y, z], b: [x], name: root, alias: Root}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+	}{
+		{
+			name:            "equality of function results",
+			path:            "$[?(length(@.a) == length(@.b))].n",
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "inequality of function results",
+			path:            "$[?(length(@.a) != length(@.b))].n",
+			expectedStrings: []string{"2\n", "3\n"},
+		},
+		{
+			name:            "ordering of function results",
+			path:            "$[?(length(@.a) > length(@.b))].n",
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "equality of string function results",
+			path:            "$[?(lower(@.name) == lower(@.alias))].n",
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "function result greater than or equal to integer literal",
+			path:            "$[?(length(@.a) >= 2)].n",
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "integer literal less than function result",
+			path:            "$[?(2 < length(@.a))].n",
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "function result equal to path",
+			path:            "$[?(length(@.a) == @.n)].n",
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "path less than function result",
+			path:            "$[?(@.n < length(@.a))].n",
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "function result matching regular expression",
+			path:            "$[?(lower(@.alias) =~ /^r/)].n",
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "function result of different type",
+			path:            "$[?(length(@.a) == lower(@.name))].n",
+			expectedStrings: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			require.NoError(t, p.Validate())
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestLengthAndSize(t *testing.T) {
+	y := `---
+- {n: 1, name: ab, tags: [x, y, z], labels: {a: 1}}
+- {n: 2, name: ünï, tags: [], labels: {a: 1, b: 2}}
+- {n: 3, name: abcd, tags: [x], labels: {size: 10}}
+- {n: 4, name: 42}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		lengthPath      string
+		sizePath        string
+		expectedStrings []string
+	}{
+		{
+			name:            "characters of string",
+			lengthPath:      "$[?(length(@.name) < 3)].n",
+			sizePath:        "$[?(@.name.size < 3)].n",
+			expectedStrings: []string{"1\n", "4\n"},
+		},
+		{
+			name:            "characters of non-ASCII string",
+			lengthPath:      "$[?(length(@.name) == 3)].n",
+			sizePath:        "$[?(@.name.size == 3)].n",
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "elements of sequence",
+			lengthPath:      "$[?(length(@.tags) > 0)].n",
+			sizePath:        "$[?(@.tags.size > 0)].n",
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "empty sequence",
+			lengthPath:      "$[?(length(@.tags) == 0)].n",
+			sizePath:        "$[?(@.tags.size == 0)].n",
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "entries of mapping",
+			lengthPath:      "$[?(length(@.labels) == 2)].n",
+			sizePath:        "$[?(@.labels.size == 2)].n",
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "size of current node",
+			lengthPath:      "$[?(length(@) == 4)].n",
+			sizePath:        "$[?(@.size == 4)].n",
+			expectedStrings: []string{"1\n", "2\n", "3\n"},
+		},
+		{
+			name:            "missing node",
+			lengthPath:      "$[?(length(@.nosuch) == 0)].n",
+			sizePath:        "$[?(@.nosuch.size == 0)].n",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "size compared with size",
+			lengthPath:      "$[?(length(@.tags) > length(@.labels))].n",
+			sizePath:        "$[?(@.tags.size > @.labels.size)].n",
+			expectedStrings: []string{"1\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, path := range []string{tc.lengthPath, tc.sizePath} {
+				p, err := yamlpath.NewPath(path)
+				require.NoError(t, err)
+
+				actual, err := p.Find(&n)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedStrings, encodeNodes(t, actual), path)
+			}
+		})
+	}
+
+	t.Run("child named size", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$[?(@.labels.size == 10)].n")
+		require.NoError(t, err)
+
+		actual, err := p.Find(&n)
+		require.NoError(t, err)
+		require.Equal(t, []string{"3\n"}, encodeNodes(t, actual))
+	})
+
+	t.Run("wrong number of arguments", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$[?(length(@.name, @.tags) == 1)].n")
+		require.NoError(t, err)
+
+		_, err = p.Find(&n)
+		require.EqualError(t, err, "filter function length: expected 1 argument but got 2")
+	})
+}
+
+func TestTypeFunc(t *testing.T) {
+	y := `---
+mixed:
+- text
+- "42"
+- 42
+- 1.5
+- true
+- null
+- 2023-01-02
+- [a]
+- {k: v}
+- &anchor {x: 1}
+- *anchor
+- !custom tagged
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+	}{
+		{
+			name:            "object",
+			path:            "$.mixed[?(type(@)=='object')]",
+			expectedStrings: []string{"{k: v}\n", "&anchor {x: 1}\n", "*anchor\n"},
+		},
+		{
+			name:            "array",
+			path:            "$.mixed[?(type(@)=='array')]",
+			expectedStrings: []string{"[a]\n"},
+		},
+		{
+			name:            "string",
+			path:            "$.mixed[?(type(@)=='string')]",
+			expectedStrings: []string{"text\n", "\"42\"\n", "2023-01-02\n", "!custom tagged\n"},
+		},
+		{
+			name:            "number",
+			path:            "$.mixed[?(type(@)=='number')]",
+			expectedStrings: []string{"42\n", "1.5\n"},
+		},
+		{
+			name:            "boolean",
+			path:            "$.mixed[?(type(@)=='boolean')]",
+			expectedStrings: []string{"true\n"},
+		},
+		{
+			name:            "null",
+			path:            "$.mixed[?(type(@)=='null')]",
+			expectedStrings: []string{"null\n"},
+		},
+		{
+			name:            "not equal",
+			path:            "$.mixed[?(type(@)!='string' && type(@)!='object')]",
+			expectedStrings: []string{"42\n", "1.5\n", "true\n", "null\n", "[a]\n"},
+		},
+		{
+			name:            "type of child",
+			path:            "$[?(type(@.mixed)=='array')].mixed[0]",
+			expectedStrings: []string{"text\n"},
+		},
+		{
+			name:            "missing node",
+			path:            "$[?(type(@.nosuch)=='null')]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "recursive descent",
+			path:            "$..[?(type(@)=='object')].x",
+			expectedStrings: []string{"1\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+
+	t.Run("wrong number of arguments", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.mixed[?(type() == 'null')]")
+		require.NoError(t, err)
+
+		_, err = p.Find(&n)
+		require.EqualError(t, err, "filter function type: expected 1 argument but got 0")
+	})
+}
+
+func TestStartsWithAndEndsWith(t *testing.T) {
+	y := `---
+- {n: 1, name: foo.yaml}
+- {n: 2, name: foobar.yml}
+- {n: 3, name: bar.yaml}
+- {n: 4, name: 42}
+- {n: 5, name: [foo.yaml]}
+- {n: 6, name: 2023-01-02}
+- {n: 7}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+	}{
+		{
+			name:            "prefix",
+			path:            "$[?(startsWith(@.name, 'foo'))].n",
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "suffix",
+			path:            "$[?(endsWith(@.name, '.yaml'))].n",
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "no matching prefix",
+			path:            "$[?(startsWith(@.name, 'baz'))].n",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "no matching suffix",
+			path:            "$[?(endsWith(@.name, '.json'))].n",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "negated",
+			path:            "$[?(!endsWith(@.name, '.yaml'))].n",
+			expectedStrings: []string{"2\n", "4\n", "5\n", "6\n", "7\n"},
+		},
+		{
+			name:            "combined",
+			path:            "$[?(startsWith(@.name, 'foo') && endsWith(@.name, '.yaml'))].n",
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "number is not a string",
+			path:            "$[?(startsWith(@.name, '4'))].n",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "number literal is not a string",
+			path:            "$[?(endsWith(@.name, 2))].n",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "sequence is not a string",
+			path:            "$[?(endsWith(@.name[0], '.yaml') && !endsWith(@.name, '.yaml'))].n",
+			expectedStrings: []string{"5\n"},
+		},
+		{
+			name:            "timestamp is a string",
+			path:            "$[?(startsWith(@.name, '2023-'))].n",
+			expectedStrings: []string{"6\n"},
+		},
+		{
+			name:            "compared with boolean",
+			path:            "$[?(startsWith(@.name, 'foo') == false)].n",
+			expectedStrings: []string{"3\n", "6\n"},
+		},
+		{
+			name:            "empty prefix",
+			path:            "$[?(startsWith(@.name, ''))].n",
+			expectedStrings: []string{"1\n", "2\n", "3\n", "6\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+
+	t.Run("wrong number of arguments", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$[?(startsWith(@.name))]")
+		require.NoError(t, err)
+
+		_, err = p.Find(&n)
+		require.EqualError(t, err, "filter function startsWith: expected 2 arguments but got 1")
+	})
+}
+
+func TestRegisterFilterFuncInvalid(t *testing.T) {
+	fn := func(args []yamlpath.Value) (yamlpath.Value, error) {
+		return yamlpath.Value{}, nil
+	}
+	require.PanicsWithValue(t, `yamlpath: invalid filter function name "1st"`, func() {
+		yamlpath.RegisterFilterFunc("1st", fn)
+	})
+	require.PanicsWithValue(t, `yamlpath: invalid filter function name ""`, func() {
+		yamlpath.RegisterFilterFunc("", fn)
+	})
+	require.PanicsWithValue(t, "yamlpath: nil filter function f", func() {
+		yamlpath.RegisterFilterFunc("f", nil)
+	})
+}
+
+func TestValue(t *testing.T) {
+	s, ok := yamlpath.StringValue("x").AsString()
+	require.True(t, ok)
+	require.Equal(t, "x", s)
+	_, ok = yamlpath.StringValue("x").AsInt()
+	require.False(t, ok)
+
+	i, ok := yamlpath.IntValue(-3).AsInt()
+	require.True(t, ok)
+	require.Equal(t, -3, i)
+	f, ok := yamlpath.IntValue(-3).AsFloat()
+	require.True(t, ok)
+	require.Equal(t, -3.0, f)
+
+	f, ok = yamlpath.FloatValue(1.5).AsFloat()
+	require.True(t, ok)
+	require.Equal(t, 1.5, f)
+	_, ok = yamlpath.FloatValue(1.5).AsInt()
+	require.False(t, ok)
+
+	b, ok := yamlpath.BoolValue(true).AsBool()
+	require.True(t, ok)
+	require.True(t, b)
+
+	_, ok = yamlpath.NullValue().AsString()
+	require.False(t, ok)
+	require.Equal(t, "!!null", yamlpath.NullValue().Node().ShortTag())
+
+	require.Nil(t, yamlpath.Value{}.Node())
+	_, ok = yamlpath.Value{}.AsString()
+	require.False(t, ok)
+}
@@ -0,0 +1,52 @@
+//go:build go1.23
+
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"context"
+	"iter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// All applies the Path to a YAML node and returns an iterator over the subnodes which match the Path, so that a
+// caller may range over the matches and stop early, for example:
+//
+//	for node, err := range p.All(root) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// If applying the Path fails, the iterator yields a nil node and the error after the preceding matches.
+func (p *Path) All(node *yaml.Node) iter.Seq2[*yaml.Node, error] {
+	return func(yield func(*yaml.Node, error) bool) {
+		e := newEvaluation(context.Background())
+		seen := map[*yaml.Node]bool{}
+		i := p.f(node, node, e)
+		for n, ok := i(); ok; n, ok = i() {
+			if e.err != nil {
+				break
+			}
+			if p.opts != nil && p.opts.distinct {
+				if seen[n] {
+					continue
+				}
+				seen[n] = true
+			}
+			if !yield(n, nil) {
+				return
+			}
+		}
+		if e.err != nil {
+			yield(nil, e.err)
+		}
+	}
+}
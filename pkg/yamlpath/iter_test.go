@@ -0,0 +1,82 @@
+//go:build go1.23
+
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAll(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{"a": [1, 2, 3], "b": {"c": 4}}`), &n)
+	require.NoError(t, err)
+
+	p, err := yamlpath.NewPath("$..*")
+	require.NoError(t, err)
+
+	t.Run("collect all matches", func(t *testing.T) {
+		expected, err := p.Find(&n)
+		require.NoError(t, err)
+
+		actual := []*yaml.Node{}
+		for node, err := range p.All(&n) {
+			require.NoError(t, err)
+			actual = append(actual, node)
+		}
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("break early", func(t *testing.T) {
+		actual := []string{}
+		for node, err := range p.All(&n) {
+			require.NoError(t, err)
+			if node.Kind != yaml.ScalarNode {
+				continue
+			}
+			actual = append(actual, node.Value)
+			if len(actual) == 2 {
+				break
+			}
+		}
+		require.Equal(t, []string{"1", "2"}, actual)
+	})
+
+	t.Run("distinct results", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$..a..*", yamlpath.WithDistinctResults())
+		require.NoError(t, err)
+		expected, err := p.Find(&n)
+		require.NoError(t, err)
+
+		actual := []*yaml.Node{}
+		for node, err := range p.All(&n) {
+			require.NoError(t, err)
+			actual = append(actual, node)
+		}
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.a[?(@==$x)]")
+		require.NoError(t, err)
+
+		nodes := []*yaml.Node{}
+		var errs []error
+		for node, err := range p.All(&n) {
+			nodes = append(nodes, node)
+			errs = append(errs, err)
+		}
+		require.Equal(t, []*yaml.Node{nil}, nodes)
+		require.Len(t, errs, 1)
+		require.EqualError(t, errs[0], "no binding for $x")
+	})
+}
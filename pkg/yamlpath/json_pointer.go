@@ -0,0 +1,108 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotSingular is returned by ToJSONPointer when a Path may match more than one node. See IsSingular.
+var ErrNotSingular = errors.New("path is not singular")
+
+// jsonPointerEscaper escapes a reference token of a JSON Pointer as described in RFC 6901.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonPointerUnescaper reverses jsonPointerEscaper.
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// ToJSONPointer returns the JSON Pointer (RFC 6901) equivalent to the Path, for example `/spec/replicas` for
+// `$['spec']['replicas']`. The root of the Path corresponds to the whole document, that is the empty JSON Pointer.
+//
+// If the Path is not singular, ToJSONPointer returns an error wrapping ErrNotSingular. Since JSON Pointer has no
+// notion of counting from the end of an array, a negative array index also results in an error.
+func (p *Path) ToJSONPointer() (string, error) {
+	if !p.IsSingular() {
+		return "", fmt.Errorf("%w: %q has no JSON Pointer equivalent", ErrNotSingular, p.expr)
+	}
+
+	var s strings.Builder
+	for _, lx := range lexAll(p.expr) {
+		var token string
+		switch lx.typ {
+		case lexemeDotChild:
+			token = unescape(strings.TrimPrefix(lx.val, dot))
+
+		case lexemeUndottedChild:
+			token = unescape(lx.val)
+
+		case lexemeBracketChild:
+			token = bracketChildNamesOf(lx.val)[0] // a singular path has exactly one name in each bracket child
+
+		case lexemeArraySubscript:
+			index, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(lx.val, leftBracket), rightBracket)))
+			if err != nil {
+				panic(err) // should not happen, since the path is singular
+			}
+			if index < 0 {
+				return "", fmt.Errorf("negative array index %d has no JSON Pointer equivalent", index)
+			}
+			token = strconv.Itoa(index)
+
+		default:
+			continue
+		}
+		s.WriteString("/" + jsonPointerEscaper.Replace(token))
+	}
+	return s.String(), nil
+}
+
+// NewPathFromJSONPointer constructs a Path equivalent to the given JSON Pointer (RFC 6901), for example
+// `$.spec.containers[0].image` for `/spec/containers/0/image`. The empty JSON Pointer refers to the whole document
+// and is equivalent to `$`. Any options modify the behaviour of the Path.
+//
+// A reference token which is an array index, such as `0`, becomes an array index of the Path and so, unlike in JSON
+// Pointer, does not select the child named `"0"` of a mapping. Other reference tokens become named children.
+func NewPathFromJSONPointer(ptr string, opts ...Option) (*Path, error) {
+	if ptr != "" && !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf(`invalid JSON Pointer %q: must be empty or start with "/"`, ptr)
+	}
+
+	var expr strings.Builder
+	expr.WriteString(root)
+	if ptr != "" {
+		for _, t := range strings.Split(ptr[1:], "/") {
+			if strings.Count(t, "~") != strings.Count(t, "~0")+strings.Count(t, "~1") {
+				return nil, fmt.Errorf(`invalid JSON Pointer %q: "~" must be followed by "0" or "1"`, ptr)
+			}
+			token := jsonPointerUnescaper.Replace(t)
+			if isArrayIndexToken(token) {
+				expr.WriteString(leftBracket + token + rightBracket)
+			} else {
+				expr.WriteString(canonicalChild(token))
+			}
+		}
+	}
+	return NewPath(expr.String(), opts...)
+}
+
+// isArrayIndexToken returns true if and only if the given reference token of a JSON Pointer may refer to an element
+// of an array, that is it is "0" or consists of digits without a leading zero.
+func isArrayIndexToken(token string) bool {
+	if token == "" || len(token) > 1 && token[0] == '0' {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
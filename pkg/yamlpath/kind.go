@@ -0,0 +1,85 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NodeKind is the kind of a YAML node, so that callers need not inspect the yaml.Kind of a node.
+type NodeKind int
+
+const (
+	// UnknownKind is the kind returned when there is no node.
+	UnknownKind NodeKind = iota
+	// DocumentKind is the kind of a document node.
+	DocumentKind
+	// SequenceKind is the kind of a sequence node.
+	SequenceKind
+	// MappingKind is the kind of a mapping node.
+	MappingKind
+	// ScalarKind is the kind of a scalar node, such as a string, number, boolean, or null.
+	ScalarKind
+	// AliasKind is the kind of an alias node.
+	AliasKind
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case UnknownKind:
+		return "unknown"
+	case DocumentKind:
+		return "document"
+	case SequenceKind:
+		return "sequence"
+	case MappingKind:
+		return "mapping"
+	case ScalarKind:
+		return "scalar"
+	case AliasKind:
+		return "alias"
+	default:
+		return fmt.Sprintf("NodeKind(%d)", int(k))
+	}
+}
+
+// withArticle returns the name of the kind preceded by the indefinite article, such as "a mapping" or "an alias".
+func (k NodeKind) withArticle() string {
+	if k == AliasKind || k == UnknownKind {
+		return "an " + k.String()
+	}
+	return "a " + k.String()
+}
+
+func kindOf(node *yaml.Node) NodeKind {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return DocumentKind
+	case yaml.SequenceNode:
+		return SequenceKind
+	case yaml.MappingNode:
+		return MappingKind
+	case yaml.ScalarNode:
+		return ScalarKind
+	case yaml.AliasNode:
+		return AliasKind
+	default:
+		return UnknownKind
+	}
+}
+
+// Type applies the Path to a YAML node and returns the kind of the first matching node. If there are no matches,
+// Type returns UnknownKind and ErrNoMatch.
+func (p *Path) Type(node *yaml.Node) (NodeKind, error) {
+	results, err := p.FindOrError(node)
+	if err != nil {
+		return UnknownKind, err
+	}
+	return kindOf(results[0]), nil
+}
@@ -53,12 +53,27 @@ const (
 	lexemeBracketPropertyName
 	lexemeArraySubscriptPropertyName
 	lexemeRecursiveFilterBegin
+	lexemeFilterBinding
+	lexemeFilterIndex
+	lexemeFilterModulo
+	lexemeFilterParent
+	lexemeFilterEqualityIgnoringCase
+	lexemeTag
+	lexemeFilterFunctionCall
+	lexemeFilterArgumentSeparator
+	lexemeFilterNotMatchesRegularExpression
+	lexemeFilterArrayLiteral
+	lexemeFilterKey
+	lexemeFilterBetween
+	lexemeFilterBetweenAnd
+	lexemeKeyRegularExpression
+	lexemeFirstOrLastElement
 	lexemeEOF // lexing complete
 )
 
 func (t lexemeType) comparator() comparator {
 	switch t {
-	case lexemeFilterEquality:
+	case lexemeFilterEquality, lexemeFilterEqualityIgnoringCase:
 		return equal
 
 	case lexemeFilterInequality:
@@ -83,10 +98,19 @@ func (t lexemeType) comparator() comparator {
 
 func (t lexemeType) isComparisonOrMatch() bool {
 	switch t {
-	case lexemeFilterEquality, lexemeFilterInequality,
+	case lexemeFilterEquality, lexemeFilterInequality, lexemeFilterEqualityIgnoringCase,
 		lexemeFilterGreaterThan, lexemeFilterGreaterThanOrEqual,
 		lexemeFilterLessThan, lexemeFilterLessThanOrEqual,
-		lexemeFilterMatchesRegularExpression:
+		lexemeFilterMatchesRegularExpression, lexemeFilterNotMatchesRegularExpression:
+		return true
+	}
+	return false
+}
+
+// isOrdering returns true if and only if the lexeme type is one of the ordering operators >, >=, <, and <=.
+func (t lexemeType) isOrdering() bool {
+	switch t {
+	case lexemeFilterGreaterThan, lexemeFilterGreaterThanOrEqual, lexemeFilterLessThan, lexemeFilterLessThanOrEqual:
 		return true
 	}
 	return false
@@ -113,9 +137,13 @@ func (l lexeme) literalValue() typedValue {
 		}
 
 	case lexemeFilterStringLiteral:
+		val, err := unescapeStringLiteral(l.val[1 : len(l.val)-1])
+		if err != nil {
+			panic(err) // should not happen, lexer should have detected errors
+		}
 		return typedValue{
 			typ: stringValueType,
-			val: l.val[1 : len(l.val)-1],
+			val: val,
 		}
 
 	case lexemeFilterBooleanLiteral:
@@ -131,10 +159,7 @@ func (l lexeme) literalValue() typedValue {
 		}
 
 	case lexemeFilterRegularExpressionLiteral:
-		return typedValue{
-			typ: regularExpressionValueType,
-			val: sanitiseRegularExpressionLiteral(l.val),
-		}
+		return newRegularExpressionValue(regexp.MustCompile(sanitiseRegularExpressionLiteral(l.val))) // already compiled during lexing
 
 	default:
 		return typedValue{
@@ -144,8 +169,26 @@ func (l lexeme) literalValue() typedValue {
 	}
 }
 
+// sanitiseRegularExpressionLiteral returns the regular expression of the given literal, such as `/a\/b/`, without its
+// delimiters and with each `\/` replaced by `/`, leaving other escape sequences, such as `\\`, unchanged. Any flags
+// following the literal, such as the `i` of `/a/i`, are prefixed to the regular expression, as in `(?i)a`.
 func sanitiseRegularExpressionLiteral(re string) string {
-	return strings.ReplaceAll(re[1:len(re)-1], `\/`, `/`)
+	var s strings.Builder
+	end := strings.LastIndex(re, filterRegularExpressionLiteralDelimiter)
+	if flags := re[end+1:]; flags != "" {
+		s.WriteString("(?" + flags + ")")
+	}
+	re = re[1:end]
+	for i := 0; i < len(re); i++ {
+		if re[i] == '\\' && i+1 < len(re) {
+			i++
+			if re[i] != '/' {
+				s.WriteByte('\\')
+			}
+		}
+		s.WriteByte(re[i])
+	}
+	return s.String()
 }
 
 func (l lexeme) comparator() comparator {
@@ -168,6 +211,10 @@ type lexer struct {
 	items                 chan lexeme // channel of scanned lexemes
 	lastEmittedStart      int         // start position of last scanned lexeme
 	lastEmittedLexemeType lexemeType  // type of last emitted lexeme (or lexemEOF if no lexeme has been emitted)
+	lastEmittedValue      string      // value of last emitted lexeme
+	requireExplicitRoot   bool        // whether a path which does not start with "$" is an error
+	parentheses           []bool      // for each open parenthesis in a filter, whether it begins function arguments
+	betweens              []int       // for each "between" awaiting its "and", the number of open parentheses at it
 }
 
 // lex creates a new scanner for the input string.
@@ -204,6 +251,30 @@ func (l *lexer) emptyStack() bool {
 	return len(l.stack) == 0
 }
 
+// openParenthesis records an open parenthesis, or the start of a filter, which is closed by closeParenthesis.
+func (l *lexer) openParenthesis(functionArguments bool) {
+	l.parentheses = append(l.parentheses, functionArguments)
+}
+
+// closeParenthesis forgets the innermost open parenthesis, if any.
+func (l *lexer) closeParenthesis() {
+	if len(l.parentheses) > 0 {
+		l.parentheses = l.parentheses[:len(l.parentheses)-1]
+	}
+}
+
+// inFunctionArguments returns true if and only if the innermost open parenthesis begins the arguments of a function
+// call, in which case "," separates arguments rather than being part of a child name.
+func (l *lexer) inFunctionArguments() bool {
+	return len(l.parentheses) > 0 && l.parentheses[len(l.parentheses)-1]
+}
+
+// inFilter returns true if and only if the lexer is inside a filter, in which case a path in the filter may be
+// followed by an operator or the end of the filter.
+func (l *lexer) inFilter() bool {
+	return len(l.parentheses) > 0
+}
+
 // nextLexeme returns the next item from the input.
 func (l *lexer) nextLexeme() lexeme {
 	for {
@@ -377,6 +448,7 @@ func (l *lexer) emit(typ lexemeType) {
 		val: l.value(),
 	}
 	l.lastEmittedStart = l.start
+	l.lastEmittedValue = l.value()
 	l.start = l.pos
 	l.lastEmittedLexemeType = typ
 }
@@ -440,17 +512,32 @@ const (
 	filterCloseBracket                      string = ")"
 	filterNot                               string = "!"
 	filterAt                                string = "@"
+	filterParent                            string = "^"
+	filterIndex                             string = "@index"
+	filterKey                               string = "@#"
+	filterBetween                           string = "between"
+	filterBetweenAnd                        string = "and"
+	filterModulo                            string = "%"
+	filterArgumentSeparator                 string = ","
 	filterConjunction                       string = "&&"
 	filterDisjunction                       string = "||"
 	filterEquality                          string = "=="
+	filterEqualityIgnoringCase              string = "==~"
 	filterInequality                        string = "!="
 	filterMatchesRegularExpression          string = "=~"
+	filterNotMatchesRegularExpression       string = "!~"
 	filterStringLiteralDelimiter            string = "'"
 	filterStringLiteralAlternateDelimiter   string = `"`
 	filterRegularExpressionLiteralDelimiter string = "/"
 	filterRegularExpressionEscape           string = `\`
+	filterRegularExpressionFlags            string = "imsU"
 	recursiveDescent                        string = ".."
 	propertyName                            string = "~"
+	keyRegularExpression                    string = ".~"
+	firstElement                            string = ".first()"
+	lastElement                             string = ".last()"
+	tagBegin                                string = "<!"
+	tagEnd                                  string = ">"
 )
 
 var orderingOperators []orderingOperator
@@ -466,6 +553,9 @@ func init() {
 }
 
 func lexPath(l *lexer) stateFn {
+	if l.requireExplicitRoot && !l.hasPrefix(root) {
+		return l.rawErrorf("path %q does not start with %s", l.input, root)
+	}
 	if l.empty() {
 		l.emit(lexemeIdentity)
 		l.emit(lexemeEOF)
@@ -474,6 +564,11 @@ func lexPath(l *lexer) stateFn {
 	if l.hasPrefix(root) {
 		return lexRoot
 	}
+	if l.consumed(filterAt) {
+		// a relative path, which starts at the current node
+		l.emit(lexemeFilterAt)
+		return lexSubPath
+	}
 
 	// emit implicit root
 	l.emitSynthetic(lexemeRoot, root)
@@ -509,7 +604,7 @@ func consumedEscapedString(l *lexer, quote string) bool {
 
 func lexSubPath(l *lexer) stateFn {
 	switch {
-	case l.hasPrefix(")"):
+	case l.hasPrefix(")"), l.hasPrefix(filterArgumentSeparator) && l.inFunctionArguments():
 		return l.pop()
 
 	case l.empty():
@@ -523,24 +618,46 @@ func lexSubPath(l *lexer) stateFn {
 	case l.consumed(recursiveDescent):
 		childName := false
 		for {
+			if l.hasPrefix(tagBegin) {
+				break
+			}
 			le := l.next()
-			if le == '.' || le == '[' || le == eof {
+			if le == '.' || le == '[' || le == eof ||
+				l.inFilter() && (le == ')' || unicode.IsSpace(le) || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' || le == ',' && l.inFunctionArguments()) {
 				l.backup()
 				break
 			}
 			childName = true
 		}
-		if !childName && !l.peeked(leftBracket, bracketQuote, bracketDoubleQuote) {
+		if !childName && !l.peeked(leftBracket, bracketQuote, bracketDoubleQuote) && !l.hasPrefix(tagBegin) {
 			return l.errorf("child name or array access or filter missing after recursive descent")
 		}
 		l.emit(lexemeRecursiveDescent)
-		return lexSubPath
+		return lexOptionalArrayIndex
+
+	case l.consumed(firstElement), l.consumed(lastElement):
+		l.emit(lexemeFirstOrLastElement)
+		return lexOptionalArrayIndex
+
+	case l.hasPrefix(keyRegularExpression + filterRegularExpressionLiteralDelimiter):
+		// the values of a mapping whose keys match a regular expression, such as ".~/^feature_/"
+		l.consume(keyRegularExpression)
+		return lexRegularExpressionLiteral(l, lexemeKeyRegularExpression, lexOptionalArrayIndex)
 
 	case l.consumed(dot):
 		childName := false
 		for {
 			le := l.next()
-			if le == '.' || le == '[' || le == ')' || le == ' ' || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' || le == '~' || le == eof {
+			if le == '\\' {
+				// the escaped character is part of the child name
+				if l.next() == eof {
+					return l.errorf(`missing character after "\" in child name`)
+				}
+				childName = true
+				continue
+			}
+			if le == '.' || le == '[' || le == ')' || unicode.IsSpace(le) || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' || le == '~' || le == eof ||
+				le == ',' && l.inFunctionArguments() {
 				l.backup()
 				break
 			}
@@ -602,17 +719,31 @@ func lexSubPath(l *lexer) stateFn {
 		} else {
 			l.emit(lexemeFilterBegin)
 		}
+		l.openParenthesis(false)
 		l.push(lexFilterEnd)
 		return lexFilterExprInitial
 
 	case l.peeked(leftBracket):
 		return lexOptionalArrayIndex
 
+	case l.consumed(tagBegin):
+		for {
+			le := l.next()
+			if le == eof || unicode.IsSpace(le) {
+				return l.errorf("unmatched %q", "<")
+			}
+			if string(le) == tagEnd {
+				break
+			}
+		}
+		l.emit(lexemeTag)
+		return lexOptionalArrayIndex
+
 	case l.lastEmittedLexemeType == lexemeEOF:
 		childName := false
 		for {
 			le := l.next()
-			if le == '.' || le == '[' || le == ']' || le == ')' || le == ' ' || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' || le == '~' || le == eof {
+			if le == '.' || le == '[' || le == ']' || le == ')' || unicode.IsSpace(le) || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' || le == '~' || le == eof {
 				l.backup()
 				break
 			}
@@ -671,8 +802,12 @@ func lexOptionalArrayIndex(l *lexer) stateFn {
 		l.emit(lexemeArraySubscript)
 	}
 
+	if l.hasPrefix(tagBegin) {
+		return lexSubPath
+	}
+
 	le := l.peek()
-	if le == ' ' || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' {
+	if unicode.IsSpace(le) || le == '&' || le == '|' || le == '=' || le == '!' || le == '>' || le == '<' || le == ',' && l.inFunctionArguments() {
 		if l.emptyStack() {
 			return l.errorf("invalid character %q", l.peek())
 		}
@@ -698,6 +833,10 @@ func enquote(quote string) string {
 func lexFilterExprInitial(l *lexer) stateFn {
 	l.stripWhitespace()
 
+	if nextState, present := lexFunctionCall(l); present {
+		return nextState
+	}
+
 	if nextState, present := lexNumericLiteral(l, lexFilterExpr); present {
 		return nextState
 	}
@@ -717,6 +856,7 @@ func lexFilterExprInitial(l *lexer) stateFn {
 	switch {
 	case l.consumed(filterOpenBracket):
 		l.emit(lexemeFilterOpenBracket)
+		l.openParenthesis(false)
 		l.push(lexFilterExpr)
 		return lexFilterExprInitial
 
@@ -727,16 +867,33 @@ func lexFilterExprInitial(l *lexer) stateFn {
 		l.emit(lexemeFilterNot)
 		return lexFilterExprInitial
 
+	case consumedFilterIndex(l):
+		l.emit(lexemeFilterIndex)
+		return lexFilterExpr
+
+	case l.consumed(filterKey):
+		l.emit(lexemeFilterKey)
+		return lexFilterExpr
+
 	case l.consumed(filterAt):
-		l.emit(lexemeFilterAt)
-		if l.peekedWhitespaced("=") || l.peekedWhitespaced("!") || l.peekedWhitespaced(">") || l.peekedWhitespaced("<") {
+		emitFilterAtOrParent(l)
+		if !l.hasPrefix(tagBegin) && (l.peekedWhitespaced("=") || l.peekedWhitespaced("!") || l.peekedWhitespaced(">") || l.peekedWhitespaced("<") ||
+			l.peekedWhitespaced(filterBetween)) {
 			return lexFilterExpr
 		}
 		l.push(lexFilterExpr)
 		return lexSubPath
 
 	case l.consumed(root):
-		l.emit(lexemeRoot)
+		if consumedBindingName(l) {
+			l.emit(lexemeFilterBinding)
+			if l.peekedWhitespaced("=") || l.peekedWhitespaced("!") || l.peekedWhitespaced(">") || l.peekedWhitespaced("<") ||
+				l.peekedWhitespaced(filterBetween) {
+				return lexFilterExpr
+			}
+		} else {
+			l.emit(lexemeRoot)
+		}
 		l.push(lexFilterExpr)
 		return lexSubPath
 
@@ -766,13 +923,22 @@ func lexFilterExpr(l *lexer) stateFn {
 	case l.empty():
 		return l.errorf("missing end of filter")
 
+	case len(l.betweens) > 0 && l.betweens[len(l.betweens)-1] == len(l.parentheses):
+		return lexBetweenUpperBound(l)
+
 	case l.hasPrefix(filterEnd): // this will be consumed by the popped state function
 		return l.pop()
 
 	case l.consumed(filterCloseBracket):
 		l.emit(lexemeFilterCloseBracket)
+		l.closeParenthesis()
 		return l.pop()
 
+	case l.inFunctionArguments() && l.consumed(filterArgumentSeparator):
+		l.emit(lexemeFilterArgumentSeparator)
+		l.push(lexFilterExpr)
+		return lexFilterTerm
+
 	case l.consumed(filterConjunction):
 		l.emit(lexemeFilterAnd)
 		l.stripWhitespace()
@@ -783,6 +949,16 @@ func lexFilterExpr(l *lexer) stateFn {
 		l.stripWhitespace()
 		return lexFilterExprInitial
 
+	case l.consumed(filterModulo):
+		l.emit(lexemeFilterModulo)
+		l.stripWhitespace()
+		return lexFilterModuloOperand
+
+	case l.consumed(filterEqualityIgnoringCase):
+		l.emit(lexemeFilterEqualityIgnoringCase)
+		l.push(lexFilterExpr)
+		return lexFilterTerm
+
 	case l.consumed(filterEquality):
 		l.emit(lexemeFilterEquality)
 		l.push(lexFilterExpr)
@@ -793,16 +969,32 @@ func lexFilterExpr(l *lexer) stateFn {
 		l.push(lexFilterExpr)
 		return lexFilterTerm
 
-	case l.hasPrefix(filterMatchesRegularExpression):
+	case l.hasPrefix(filterMatchesRegularExpression), l.hasPrefix(filterNotMatchesRegularExpression):
+		operator, typ := filterMatchesRegularExpression, lexemeFilterMatchesRegularExpression
+		if l.hasPrefix(filterNotMatchesRegularExpression) {
+			operator, typ = filterNotMatchesRegularExpression, lexemeFilterNotMatchesRegularExpression
+		}
 		switch l.lastEmittedLexemeType {
 		case lexemeFilterStringLiteral, lexemeFilterIntegerLiteral, lexemeFilterFloatLiteral:
-			return l.errorf("literal cannot be matched using %s", filterMatchesRegularExpression)
+			return l.errorf("literal cannot be matched using %s", operator)
 		}
-		l.consume(filterMatchesRegularExpression)
-		l.emit(lexemeFilterMatchesRegularExpression)
+		l.consume(operator)
+		l.emit(typ)
 
 		l.stripWhitespace()
-		return lexRegularExpressionLiteral(l, lexFilterExpr)
+		if l.consumed(root) {
+			// a regular expression bound to a name, such as $pattern
+			if !consumedBindingName(l) {
+				return l.errorf("missing binding name after %s", root)
+			}
+			l.emit(lexemeFilterBinding)
+			l.push(lexFilterExpr)
+			return lexSubPath
+		}
+		return lexRegularExpressionLiteral(l, lexemeFilterRegularExpressionLiteral, lexFilterExpr)
+
+	case peekedKeyword(l, filterBetween):
+		return lexBetween(l)
 	}
 
 	for _, o := range orderingOperators {
@@ -817,10 +1009,25 @@ func lexFilterExpr(l *lexer) stateFn {
 func lexFilterTerm(l *lexer) stateFn {
 	l.stripWhitespace()
 
+	if consumedFilterIndex(l) {
+		l.emit(lexemeFilterIndex)
+		return lexFilterExpr
+	}
+
+	if l.consumed(filterKey) {
+		l.emit(lexemeFilterKey)
+		return lexFilterExpr
+	}
+
+	if nextState, present := lexFunctionCall(l); present {
+		return nextState
+	}
+
 	if l.consumed(filterAt) {
-		l.emit(lexemeFilterAt)
+		emitFilterAtOrParent(l)
 
-		if l.peekedWhitespaced("|") || l.peekedWhitespaced("&") || l.peekedWhitespaced(")") {
+		if l.peekedWhitespaced("|") || l.peekedWhitespaced("&") || l.peekedWhitespaced(")") ||
+			l.inFunctionArguments() && l.peekedWhitespaced(filterArgumentSeparator) {
 			if l.emptyStack() {
 				return l.errorf("invalid character %q", l.peek())
 			}
@@ -830,7 +1037,15 @@ func lexFilterTerm(l *lexer) stateFn {
 	}
 
 	if l.consumed(root) {
-		l.emit(lexemeRoot)
+		if consumedBindingName(l) {
+			l.emit(lexemeFilterBinding)
+		} else {
+			l.emit(lexemeRoot)
+		}
+		if unicode.IsSpace(l.peek()) && !l.emptyStack() {
+			// the term is complete, for example "$min" in "@.port between $min and $max"
+			return l.pop()
+		}
 		return lexSubPath
 	}
 
@@ -850,9 +1065,83 @@ func lexFilterTerm(l *lexer) stateFn {
 		return nextState
 	}
 
+	if nextState, present := lexArrayLiteral(l, lexFilterExpr); present {
+		return nextState
+	}
+
 	return l.errorf("invalid filter term")
 }
 
+// consumedFilterIndex consumes "@index" and returns true if and only if "@index" is next and is not followed by a
+// letter, digit, or "_".
+func consumedFilterIndex(l *lexer) bool {
+	if !l.hasPrefix(filterIndex) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(l.input[l.pos+len(filterIndex):]); unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+		return false
+	}
+	l.consume(filterIndex)
+	return true
+}
+
+// peekedKeyword returns true if and only if the given keyword, such as "between", is next and is not followed by a
+// letter, digit, or "_". The lexing position is not modified.
+func peekedKeyword(l *lexer, keyword string) bool {
+	if !l.hasPrefix(keyword) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.pos+len(keyword):])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+}
+
+// emitFilterAtOrParent emits the "@" which has just been consumed or, if "@" is followed by one or more "^", consumes
+// them and emits a parent term, such as "@^".
+func emitFilterAtOrParent(l *lexer) {
+	if !l.hasPrefix(filterParent) {
+		l.emit(lexemeFilterAt)
+		return
+	}
+	for l.consumed(filterParent) {
+	}
+	l.emit(lexemeFilterParent)
+}
+
+// consumedBindingName consumes the name of a binding, such as "params" following "$" in "$params", and returns true
+// if and only if such a name was consumed. A binding name consists of a letter or "_" followed by any number of
+// letters, digits, and "_".
+func consumedBindingName(l *lexer) bool {
+	if r := l.peek(); !unicode.IsLetter(r) && r != '_' {
+		return false
+	}
+	for {
+		r := l.next()
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			l.backup()
+			return true
+		}
+	}
+}
+
+// lexFunctionCall lexes the name and open parenthesis of a function call, such as "lower(", if they are next, and
+// returns the state function for lexing the arguments.
+func lexFunctionCall(l *lexer) (stateFn, bool) {
+	start := l.pos
+	if !consumedBindingName(l) || !l.consumed(filterOpenBracket) {
+		l.pos = start
+		return nil, false
+	}
+	l.emit(lexemeFilterFunctionCall)
+	l.openParenthesis(true)
+	l.push(lexFilterExpr)
+	l.stripWhitespace()
+	if l.hasPrefix(filterCloseBracket) {
+		return lexFilterExpr, true // no arguments
+	}
+	l.push(lexFilterExpr)
+	return lexFilterTerm, true
+}
+
 func lexFilterEnd(l *lexer) stateFn {
 	if l.hasPrefix(filterEnd) {
 		if l.lastEmittedLexemeType == lexemeFilterBegin {
@@ -860,6 +1149,7 @@ func lexFilterEnd(l *lexer) stateFn {
 		}
 		l.consume(filterEnd)
 		l.emit(lexemeFilterEnd)
+		l.closeParenthesis()
 		return lexSubPath
 	}
 
@@ -876,35 +1166,58 @@ func validateArrayIndex(l *lexer) bool {
 	return true
 }
 
+// lexFilterModuloOperand lexes the integer literal following the modulo operator.
+func lexFilterModuloOperand(l *lexer) stateFn {
+	nextState, present := lexNumericLiteral(l, lexFilterExpr)
+	if !present {
+		return l.errorf("missing integer literal after %s", filterModulo)
+	}
+	if l.lastEmittedLexemeType == lexemeFilterFloatLiteral {
+		return func(l *lexer) stateFn {
+			return l.errorf("%s must be followed by an integer literal", filterModulo)
+		}
+	}
+	return nextState
+}
+
 func lexNumericLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 	n := l.peek()
 	if n == '.' || n == '-' || (n >= '0' && n <= '9') {
 		float := n == '.'
 		for {
-			l.next()
+			prev := l.next()
 			n := l.peek()
 			if n == '.' || n == 'e' || n == 'E' || n == '-' {
 				float = true
 				continue
 			}
+			if n == '+' && (prev == 'e' || prev == 'E') {
+				// sign of exponent
+				continue
+			}
 			if !(n >= '0' && n <= '9') {
 				break
 			}
 		}
+		// include any letters immediately following the literal, such as the x of 0x, in the invalid literal
+		malformed := false
+		for r := l.peek(); unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'; r = l.peek() {
+			l.next()
+			malformed = true
+		}
+		if malformed {
+			return invalidNumberLiteral(l), true
+		}
 
 		if float {
-			// validate float
 			if _, err := strconv.ParseFloat(l.value(), 64); err != nil {
-				err := err.(*strconv.NumError)
-				return l.rawErrorf("invalid float literal %q: %s before position %d", err.Num, err, l.pos), true
+				return invalidNumberLiteral(l), true
 			}
 			l.emit(lexemeFilterFloatLiteral)
 			return lexFilterExpr, true
 		}
-		// validate integer
 		if _, err := strconv.Atoi(l.value()); err != nil {
-			err := err.(*strconv.NumError)
-			return l.rawErrorf("invalid integer literal %q: %s before position %d", err.Num, err, l.pos), true
+			return invalidNumberLiteral(l), true
 		}
 		l.emit(lexemeFilterIntegerLiteral)
 		return lexFilterExpr, true
@@ -912,6 +1225,12 @@ func lexNumericLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 	return nil, false
 }
 
+// invalidNumberLiteral returns an error lexeme for the malformed or out of range numeric literal which has just been
+// scanned, such as `1.2.3`, `1e`, `--5`, or `0x`, and terminates the scan.
+func invalidNumberLiteral(l *lexer) stateFn {
+	return l.rawErrorf("invalid number literal %q at position %d", l.value(), l.start)
+}
+
 func lexStringLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 	var quote string
 	if l.hasPrefix(filterStringLiteralDelimiter) {
@@ -923,7 +1242,11 @@ func lexStringLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 		pos := l.pos
 		context := l.context()
 		for {
-			if l.next() == eof {
+			r := l.next()
+			if r == eof {
+				return l.rawErrorf(`unmatched string delimiter %s at position %d, following %q`, quote, pos, context), true
+			}
+			if r == '\\' && l.next() == eof {
 				return l.rawErrorf(`unmatched string delimiter %s at position %d, following %q`, quote, pos, context), true
 			}
 			if l.hasPrefix(quote) {
@@ -931,6 +1254,9 @@ func lexStringLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 			}
 		}
 		l.next()
+		if _, err := unescapeStringLiteral(l.value()[1 : len(l.value())-1]); err != nil {
+			return l.rawErrorf("invalid string literal %s: %s before position %d", l.value(), err, l.pos), true
+		}
 		l.emit(lexemeFilterStringLiteral)
 
 		return nextState, true
@@ -938,6 +1264,55 @@ func lexStringLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 	return nil, false
 }
 
+// unescapeStringLiteral returns the value of the body of a filter string literal, that is the literal without its
+// delimiters, after decoding the escape sequences \n, \t, \r, \\, \', \", \xXX, and \uXXXX. The escape sequences
+// \xXX and \uXXXX, where each X is a hexadecimal digit, denote the Unicode code point with the given value.
+func unescapeStringLiteral(body string) (string, error) {
+	if !strings.Contains(body, `\`) {
+		return body, nil
+	}
+	var s strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			s.WriteByte(body[i])
+			continue
+		}
+		if i+1 == len(body) {
+			return "", fmt.Errorf(`missing character after "\"`)
+		}
+		i++
+		switch c := body[i]; c {
+		case 'n':
+			s.WriteByte('\n')
+		case 't':
+			s.WriteByte('\t')
+		case 'r':
+			s.WriteByte('\r')
+		case '\\', '\'', '"':
+			s.WriteByte(c)
+		case 'x', 'u':
+			digits := 2
+			if c == 'u' {
+				digits = 4
+			}
+			if i+1+digits > len(body) {
+				return "", fmt.Errorf("invalid escape sequence %s", `\`+body[i:])
+			}
+			seq := body[i : i+1+digits]
+			r, err := strconv.ParseUint(seq[1:], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence %s", `\`+seq)
+			}
+			s.WriteRune(rune(r))
+			i += digits
+		default:
+			_, width := utf8.DecodeRuneInString(body[i:])
+			return "", fmt.Errorf("unsupported escape sequence %s", `\`+body[i:i+width])
+		}
+	}
+	return s.String(), nil
+}
+
 func lexBooleanLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 	if l.consumedWhitespaced("true") || l.consumedWhitespaced("false") {
 		l.emit(lexemeFilterBooleanLiteral)
@@ -954,6 +1329,124 @@ func lexNullLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
 	return nil, false
 }
 
+// lexArrayLiteral lexes an array literal, such as `['a', 'b']`, which may only follow `==` or `!=`.
+func lexArrayLiteral(l *lexer, nextState stateFn) (stateFn, bool) {
+	if !l.hasPrefix(leftBracket) {
+		return nil, false
+	}
+	if l.lastEmittedLexemeType != lexemeFilterEquality && l.lastEmittedLexemeType != lexemeFilterInequality {
+		return l.errorf("array literal can only be compared using %s or %s", filterEquality, filterInequality), true
+	}
+	pos := l.pos
+	context := l.context()
+	quote := eof
+	for {
+		r := l.next()
+		switch {
+		case r == eof:
+			return l.rawErrorf("missing end of array literal at position %d, following %q", pos, context), true
+
+		case quote != eof:
+			if r == '\\' {
+				l.next()
+			} else if r == quote {
+				quote = eof
+			}
+			continue
+
+		case r == '\'' || r == '"':
+			quote = r
+			continue
+		}
+		if r == ']' {
+			break
+		}
+	}
+	if _, err := arrayLiteralElements(l.value()); err != nil {
+		return l.rawErrorf("invalid array literal %s: %s before position %d", l.value(), err, l.pos), true
+	}
+	l.emit(lexemeFilterArrayLiteral)
+	return nextState, true
+}
+
+// arrayLiteralElements returns the values of the elements of the given array literal, each of which is a string,
+// integer, floating point, boolean, or null literal.
+func arrayLiteralElements(literal string) ([]typedValue, error) {
+	body := strings.TrimSpace(literal[1 : len(literal)-1])
+	elements := []typedValue{}
+	for body != "" {
+		var element string
+		if body[0] == '\'' || body[0] == '"' {
+			end := 1
+			for end < len(body) && body[end] != body[0] {
+				if body[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(body) {
+				return nil, fmt.Errorf("unmatched string delimiter %c", body[0])
+			}
+			element = body[:end+1]
+		} else {
+			element = body
+			if comma := strings.Index(body, filterArgumentSeparator); comma >= 0 {
+				element = body[:comma]
+			}
+			element = strings.TrimSpace(element)
+		}
+
+		v, err := arrayLiteralElement(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, v)
+
+		rest := strings.TrimSpace(body[len(element):])
+		if rest == "" {
+			break
+		}
+		if !strings.HasPrefix(rest, filterArgumentSeparator) {
+			return nil, fmt.Errorf("missing %s after element %s", filterArgumentSeparator, element)
+		}
+		body = strings.TrimSpace(strings.TrimPrefix(rest, filterArgumentSeparator))
+		if body == "" {
+			return nil, fmt.Errorf("missing element after %s", filterArgumentSeparator)
+		}
+	}
+	return elements, nil
+}
+
+// arrayLiteralElement returns the value of the given element of an array literal.
+func arrayLiteralElement(element string) (typedValue, error) {
+	switch {
+	case element == "":
+		return typedValue{}, fmt.Errorf("missing element")
+
+	case element[0] == '\'' || element[0] == '"':
+		val, err := unescapeStringLiteral(element[1 : len(element)-1])
+		if err != nil {
+			return typedValue{}, fmt.Errorf("invalid string literal %s: %s", element, err)
+		}
+		return typedValue{typ: stringValueType, val: val}, nil
+
+	case element == "true" || element == "false":
+		return typedValue{typ: booleanValueType, val: element}, nil
+
+	case element == "null":
+		return typedValue{typ: nullValueType, val: element}, nil
+
+	case element[0] == '.' || element[0] == '-' || element[0] >= '0' && element[0] <= '9':
+		if _, err := strconv.Atoi(element); err == nil {
+			return typedValue{typ: intValueType, val: element}, nil
+		}
+		if _, err := strconv.ParseFloat(element, 64); err == nil {
+			return typedValue{typ: floatValueType, val: element}, nil
+		}
+	}
+	return typedValue{}, fmt.Errorf("invalid element %s", element)
+}
+
 var comparisonOperatorLexeme map[orderingOperator]lexemeType
 
 func init() {
@@ -966,14 +1459,14 @@ func init() {
 }
 
 func lexComparison(l *lexer, comparisonOperator orderingOperator) stateFn {
-	if l.lastEmittedLexemeType == lexemeFilterStringLiteral {
+	if l.lastEmittedLexemeType == lexemeFilterStringLiteral && !isOrderableLiteral(l.lastEmittedValue) {
 		return l.errorf("strings cannot be compared using %s", comparisonOperator)
 	}
 	l.consume(comparisonOperator.String())
 	l.emit(comparisonOperatorLexeme[comparisonOperator])
 
 	l.stripWhitespace()
-	if l.hasPrefix(filterStringLiteralDelimiter) {
+	if l.hasPrefix(filterStringLiteralDelimiter) && !isOrderableLiteral(peekedStringLiteral(l)) {
 		return l.errorf("strings cannot be compared using %s", comparisonOperator)
 	}
 
@@ -981,31 +1474,163 @@ func lexComparison(l *lexer, comparisonOperator orderingOperator) stateFn {
 	return lexFilterTerm
 }
 
-func lexRegularExpressionLiteral(l *lexer, nextState stateFn) stateFn {
+// lexBetween lexes "between" and the lower bound of a range test, such as "@.port between 1024 and 65535". The "and"
+// and the upper bound are lexed by lexBetweenUpperBound once the lower bound has been lexed.
+func lexBetween(l *lexer) stateFn {
+	if isNonNumericLiteral(l.lastEmittedLexemeType) {
+		return l.errorf("literal cannot be tested using %s", filterBetween)
+	}
+	l.consume(filterBetween)
+	l.emit(lexemeFilterBetween)
+
+	l.stripWhitespace()
+	if peekedNonNumericLiteral(l) {
+		return l.errorf("lower bound of %s must be numeric", filterBetween)
+	}
+	l.betweens = append(l.betweens, len(l.parentheses))
+	l.push(lexFilterExpr)
+	return lexFilterTerm
+}
+
+// lexBetweenUpperBound lexes the "and" and the upper bound of a range test whose lower bound has just been lexed.
+func lexBetweenUpperBound(l *lexer) stateFn {
+	if !peekedKeyword(l, filterBetweenAnd) {
+		return l.errorf("missing %s after lower bound of %s", filterBetweenAnd, filterBetween)
+	}
+	l.betweens = l.betweens[:len(l.betweens)-1]
+	l.consume(filterBetweenAnd)
+	l.emit(lexemeFilterBetweenAnd)
+
+	l.stripWhitespace()
+	if peekedNonNumericLiteral(l) {
+		return l.errorf("upper bound of %s must be numeric", filterBetween)
+	}
+	l.push(lexFilterExpr)
+	return lexFilterTerm
+}
+
+// isNonNumericLiteral returns true if and only if the given lexeme type is that of a literal which is not a number.
+func isNonNumericLiteral(typ lexemeType) bool {
+	switch typ {
+	case lexemeFilterStringLiteral, lexemeFilterBooleanLiteral, lexemeFilterNullLiteral, lexemeFilterArrayLiteral:
+		return true
+	}
+	return false
+}
+
+// peekedNonNumericLiteral returns true if and only if a string, boolean, null, or array literal is next. The lexing
+// position is not modified.
+func peekedNonNumericLiteral(l *lexer) bool {
+	return l.hasPrefix(filterStringLiteralDelimiter) || l.hasPrefix(filterStringLiteralAlternateDelimiter) ||
+		l.hasPrefix(leftBracket) || peekedKeyword(l, "true") || peekedKeyword(l, "false") || peekedKeyword(l, "null")
+}
+
+// peekedStringLiteral returns the string literal, including its delimiters, which is next in the input or, if there
+// is no complete string literal next, "". The lexing position is not modified.
+func peekedStringLiteral(l *lexer) string {
+	s := l.input[l.pos:]
+	if s == "" {
+		return ""
+	}
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case quote:
+			return s[:i+1]
+		}
+	}
+	return ""
+}
+
+// isOrderableLiteral returns true if and only if the given string literal, including its delimiters, is a valid
+// string literal whose value is a timestamp or a number, which may be compared using an ordering operator.
+func isOrderableLiteral(literal string) bool {
+	return isTimestampLiteral(literal) || isNumericLiteral(literal)
+}
+
+// isNumericLiteral returns true if and only if the given string literal, including its delimiters, is a valid
+// string literal whose value parses as a number.
+func isNumericLiteral(literal string) bool {
+	if len(literal) < 2 {
+		return false
+	}
+	s, err := unescapeStringLiteral(literal[1 : len(literal)-1])
+	if err != nil {
+		return false
+	}
+	_, ok := numericValueOf(typedValueOfString(s))
+	return ok
+}
+
+// isTimestampLiteral returns true if and only if the given string literal, including its delimiters, is a valid
+// string literal whose value is a timestamp, which may be compared using an ordering operator. See
+// WithTimeComparison.
+func isTimestampLiteral(literal string) bool {
+	if len(literal) < 2 {
+		return false
+	}
+	s, err := unescapeStringLiteral(literal[1 : len(literal)-1])
+	if err != nil {
+		return false
+	}
+	_, ok := parseTimestamp(s)
+	return ok
+}
+
+// lexRegularExpressionLiteral lexes a regular expression literal, such as `/^a.*b$/` or, with flags, `/^a.*b$/i`, and
+// emits it as a lexeme of the given type.
+func lexRegularExpressionLiteral(l *lexer, typ lexemeType, nextState stateFn) stateFn {
 	if !l.hasPrefix(filterRegularExpressionLiteralDelimiter) {
 		return l.errorf("regular expression does not start with %s", filterRegularExpressionLiteralDelimiter)
 	}
 	pos := l.pos
 	context := l.context()
+	l.next()
 	escape := false
+	class := false // whether the next rune is in a character class, such as [/a-z], where / does not end the literal
+	first := false // whether the next rune is the first in a character class, where ] does not end the class
 	for {
-		if l.next() == eof {
+		r := l.next()
+		switch {
+		case r == eof:
 			return l.rawErrorf(`unmatched regular expression delimiter %s at position %d, following %q`, filterRegularExpressionLiteralDelimiter, pos, context)
-		}
-		if !escape && l.hasPrefix(filterRegularExpressionLiteralDelimiter) {
-			break
-		}
-		if !escape && l.hasPrefix(filterRegularExpressionEscape) {
-			escape = true
-		} else {
+
+		case escape:
 			escape = false
+
+		case string(r) == filterRegularExpressionEscape:
+			escape = true
+
+		case !class && r == '[':
+			class = true
+			if l.peek() == '^' {
+				l.next()
+			}
+			first = true
+			continue
+
+		case class && r == '[' && l.hasPrefix(":"):
+			// a named class, such as [:alpha:], within a character class
+			if i := strings.Index(l.input[l.pos:], ":]"); i >= 0 {
+				l.pos += i + len(":]")
+			}
+
+		case class && r == ']' && !first:
+			class = false
+
+		case !class && string(r) == filterRegularExpressionLiteralDelimiter:
+			// consume any flags, stopping at the first rune which is not a flag, such as the ")" of "/a/)]"
+			for strings.ContainsRune(filterRegularExpressionFlags, l.peek()) {
+				l.next()
+			}
+			if _, err := regexp.Compile(sanitiseRegularExpressionLiteral(l.input[pos:l.pos])); err != nil {
+				return l.rawErrorf(`invalid regular expression at position %d, following %q: %s`, pos, context, err)
+			}
+			l.emit(typ)
+			return nextState
 		}
+		first = false
 	}
-	l.next()
-	if _, err := regexp.Compile(sanitiseRegularExpressionLiteral(l.value())); err != nil {
-		return l.rawErrorf(`invalid regular expression at position %d, following %q: %s`, pos, context, err)
-	}
-	l.emit(lexemeFilterRegularExpressionLiteral)
-
-	return nextState
 }
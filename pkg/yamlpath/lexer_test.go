@@ -26,6 +26,16 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
+		{
+			name: "relative path",
+			path: "@.child[0]",
+			expected: []lexeme{
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
 		{
 			name: "root",
 			path: "$",
@@ -176,6 +186,91 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeError, val: `invalid character ' ' at position 7, following ".child"`},
 			},
 		},
+		{
+			name: "dot child with embedded tab",
+			path: "$.child\tmore",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeError, val: `invalid character '\t' at position 7, following ".child"`},
+			},
+		},
+		{
+			name: "dot child with accented latin name",
+			path: "$.café.naïve",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".café"},
+				{typ: lexemeDotChild, val: ".naïve"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "dot child with cyrillic name",
+			path: "$.ключ[0]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".ключ"},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "dot child with CJK name",
+			path: "$.設定.名前_1",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".設定"},
+				{typ: lexemeDotChild, val: ".名前_1"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "dot child with non-ASCII name followed by ideographic space",
+			path: "$.設定　more",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".設定"},
+				{typ: lexemeError, val: `invalid character '\u3000' at position 8, following ".設定"`},
+			},
+		},
+		{
+			name: "dot child with escaped dot",
+			path: `$.child\.name.other`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: `.child\.name`},
+				{typ: lexemeDotChild, val: ".other"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "dot child with escaped left bracket",
+			path: `$.child\[0]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: `.child\[0]`},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "dot child with escaped backslash",
+			path: `$.child\\[0]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: `.child\\`},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "dot child with trailing backslash",
+			path: `$.child\`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: `missing character after "\" in child name at position 8, following "$.child\\"`},
+			},
+		},
 		{
 			name: "bracket child",
 			path: "$['child']",
@@ -267,6 +362,38 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
+		{
+			name: "bracket child named at sign",
+			path: "$['@']",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeBracketChild, val: "['@']"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "bracket child named dollar sign",
+			path: `$["$"]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeBracketChild, val: `["$"]`},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "bracket child named at sign in filter",
+			path: "$[?(@['@']==1)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeBracketChild, val: "['@']"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
 		{
 			name: "bracket child with array subscript",
 			path: "$['child'][*]",
@@ -1110,7 +1237,7 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeFilterAt, val: "@"},
 				{typ: lexemeDotChild, val: ".child"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeError, val: `invalid integer literal "-": strconv.Atoi: parsing "-": invalid syntax before position 14`},
+				{typ: lexemeError, val: `invalid number literal "-" at position 13`},
 			},
 		},
 		{
@@ -1122,7 +1249,7 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeFilterAt, val: "@"},
 				{typ: lexemeDotChild, val: ".child"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeError, val: `invalid integer literal "9223372036854775808": strconv.Atoi: parsing "9223372036854775808": value out of range before position 32`},
+				{typ: lexemeError, val: `invalid number literal "9223372036854775808" at position 13`},
 			},
 		},
 		{
@@ -1134,7 +1261,88 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeFilterAt, val: "@"},
 				{typ: lexemeDotChild, val: ".child"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeError, val: `invalid float literal "1.2.3": strconv.ParseFloat: parsing "1.2.3": invalid syntax before position 18`},
+				{typ: lexemeError, val: `invalid number literal "1.2.3" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal, double minus sign",
+			path: "$[?(@.child==--5)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid number literal "--5" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal, hexadecimal prefix without digits",
+			path: "$[?(@.child==0x)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid number literal "0x" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal, hexadecimal literal",
+			path: "$[?(@.child==0x1F)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid number literal "0x1F" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal, letter following digits",
+			path: "$[?(@.child==1x)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid number literal "1x" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal, letter following exponent",
+			path: "$[?(@.child==1e5x)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid number literal "1e5x" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal, underscore separator",
+			path: "$[?(@.child==1_000)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid number literal "1_000" at position 13`},
+			},
+		},
+		{
+			name: "filter integer equality with invalid literal on the left",
+			path: "$[?(1.2.3==@.child)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeError, val: `invalid number literal "1.2.3" at position 4`},
 			},
 		},
 		{
@@ -1205,6 +1413,111 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
+		{
+			name: "filter scientific notation float, exponent",
+			path: "$[?(@.child>1e6)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterFloatLiteral, val: "1e6"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter scientific notation float, fraction and positive exponent",
+			path: "$[?(@.child>1.5e+3)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterFloatLiteral, val: "1.5e+3"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter scientific notation float, upper case exponent",
+			path: "$[?(@.child>2E-2)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterFloatLiteral, val: "2E-2"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter scientific notation float, negative with upper case positive exponent",
+			path: "$[?(@.child>-2.5E+10)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterFloatLiteral, val: "-2.5E+10"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter scientific notation float, missing exponent",
+			path: "$[?(@.child>1e)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeError, val: `invalid number literal "1e" at position 12`},
+			},
+		},
+		{
+			name: "filter scientific notation float, missing exponent after sign",
+			path: "$[?(@.child>1.5E+)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeError, val: `invalid number literal "1.5E+" at position 12`},
+			},
+		},
+		{
+			name: "filter scientific notation float, missing exponent after negative sign",
+			path: "$[?(@.child>2e-)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeError, val: `invalid number literal "2e-" at position 12`},
+			},
+		},
+		{
+			name: "filter scientific notation float, plus sign not following exponent",
+			path: "$[?(@.child>1+2)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeError, val: `invalid filter expression at position 13, following "1"`},
+			},
+		},
 		{
 			name: "filter boolean true equality, literal on the right",
 			path: "$[?(@.child== true )]",
@@ -1351,26 +1664,32 @@ func TestLexer(t *testing.T) {
 			},
 		},
 		{
-			name: "filter string equality, literal on the right",
-			path: "$[?(@.child=='x')]",
+			name: "filter equality, nested root paths on both sides",
+			path: "$[?($.x[0].y==$['z'][-1].w)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".x"},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeDotChild, val: ".y"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeFilterStringLiteral, val: "'x'"},
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeBracketChild, val: "['z']"},
+				{typ: lexemeArraySubscript, val: "[-1]"},
+				{typ: lexemeDotChild, val: ".w"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter string equality, literal on the left",
-			path: "$[?('x'==@.child)]",
+			name: "filter equality, root path ending in recursive descent on the left",
+			path: "$[?($..x==@.child)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterStringLiteral, val: "'x'"},
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeRecursiveDescent, val: "..x"},
 				{typ: lexemeFilterEquality, val: "=="},
 				{typ: lexemeFilterAt, val: "@"},
 				{typ: lexemeDotChild, val: ".child"},
@@ -1379,28 +1698,68 @@ func TestLexer(t *testing.T) {
 			},
 		},
 		{
-			name: "filter string equality, literal on the left with unmatched string delimiter",
-			path: "$[?('x==@.child)]",
+			name: "filter existence, recursive descent at end of filter",
+			path: "$[?(@..x)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeError, val: `unmatched string delimiter ' at position 4, following "[?("`},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeRecursiveDescent, val: "..x"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter string equality with unmatched string delimiter",
-			path: "$[?(@.child=='x)]",
+			name: "filter string equality, literal on the right",
+			path: "$[?(@.child=='x')]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
 				{typ: lexemeDotChild, val: ".child"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeError, val: `unmatched string delimiter ' at position 13, following "=="`},
+				{typ: lexemeFilterStringLiteral, val: "'x'"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter integer inequality, literal on the right",
+			name: "filter string equality, literal on the left",
+			path: "$[?('x'==@.child)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterStringLiteral, val: "'x'"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter string equality, literal on the left with unmatched string delimiter",
+			path: "$[?('x==@.child)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeError, val: `unmatched string delimiter ' at position 4, following "[?("`},
+			},
+		},
+		{
+			name: "filter string equality with unmatched string delimiter",
+			path: "$[?(@.child=='x)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `unmatched string delimiter ' at position 13, following "=="`},
+			},
+		},
+		{
+			name: "filter integer inequality, literal on the right",
 			path: "$[?(@.child!=1)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
@@ -1510,6 +1869,62 @@ func TestLexer(t *testing.T) {
 				{typ: lexemeError, val: `strings cannot be compared using > at position 12, following ">"`},
 			},
 		},
+		{
+			name: "filter greater than timestamp string",
+			path: "$[?(@.created>'2023-01-01T00:00:00Z')]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".created"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterStringLiteral, val: "'2023-01-01T00:00:00Z'"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter greater than numeric string",
+			path: "$[?(@.version>'1.5')]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".version"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterStringLiteral, val: "'1.5'"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter less than, numeric string on the left",
+			path: `$[?("10"<@.count)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterStringLiteral, val: `"10"`},
+				{typ: lexemeFilterLessThan, val: "<"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".count"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter greater than, timestamp string on the left",
+			path: "$[?('2023-01-01'>@.created)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterStringLiteral, val: "'2023-01-01'"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".created"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
 		{
 			name: "filter greater than, string on the left",
 			path: "$[?('x'>@.child)]",
@@ -1887,244 +2302,1190 @@ func TestLexer(t *testing.T) {
 			},
 		},
 		{
-			name: "filter negation",
-			path: "$[?(!@.child)]",
+			name: "filter comparing with binding",
+			path: "$[?(@.env==$params.region)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterNot, val: "!"},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeDotChild, val: ".env"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterBinding, val: "$params"},
+				{typ: lexemeDotChild, val: ".region"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter negation of comparison (edge case)",
-			path: "$[?(!@.child>1)]",
+			name: "filter comparing bare binding",
+			path: "$[?($max_2 > @.n)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterNot, val: "!"},
-				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterBinding, val: "$max_2"},
 				{typ: lexemeFilterGreaterThan, val: ">"},
-				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".n"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter negation of bracket",
-			path: "$[?(!(@.child))]",
+			name: "filter binding existence",
+			path: "$[?($p['a'][0])]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterNot, val: "!"},
-				{typ: lexemeFilterOpenBracket, val: "("},
+				{typ: lexemeFilterBinding, val: "$p"},
+				{typ: lexemeBracketChild, val: "['a']"},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter comparing with parent",
+			path: "$.items[?(@^^.total==@.value)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".items"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterParent, val: "@^^"},
+				{typ: lexemeDotChild, val: ".total"},
+				{typ: lexemeFilterEquality, val: "=="},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeDotChild, val: ".value"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression",
-			path: "$[?(@.child=~/.*/)]",
+			name: "filter comparing bare parent",
+			path: "$[?(@.x==@^)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
-				{typ: lexemeFilterRegularExpressionLiteral, val: "/.*/"},
+				{typ: lexemeDotChild, val: ".x"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterParent, val: "@^"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression with escaped /",
-			path: `$[?(@.child=~/\/.*/)]`,
+			name: "filter case-insensitive equality",
+			path: "$[?(@.status ==~ 'active')]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
-				{typ: lexemeFilterRegularExpressionLiteral, val: `/\/.*/`},
+				{typ: lexemeDotChild, val: ".status"},
+				{typ: lexemeFilterEqualityIgnoringCase, val: "==~"},
+				{typ: lexemeFilterStringLiteral, val: "'active'"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: `filter regular expression with escaped \`,
-			path: `$[?(@.child=~/\\/)]`,
+			name: "filter case-insensitive equality of bare current node",
+			path: `$[?(@==~"x")]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
-				{typ: lexemeFilterRegularExpressionLiteral, val: `/\\/`},
+				{typ: lexemeFilterEqualityIgnoringCase, val: "==~"},
+				{typ: lexemeFilterStringLiteral, val: `"x"`},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression with missing leading /",
-			path: `$[?(@.child=~.*/)]`,
+			name: "filter string literal with escaped quote",
+			path: `$[?(@.name=='it\'s' || @.name=="say \"hi\"")]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
-				{typ: lexemeError, val: `regular expression does not start with / at position 13, following "=~"`},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: `'it\'s'`},
+				{typ: lexemeFilterOr, val: "||"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: `"say \"hi\""`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression with missing trailing /",
-			path: `$[?(@.child=~/.*)]`,
+			name: "filter string literal with escape sequences",
+			path: `$[?(@.name=='\u00e9cole\t\x41\\\n')]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
-				{typ: lexemeError, val: `unmatched regular expression delimiter / at position 13, following "=~"`},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: `'\u00e9cole\t\x41\\\n'`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression to match string literal",
-			path: `$[?('x'=~/.*/)]`,
+			name: "filter empty string literal",
+			path: `$[?(@.name=='' || ""!=@.name)]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterStringLiteral, val: "'x'"},
-				{typ: lexemeError, val: `literal cannot be matched using =~ at position 7, following "'x'"`},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: "''"},
+				{typ: lexemeFilterOr, val: "||"},
+				{typ: lexemeFilterStringLiteral, val: `""`},
+				{typ: lexemeFilterInequality, val: "!="},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression to match integer literal",
-			path: `$[?(0=~/.*/)]`,
+			name: "filter empty string literal in bracket child filter",
+			path: `$['']['a'][?(@['']=='')]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeBracketChild, val: "['']"},
+				{typ: lexemeBracketChild, val: "['a']"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterIntegerLiteral, val: "0"},
-				{typ: lexemeError, val: `literal cannot be matched using =~ at position 5, following "0"`},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeBracketChild, val: "['']"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: "''"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter regular expression to match float literal",
-			path: `$[?(.1=~/.*/)]`,
+			name: "filter string literal with invalid unicode escape",
+			path: `$[?(@.name=='\uXY')]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterFloatLiteral, val: ".1"},
-				{typ: lexemeError, val: `literal cannot be matched using =~ at position 6, following ".1"`},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid string literal '\uXY': invalid escape sequence \uXY before position 18`},
 			},
 		},
 		{
-			name: "filter invalid regular expression",
-			path: `$[?(@.child=~/(.*/)]`,
+			name: "filter string literal with short hexadecimal escape",
+			path: `$[?(@.name=='\x4')]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeDotChild, val: ".child"},
-				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
-				{typ: lexemeError, val: "invalid regular expression at position 13, following \"=~\": error parsing regexp: missing closing ): `(.*`"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid string literal '\x4': invalid escape sequence \x4 before position 17`},
 			},
 		},
 		{
-			name: "unescaped single quote in bracket child name",
-			path: `$['single'quote']`,
+			name: "filter string literal with unsupported escape",
+			path: `$[?(@.name=='C:\dir')]`,
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeError, val: `missing "]" or "," at position 10, following "$['single'"`},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `invalid string literal 'C:\dir': unsupported escape sequence \d before position 20`},
 			},
 		},
 		{
-			name: "escaped single quote in bracket child name",
-			path: `$['single\']quote']`, // apparent '] to catch poor lexing
+			name: "filter match with bound regular expression",
+			path: "$[?(@.name=~$patterns.name)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeBracketChild, val: `['single\']quote']`}, // still escaped for later parsing
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterBinding, val: "$patterns"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "escaped backslash in bracket child name",
-			path: `$['\\']`,
+			name: "filter match with root instead of regular expression",
+			path: "$[?(@.name=~$.x)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeBracketChild, val: `['\\']`}, // still escaped for later parsing
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeError, val: `missing binding name after $ at position 13, following "=~$"`},
+			},
+		},
+		{
+			name: "recursive descent tag",
+			path: "$..<!!int>",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeRecursiveDescent, val: ".."},
+				{typ: lexemeTag, val: "<!!int>"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "unescaped single quote after escaped backslash in bracket child name",
-			path: `$['single\\'quote']`,
+			name: "tag of child followed by child",
+			path: "$.a<!MyTag>.b",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeError, val: `missing "]" or "," at position 12, following "$['single\\\\'"`},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeTag, val: "<!MyTag>"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "unsupported escape sequence in bracket child name",
-			path: `$['\n']`,
+			name: "unmatched tag",
+			path: "$[*]<!!int",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeError, val: `unsupported escape sequence inside '' at position 3, following "$['"`},
+				{typ: lexemeArraySubscript, val: "[*]"},
+				{typ: lexemeError, val: `unmatched "<" at position 10, following "[*]<!!int"`},
 			},
 		},
 		{
-			name: "unclosed and empty bracket child name with space",
-			path: `$[ '`,
+			name: "filter tag of current node",
+			path: "$[?(@<!!int> < 3)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeError, val: `unmatched "'" at position 4, following "$[ '"`},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeTag, val: "<!!int>"},
+				{typ: lexemeFilterLessThan, val: "<"},
+				{typ: lexemeFilterIntegerLiteral, val: "3"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "unclosed and empty bracket child name with formfeed",
-			path: "[\f'",
+			name: "filter index",
+			path: "$[?(@index<2)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
-				{typ: lexemeError, val: "unmatched \"'\" at position 3, following \"[\\f'\""},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIndex, val: "@index"},
+				{typ: lexemeFilterLessThan, val: "<"},
+				{typ: lexemeFilterIntegerLiteral, val: "2"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter involving value of current node on left hand side",
-			path: "$[?(@==1)]",
+			name: "filter key",
+			path: "$.*[?(@# =~ /^a/ || 'b' == @#)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".*"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterKey, val: "@#"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/^a/"},
+				{typ: lexemeFilterOr, val: "||"},
+				{typ: lexemeFilterStringLiteral, val: "'b'"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterKey, val: "@#"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
 		{
-			name: "filter involving value of current node on right hand side",
-			path: "$[?(1==@ || 2== @ )]",
+			name: "first and last elements",
+			path: "$.a.first().b.last()[0]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFirstOrLastElement, val: ".first()"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeFirstOrLastElement, val: ".last()"},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "first element in filter",
+			path: "$[?(@.first() == 1)]",
 			expected: []lexeme{
 				{typ: lexemeRoot, val: "$"},
 				{typ: lexemeFilterBegin, val: "[?("},
-				{typ: lexemeFilterIntegerLiteral, val: "1"},
-				{typ: lexemeFilterEquality, val: "=="},
 				{typ: lexemeFilterAt, val: "@"},
-				{typ: lexemeFilterOr, val: "||"},
-				{typ: lexemeFilterIntegerLiteral, val: "2"},
+				{typ: lexemeFirstOrLastElement, val: ".first()"},
 				{typ: lexemeFilterEquality, val: "=="},
-				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
 				{typ: lexemeFilterEnd, val: ")]"},
 				{typ: lexemeIdentity, val: ""},
 			},
 		},
+		{
+			name: "child named first",
+			path: "$.first.last",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".first"},
+				{typ: lexemeDotChild, val: ".last"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "key regular expression",
+			path: "$.config.~/^feature_/.on",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".config"},
+				{typ: lexemeKeyRegularExpression, val: ".~/^feature_/"},
+				{typ: lexemeDotChild, val: ".on"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "key regular expression containing dot and slash",
+			path: `$.~/[./]x\//[0]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeKeyRegularExpression, val: `.~/[./]x\//`},
+				{typ: lexemeArraySubscript, val: "[0]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "key regular expression in filter",
+			path: "$[?(@.~/^a/ == 1)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeKeyRegularExpression, val: ".~/^a/"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "invalid key regular expression",
+			path: "$.a.~/(/",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeError, val: "invalid regular expression at position 5, following \".a.~\": error parsing regexp: missing closing ): `(`"},
+			},
+		},
+		{
+			name: "unterminated key regular expression",
+			path: "$.~/a",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: `unmatched regular expression delimiter / at position 3, following "$.~"`},
+			},
+		},
+		{
+			name: "filter between",
+			path: "$[?(@.port between 1024 and 65535)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".port"},
+				{typ: lexemeFilterBetween, val: "between"},
+				{typ: lexemeFilterIntegerLiteral, val: "1024"},
+				{typ: lexemeFilterBetweenAnd, val: "and"},
+				{typ: lexemeFilterIntegerLiteral, val: "65535"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter between paths",
+			path: "$[?(@ between $.min and $max && @.b)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterBetween, val: "between"},
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".min"},
+				{typ: lexemeFilterBetweenAnd, val: "and"},
+				{typ: lexemeFilterBinding, val: "$max"},
+				{typ: lexemeFilterAnd, val: "&&"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter between child named and",
+			path: "$[?(@.between between @.and and 2)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".between"},
+				{typ: lexemeFilterBetween, val: "between"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".and"},
+				{typ: lexemeFilterBetweenAnd, val: "and"},
+				{typ: lexemeFilterIntegerLiteral, val: "2"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter between missing and",
+			path: "$[?(@.port between 1024)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".port"},
+				{typ: lexemeFilterBetween, val: "between"},
+				{typ: lexemeFilterIntegerLiteral, val: "1024"},
+				{typ: lexemeError, val: `missing and after lower bound of between at position 23, following "1024"`},
+			},
+		},
+		{
+			name: "filter between string bound",
+			path: "$[?(@.port between 1 and 'x')]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".port"},
+				{typ: lexemeFilterBetween, val: "between"},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterBetweenAnd, val: "and"},
+				{typ: lexemeError, val: `upper bound of between must be numeric at position 25, following "and "`},
+			},
+		},
+		{
+			name: "filter between literal",
+			path: "$[?(true between 1 and 2)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterBooleanLiteral, val: "true"},
+				{typ: lexemeError, val: `literal cannot be tested using between at position 9, following "true "`},
+			},
+		},
+		{
+			name: "filter index modulo",
+			path: "$[?(@index % 2 == 0)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIndex, val: "@index"},
+				{typ: lexemeFilterModulo, val: "%"},
+				{typ: lexemeFilterIntegerLiteral, val: "2"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterIntegerLiteral, val: "0"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter modulo of path",
+			path: "$[?(1==@.n % 3)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".n"},
+				{typ: lexemeFilterModulo, val: "%"},
+				{typ: lexemeFilterIntegerLiteral, val: "3"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter modulo missing integer literal",
+			path: "$[?(@index % == 0)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIndex, val: "@index"},
+				{typ: lexemeFilterModulo, val: "%"},
+				{typ: lexemeError, val: `missing integer literal after % at position 13, following "% "`},
+			},
+		},
+		{
+			name: "filter modulo float literal",
+			path: "$[?(@index % 2.5 == 0)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIndex, val: "@index"},
+				{typ: lexemeFilterModulo, val: "%"},
+				{typ: lexemeFilterFloatLiteral, val: "2.5"},
+				{typ: lexemeError, val: `% must be followed by an integer literal at position 16, following "2.5"`},
+			},
+		},
+		{
+			name: "filter negation",
+			path: "$[?(!@.child)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterNot, val: "!"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter negation of comparison (edge case)",
+			path: "$[?(!@.child>1)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterNot, val: "!"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter negation of bracket",
+			path: "$[?(!(@.child))]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterNot, val: "!"},
+				{typ: lexemeFilterOpenBracket, val: "("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "adjacent filters",
+			path: "$.items[?(@.a)][?(@.b)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".items"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression",
+			path: "$[?(@.child=~/.*/)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/.*/"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with escaped /",
+			path: `$[?(@.child=~/\/.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/\/.*/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: `filter regular expression with escaped \`,
+			path: `$[?(@.child=~/\\/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/\\/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with / in character class",
+			path: `$[?(@.path=~/[/a-z]+/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/[/a-z]+/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with / in negated character class",
+			path: `$[?(@.path=~/^\/[^/]+$/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/^\/[^/]+$/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with ] and / in character class",
+			path: `$[?(@.path=~/[]/][^]/]/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/[]/][^]/]/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with escaped ] and / in character class",
+			path: `$[?(@.path=~/[\]/]|[\\/]/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/[\]/]|[\\/]/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with / in named class in character class",
+			path: `$[?(@.path=~/[[:alpha:]/]/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/[[:alpha:]/]/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with alternation and / in character classes",
+			path: `$[?(@.path=~/^(a[/]b|c[/])$/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: `/^(a[/]b|c[/])$/`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with flag",
+			path: `$[?(@.child=~/a/i)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/a/i"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression without flags",
+			path: `$[?(@.child=~/a/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/a/"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with several flags followed by conjunction",
+			path: `$[?(@.child=~/a.b/is&&@.x)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/a.b/is"},
+				{typ: lexemeFilterAnd, val: "&&"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".x"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression with unknown flag",
+			path: `$[?(@.child=~/a/x)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/a/"},
+				{typ: lexemeError, val: `invalid filter expression at position 16, following "/a/"`},
+			},
+		},
+		{
+			name: "filter regular expression with unterminated character class",
+			path: `$[?(@.path=~/[/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".path"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeError, val: `unmatched regular expression delimiter / at position 12, following "=~"`},
+			},
+		},
+		{
+			name: "filter regular expression with missing leading /",
+			path: `$[?(@.child=~.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeError, val: `regular expression does not start with / at position 13, following "=~"`},
+			},
+		},
+		{
+			name: "filter regular expression with missing trailing /",
+			path: `$[?(@.child=~/.*)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeError, val: `unmatched regular expression delimiter / at position 13, following "=~"`},
+			},
+		},
+		{
+			name: "filter regular expression to match string literal",
+			path: `$[?('x'=~/.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterStringLiteral, val: "'x'"},
+				{typ: lexemeError, val: `literal cannot be matched using =~ at position 7, following "'x'"`},
+			},
+		},
+		{
+			name: "filter regular expression to match integer literal",
+			path: `$[?(0=~/.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIntegerLiteral, val: "0"},
+				{typ: lexemeError, val: `literal cannot be matched using =~ at position 5, following "0"`},
+			},
+		},
+		{
+			name: "filter regular expression to match float literal",
+			path: `$[?(.1=~/.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterFloatLiteral, val: ".1"},
+				{typ: lexemeError, val: `literal cannot be matched using =~ at position 6, following ".1"`},
+			},
+		},
+		{
+			name: "filter regular expression non-match",
+			path: "$[?(@.child!~/.*/)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterNotMatchesRegularExpression, val: "!~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/.*/"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression non-match with whitespace",
+			path: "$[?(@.child !~ /.*/ && @.x)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterNotMatchesRegularExpression, val: "!~"},
+				{typ: lexemeFilterRegularExpressionLiteral, val: "/.*/"},
+				{typ: lexemeFilterAnd, val: "&&"},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".x"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression non-match with bound regular expression",
+			path: "$[?(@.name!~$patterns.name)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterNotMatchesRegularExpression, val: "!~"},
+				{typ: lexemeFilterBinding, val: "$patterns"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter regular expression non-match of string literal",
+			path: `$[?('x'!~/.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterStringLiteral, val: "'x'"},
+				{typ: lexemeError, val: `literal cannot be matched using !~ at position 7, following "'x'"`},
+			},
+		},
+		{
+			name: "filter regular expression non-match with missing leading /",
+			path: `$[?(@.child!~.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterNotMatchesRegularExpression, val: "!~"},
+				{typ: lexemeError, val: `regular expression does not start with / at position 13, following "!~"`},
+			},
+		},
+		{
+			name: "filter array literal",
+			path: `$[?(@.tags==['a', "b,]", 1, -2.5, true, null])]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterArrayLiteral, val: `['a', "b,]", 1, -2.5, true, null]`},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter empty array literal with inequality",
+			path: "$[?(@.tags != [ ])]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterInequality, val: "!="},
+				{typ: lexemeFilterArrayLiteral, val: "[ ]"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter array literal with ordering",
+			path: "$[?(@.tags<['a'])]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterLessThan, val: "<"},
+				{typ: lexemeError, val: `array literal can only be compared using == or != at position 11, following "<"`},
+			},
+		},
+		{
+			name: "filter array literal with missing element",
+			path: "$[?(@.tags==['a',])]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: "invalid array literal ['a',]: missing element after , before position 18"},
+			},
+		},
+		{
+			name: "filter array literal with invalid element",
+			path: "$[?(@.tags==[a])]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: "invalid array literal [a]: invalid element a before position 15"},
+			},
+		},
+		{
+			name: "filter array literal with missing end",
+			path: "$[?(@.tags==['a'",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".tags"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeError, val: `missing end of array literal at position 12, following "=="`},
+			},
+		},
+		{
+			name: "filter invalid regular expression",
+			path: `$[?(@.child=~/(.*/)]`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".child"},
+				{typ: lexemeFilterMatchesRegularExpression, val: "=~"},
+				{typ: lexemeError, val: "invalid regular expression at position 13, following \"=~\": error parsing regexp: missing closing ): `(.*`"},
+			},
+		},
+		{
+			name: "unescaped single quote in bracket child name",
+			path: `$['single'quote']`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: `missing "]" or "," at position 10, following "$['single'"`},
+			},
+		},
+		{
+			name: "escaped single quote in bracket child name",
+			path: `$['single\']quote']`, // apparent '] to catch poor lexing
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeBracketChild, val: `['single\']quote']`}, // still escaped for later parsing
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "escaped backslash in bracket child name",
+			path: `$['\\']`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeBracketChild, val: `['\\']`}, // still escaped for later parsing
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "unescaped single quote after escaped backslash in bracket child name",
+			path: `$['single\\'quote']`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: `missing "]" or "," at position 12, following "$['single\\\\'"`},
+			},
+		},
+		{
+			name: "unsupported escape sequence in bracket child name",
+			path: `$['\n']`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: `unsupported escape sequence inside '' at position 3, following "$['"`},
+			},
+		},
+		{
+			name: "unclosed and empty bracket child name with space",
+			path: `$[ '`,
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: `unmatched "'" at position 4, following "$[ '"`},
+			},
+		},
+		{
+			name: "unclosed and empty bracket child name with formfeed",
+			path: "[\f'",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeError, val: "unmatched \"'\" at position 3, following \"[\\f'\""},
+			},
+		},
+		{
+			name: "filter involving value of current node on left hand side",
+			path: "$[?(@==1)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter involving value of current node on right hand side",
+			path: "$[?(1==@ || 2== @ )]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterIntegerLiteral, val: "1"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterOr, val: "||"},
+				{typ: lexemeFilterIntegerLiteral, val: "2"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter function call compared with string literal",
+			path: "$[?(lower(@.name)=='admin')]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterFunctionCall, val: "lower("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".name"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterEquality, val: "=="},
+				{typ: lexemeFilterStringLiteral, val: "'admin'"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter function call with several arguments",
+			path: "$[?(semver(@.version) > semver('1.2.0', @, 3, @index) && f())]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterFunctionCall, val: "semver("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".version"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterGreaterThan, val: ">"},
+				{typ: lexemeFilterFunctionCall, val: "semver("},
+				{typ: lexemeFilterStringLiteral, val: "'1.2.0'"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeFilterIntegerLiteral, val: "3"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeFilterIndex, val: "@index"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterAnd, val: "&&"},
+				{typ: lexemeFilterFunctionCall, val: "f("},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter nested function calls",
+			path: "$[?(f(g(@.a,$.b),@.c[?(@.d,e)]))]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterFunctionCall, val: "f("},
+				{typ: lexemeFilterFunctionCall, val: "g("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeDotChild, val: ".b"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".c"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".d,e"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeFilterCloseBracket, val: ")"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter comma in child name outside function call",
+			path: "$[?(@.a,b)]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a,b"},
+				{typ: lexemeFilterEnd, val: ")]"},
+				{typ: lexemeIdentity, val: ""},
+			},
+		},
+		{
+			name: "filter function call missing argument",
+			path: "$[?(f(@.a,))]",
+			expected: []lexeme{
+				{typ: lexemeRoot, val: "$"},
+				{typ: lexemeFilterBegin, val: "[?("},
+				{typ: lexemeFilterFunctionCall, val: "f("},
+				{typ: lexemeFilterAt, val: "@"},
+				{typ: lexemeDotChild, val: ".a"},
+				{typ: lexemeFilterArgumentSeparator, val: ","},
+				{typ: lexemeError, val: `invalid filter term at position 10, following ","`},
+			},
+		},
 	}
 
 	focussed := false
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+// Option modifies the behaviour of a Path constructed by NewPath.
+type Option func(*options)
+
+// options holds the settings of a Path, which apply equally to any subpaths of its filters except as described by
+// WithStrictTypes.
+type options struct {
+	numericCoercion     bool
+	maxDepth            int
+	distinct            bool
+	timeComparison      bool
+	requireExplicitRoot bool
+	strictSlices        bool
+	scalarWildcardSelf  bool
+	strictTypes         bool
+	anchoredRegex       bool
+	sequenceExtension   bool
+}
+
+// defaultMaxDepth is the maximum depth of recursive descent unless WithMaxDepth is used. It is generous enough
+// not to affect normal documents.
+const defaultMaxDepth = 10000
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		maxDepth: defaultMaxDepth,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithNumericCoercion causes filters which compare a string with a number to parse the string as a number and,
+// if this succeeds, to compare the two numerically. For example, with this option `$[?(@.port==8080)]` matches
+// `port: "8080"`. Without this option, strings and numbers are never equal. Ordering comparisons, such as `>`, parse
+// strings as numbers regardless of this option.
+func WithNumericCoercion() Option {
+	return func(o *options) {
+		o.numericCoercion = true
+	}
+}
+
+// WithMaxDepth limits recursive descent, such as `$..a`, to nodes at most n levels below the node at which the
+// recursive descent starts. If a recursive descent would go deeper, Find returns an error wrapping
+// ErrMaxDepthExceeded. The default limit is 10000. Aliases are not followed, so cyclic documents cannot make
+// recursive descent run forever, but this option protects against pathologically deep documents.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithDistinctResults causes Find and the other methods which apply a Path to return each matching node only once,
+// in the order in which the node was first matched. Without this option, a path such as `$..*..*` may match
+// the same node more than once.
+func WithDistinctResults() Option {
+	return func(o *options) {
+		o.distinct = true
+	}
+}
+
+// WithTimeComparison causes filters which compare two values which are both timestamps, such as
+// `2023-01-01T00:00:00Z`, to compare them chronologically. A value is a timestamp if it is a string or a YAML
+// timestamp which is a date, such as `2023-01-01`, or a date and time in RFC 3339 format, optionally with a space
+// rather than "T" and without a time zone (in which case it is taken to be UTC). For example, with this option
+// `$[?(@.created > '2023-01-01T00:00:00Z')]` matches `created: 2023-06-30T12:00:00+02:00`. Values which are not both
+// timestamps are compared as usual.
+func WithTimeComparison() Option {
+	return func(o *options) {
+		o.timeComparison = true
+	}
+}
+
+// WithRequireExplicitRoot causes NewPath to return an error if the path does not start with `$`. Without this
+// option, a path such as `.a.b` or `a.b` is taken to start at the root, as if it were `$.a.b`, and the empty path
+// matches the node to which it is applied. Paths in filters, such as `@.a`, are not affected.
+func WithRequireExplicitRoot() Option {
+	return func(o *options) {
+		o.requireExplicitRoot = true
+	}
+}
+
+// WithStrictSlices causes NewPath to return an error if a slice, such as `$.items[5:2]`, is empty because its start
+// is greater than its end and its step is positive, or its start is less than its end and its step is negative. Such
+// a slice usually indicates a mistake. Since a negative index counts from the end of an array, a slice with one
+// negative and one non-negative bound, such as `[-1:2]`, may or may not be empty and is not an error. Without this
+// option, an inverted slice simply matches nothing.
+func WithStrictSlices() Option {
+	return func(o *options) {
+		o.strictSlices = true
+	}
+}
+
+// WithScalarWildcardSelf causes a wildcard, `.*` or `[*]`, applied to a scalar node to select the scalar itself, as
+// if the scalar were its own only child, so that, for example, `$.tags[*]` selects each tag whether `tags` is a
+// sequence or a single scalar. Without this option, a wildcard applied to a scalar selects nothing. Recursive descent,
+// such as `$..*`, is not affected.
+func WithScalarWildcardSelf() Option {
+	return func(o *options) {
+		o.scalarWildcardSelf = true
+	}
+}
+
+// WithStrictTypes causes Find and the other methods which apply a Path to return an error wrapping ErrTypeMismatch,
+// rather than no matches, when a child, such as `.name` or `['name']`, is applied to a node which is not a mapping or
+// an array subscript, such as `[0]` or `[1:3]`, is applied to a node which is not a sequence, so that a path which
+// does not fit the structure of a document is detected early. A single array index may still be applied to a mapping
+// with integer keys. Wildcards, property names, subpaths in filters, and any segments following a recursive descent,
+// all of which are typically applied to nodes of various kinds, are not affected.
+func WithStrictTypes() Option {
+	return func(o *options) {
+		o.strictTypes = true
+	}
+}
+
+// WithAnchoredRegex causes each regular expression in a filter, such as the `/admin/` of `$[?(@.role =~ /admin/)]`,
+// to match only an entire string, as if it were written `/\A(?:admin)\z/`, so that the example matches `role: admin`
+// but not `role: admin-user`. This applies equally to `!~` and to regular expressions bound to names such as
+// $pattern. Without this option, a regular expression matches any string which contains a match, as in Go's regexp
+// package.
+func WithAnchoredRegex() Option {
+	return func(o *options) {
+		o.anchoredRegex = true
+	}
+}
+
+// WithSequenceExtension causes Upsert to extend a sequence with null elements when the Path has an array index
+// beyond the end of the sequence, so that, for example, upserting `$.a[2]` to x in the document `a: [w]` produces
+// `a: [w, null, x]`. Without this option, such an index is an error. The option does not affect Find.
+func WithSequenceExtension() Option {
+	return func(o *options) {
+		o.sequenceExtension = true
+	}
+}
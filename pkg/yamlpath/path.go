@@ -7,7 +7,13 @@
 package yamlpath
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -15,26 +21,591 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ErrNoMatch is returned by FindOrError when a Path matches no nodes and by FindFirstOf when none of its Paths
+// matches any nodes.
+var ErrNoMatch = errors.New("path matched no nodes")
+
 // Path is a compiled YAML path expression.
 type Path struct {
-	f func(node, root *yaml.Node) yit.Iterator
+	f    func(node, root *yaml.Node, e *evaluation) yit.Iterator
+	expr string   // the expression from which the Path was constructed
+	opts *options // the options with which the Path was constructed
 }
 
 // Find applies the Path to a YAML node and returns the addresses of the subnodes which match the Path.
+//
+// Since NewPath detects syntax errors, including those in filters, Find returns an error only if applying the Path
+// fails, for example because a filter refers to a name which is not bound or calls a filter function which is not
+// registered or which returns an error.
 func (p *Path) Find(node *yaml.Node) ([]*yaml.Node, error) {
-	return p.find(node, node), nil // currently, errors are not possible
+	return p.FindContext(context.Background(), node)
+}
+
+// FindContext is like Find except that it periodically checks the given context while applying the Path and, if
+// the context is done, stops and returns the context's error.
+func (p *Path) FindContext(ctx context.Context, node *yaml.Node) ([]*yaml.Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.evaluate(node, newEvaluation(ctx))
+}
+
+// FindWithBindings is like Find except that a filter may refer to the given nodes by name. For example, with a
+// binding of the name "params" to a node, the filter `[?(@.env==$params.region)]` compares the child "env" of the
+// current node with the child "region" of the bound node. If a filter refers to a name which is not bound,
+// FindWithBindings returns an error.
+func (p *Path) FindWithBindings(node *yaml.Node, bindings map[string]*yaml.Node) ([]*yaml.Node, error) {
+	e := newEvaluation(context.Background())
+	e.bindings = bindings
+	return p.evaluate(node, e)
+}
+
+// FindWithRegexpBindings is like FindWithBindings except that a regular expression match in a filter may also refer
+// by name to one of the given compiled regular expressions, such as one supplied by a user, so that
+// `[?(@.name=~$pattern)]`, with the name "pattern" bound to a regular expression, selects the nodes whose name matches
+// the regular expression. A name bound to a regular expression takes precedence over the same name in bindings.
+func (p *Path) FindWithRegexpBindings(node *yaml.Node, bindings map[string]*yaml.Node, regexps map[string]*regexp.Regexp) ([]*yaml.Node, error) {
+	e := newEvaluation(context.Background())
+	e.bindings = bindings
+	e.regexps = regexps
+	return p.evaluate(node, e)
+}
+
+// ErrMultipleDocuments is returned by FindInYAML when the YAML contains more than one document.
+var ErrMultipleDocuments = errors.New("YAML contains more than one document")
+
+// FindInYAML constructs a Path from the given string expression and options, as NewPath does, parses the given YAML,
+// and applies the Path to the top level node of the YAML document, that is the content of its document node. YAML
+// which contains no document produces no matches. FindInYAML returns an error if the path or the YAML is invalid or
+// if the YAML contains more than one document, in which case the error wraps ErrMultipleDocuments.
+func FindInYAML(path string, data []byte, opts ...Option) ([]*yaml.Node, error) {
+	p, err := NewPath(path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return []*yaml.Node{}, nil
+		}
+		return nil, err
+	}
+	var next yaml.Node
+	if err := decoder.Decode(&next); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: the second document starts at line %d", ErrMultipleDocuments, next.Line)
+	}
+
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) == 1 {
+		return p.Find(doc.Content[0])
+	}
+	return p.Find(&doc)
+}
+
+func (p *Path) evaluate(node *yaml.Node, e *evaluation) ([]*yaml.Node, error) {
+	results := p.find(node, node, e)
+	if e.err != nil {
+		return nil, e.err
+	}
+	if p.opts != nil && p.opts.distinct {
+		results = distinct(results)
+	}
+	return results, nil
+}
+
+// distinct returns the given nodes without any duplicates, preserving the order in which the nodes first occur.
+func distinct(nodes []*yaml.Node) []*yaml.Node {
+	seen := map[*yaml.Node]bool{}
+	result := []*yaml.Node{}
+	for _, n := range nodes {
+		if !seen[n] {
+			seen[n] = true
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// FindOrError is like Find except that it returns ErrNoMatch if the Path matches no nodes.
+func (p *Path) FindOrError(node *yaml.Node) ([]*yaml.Node, error) {
+	results, err := p.Find(node)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrNoMatch
+	}
+	return results, nil
 }
 
-func (p *Path) find(node, root *yaml.Node) []*yaml.Node {
-	return p.f(node, root).ToArray()
+// FindFirstOf applies the Path and then each of the given paths in turn to a YAML node, as FindNth does, and
+// returns the first match of the first of them which matches any nodes, so that, for example, a configuration
+// setting may be read from `$.spec.timeout` or else from `$.defaults.timeout`. Paths after the first which matches
+// are not applied. If none of them matches any nodes, FindFirstOf returns ErrNoMatch. If applying a path fails,
+// FindFirstOf returns the error without applying any later paths.
+func (p *Path) FindFirstOf(root *yaml.Node, paths ...*Path) (*yaml.Node, error) {
+	for _, q := range append([]*Path{p}, paths...) {
+		match, err := q.FindNth(root, 0)
+		if err != nil {
+			return nil, err
+		}
+		if match != nil {
+			return match, nil
+		}
+	}
+	return nil, ErrNoMatch
 }
 
-// NewPath constructs a Path from a string expression.
-func NewPath(path string) (*Path, error) {
-	return newPath(lex("Path lexer", path))
+// FindValues is like Find except that it decodes each match into a Go value, as if by the match's Decode method
+// with a pointer to an interface{}. So a scalar becomes a string, int, float64, bool, time.Time, or nil, a sequence
+// becomes a []interface{}, and a mapping becomes a map[string]interface{} (or, if some of its keys are not strings, a
+// map[interface{}]interface{}). A timestamp, such as 2023-01-02 or !!timestamp '2023-01-02', becomes a time.Time,
+// whereas a quoted date without a tag, such as '2023-01-02', is a string.
+func (p *Path) FindValues(node *yaml.Node) ([]interface{}, error) {
+	results, err := p.Find(node)
+	if err != nil {
+		return nil, err
+	}
+	values := []interface{}{}
+	for _, r := range results {
+		var v interface{}
+		if err := r.Decode(&v); err != nil {
+			return nil, fmt.Errorf("cannot decode match at line %d, column %d: %w", r.Line, r.Column, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
 }
 
-func newPath(l *lexer) (*Path, error) {
+// FindScalar applies the Path, which must be singular, to the given node and decodes the matching scalar, as
+// FindValues does. It returns the value and true or, if the Path matches no node, nil and false. This is the
+// simplest way of reading a single value, such as `$.spec.replicas`, from a document. An alias of a scalar is
+// decoded as the scalar and, if a mapping has duplicate keys, only the first match is decoded.
+//
+// If the Path is not singular, FindScalar returns an error wrapping ErrNotSingular and, if the Path matches a mapping
+// or sequence, an error wrapping ErrTypeMismatch.
+func (p *Path) FindScalar(root *yaml.Node) (interface{}, bool, error) {
+	if !p.IsSingular() {
+		return nil, false, fmt.Errorf("%w: %q may match more than one node", ErrNotSingular, p.expr)
+	}
+	results, err := p.Find(root)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(results) == 0 {
+		return nil, false, nil
+	}
+
+	match := results[0]
+	r := match
+	if r.Kind == yaml.AliasNode && r.Alias != nil {
+		r = r.Alias
+	}
+	if r.Kind != yaml.ScalarNode {
+		return nil, false, fmt.Errorf("%w: %q requires a scalar but matched %s node%s", ErrTypeMismatch, p.expr, kindOf(r).withArticle(), positionOf(match))
+	}
+	var v interface{}
+	if err := r.Decode(&v); err != nil {
+		return nil, false, fmt.Errorf("cannot decode match at line %d, column %d: %w", r.Line, r.Column, err)
+	}
+	return v, true, nil
+}
+
+// FindFunc applies the Path to the given node, as Find does, and returns the matches for which the given predicate
+// returns true, in the order in which Find returns them. This allows a condition which is awkward to express in a
+// filter to be written in Go, while the Path navigates to the nodes to be tested.
+func (p *Path) FindFunc(node *yaml.Node, pred func(*yaml.Node) bool) ([]*yaml.Node, error) {
+	results, err := p.Find(node)
+	if err != nil {
+		return nil, err
+	}
+	matches := []*yaml.Node{}
+	for _, r := range results {
+		if pred(r) {
+			matches = append(matches, r)
+		}
+	}
+	return matches, nil
+}
+
+// FindDistinctBy applies the Path to the given node, as Find does, and returns the first match for each distinct value
+// of the given subpath, such as `.name`, applied to each match. The subpath is applied with the same options as the
+// Path and its root, `$`, refers to the match. The matches are returned in the order in which Find returns them.
+//
+// The value of the first node matched by the subpath, decoded as for FindValues, is the key of a match, so that, for
+// example, `a` and `'a'` are the same key but `1` and `'1'` are not. All the matches for which the subpath matches
+// no node have the same key.
+func (p *Path) FindDistinctBy(root *yaml.Node, subpath string) ([]*yaml.Node, error) {
+	key, err := compile(subpath, p.opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key subpath %q: %w", subpath, err)
+	}
+
+	results, err := p.Find(root)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	distinct := []*yaml.Node{}
+	for _, r := range results {
+		k, err := key.Find(r)
+		if err != nil {
+			return nil, err
+		}
+		id := "" // no key
+		if len(k) > 0 {
+			var v interface{}
+			if err := k[0].Decode(&v); err != nil {
+				return nil, fmt.Errorf("cannot decode key at line %d, column %d: %w", k[0].Line, k[0].Column, err)
+			}
+			// format the key so that values which are not comparable, such as maps, may be used
+			id = fmt.Sprintf("%T %#v", v, v)
+		}
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, r)
+		}
+	}
+	return distinct, nil
+}
+
+// Matches returns true if and only if applying the Path to the given root node would match the given candidate
+// node, which is compared by address. Matches stops applying the Path as soon as it matches the candidate.
+func (p *Path) Matches(root, candidate *yaml.Node) (bool, error) {
+	e := newEvaluation(context.Background())
+	i := p.f(root, root, e)
+	for n, ok := i(); ok && e.err == nil; n, ok = i() {
+		if n == candidate {
+			return true, nil
+		}
+	}
+	return false, e.err
+}
+
+// FindNth applies the Path to a YAML node and returns the match at the given index, counting from 0, in the order in
+// which Find returns the matches, or nil if there are fewer matches. FindNth stops applying the Path as soon as it
+// reaches the match. A negative index counts back from the last match, so that -1 selects the last match, which
+// requires all the matches to be found, as Find does.
+func (p *Path) FindNth(node *yaml.Node, n int) (*yaml.Node, error) {
+	if n < 0 {
+		results, err := p.Find(node)
+		if err != nil || len(results) < -n {
+			return nil, err
+		}
+		return results[len(results)+n], nil
+	}
+
+	e := newEvaluation(context.Background())
+	seen := map[*yaml.Node]bool{}
+	i := p.f(node, node, e)
+	for m, ok := i(); ok && e.err == nil; m, ok = i() {
+		if p.opts != nil && p.opts.distinct {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+		}
+		if n == 0 {
+			return m, nil
+		}
+		n--
+	}
+	return nil, e.err
+}
+
+// FindRange applies the Path to a YAML node and returns at most limit matches, starting with the match at the given
+// offset, counting from 0, in the order in which Find returns the matches, so that, for example, a user interface may
+// page through the matches of a broad path. FindRange stops applying the Path as soon as it reaches the last match of
+// the range, so it collects at most offset+limit matches. If there are no more than offset matches, FindRange returns
+// no matches. FindRange returns an error if the offset or the limit is negative.
+func (p *Path) FindRange(root *yaml.Node, offset, limit int) ([]*yaml.Node, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("invalid range with offset %d and limit %d", offset, limit)
+	}
+
+	results := []*yaml.Node{}
+	if limit == 0 {
+		return results, nil
+	}
+	e := newEvaluation(context.Background())
+	seen := map[*yaml.Node]bool{}
+	i := p.f(root, root, e)
+	for m, ok := i(); ok && e.err == nil; m, ok = i() {
+		if p.opts != nil && p.opts.distinct {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		results = append(results, m)
+		if len(results) == limit {
+			break
+		}
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return results, nil
+}
+
+// Count applies the Path to a YAML node and returns the number of subnodes which match the Path, as Find does,
+// without collecting the matches.
+func (p *Path) Count(node *yaml.Node) (int, error) {
+	e := newEvaluation(context.Background())
+	seen := map[*yaml.Node]bool{}
+	count := 0
+	i := p.f(node, node, e)
+	for n, ok := i(); ok && e.err == nil; n, ok = i() {
+		if p.opts != nil && p.opts.distinct {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+		}
+		count++
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+	return count, nil
+}
+
+// CountInDocuments applies the Path, as Count does, to each of the given nodes, such as the documents of a YAML
+// stream, and returns the number of matches in each, in the same order as the nodes. If applying the Path to a node
+// fails, CountInDocuments returns an error which identifies the node by its index.
+func (p *Path) CountInDocuments(docs []*yaml.Node) ([]int, error) {
+	counts := make([]int, 0, len(docs))
+	for i, doc := range docs {
+		count, err := p.Count(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// FindInStreamWithContext applies the Path, as Find does, to each of the given nodes, such as the documents of a YAML
+// stream, except that `$` in a filter refers to the given context node, such as a document of defaults shared by the
+// stream, rather than to the node to which the Path is applied. So, for example, `$[?(@.replicas > $.maxReplicas)]`
+// matches each document whose replicas exceed the maxReplicas of the context node. A `$` at the start of the Path
+// still refers to each node in turn, as do `@` and `@^`.
+//
+// FindInStreamWithContext returns the matches in each of the nodes, in the same order as the nodes. If applying the
+// Path to a node fails, FindInStreamWithContext returns an error which identifies the node by its index.
+func (p *Path) FindInStreamWithContext(docs []*yaml.Node, contextDoc *yaml.Node) ([]*yaml.Node, error) {
+	results := []*yaml.Node{}
+	for i, doc := range docs {
+		e := newEvaluation(context.Background())
+		e.shared = contextDoc
+		matches, err := p.evaluate(doc, e)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		results = append(results, matches...)
+	}
+	return results, nil
+}
+
+func (p *Path) find(node, root *yaml.Node, e *evaluation) []*yaml.Node {
+	return p.f(node, root, e).ToArray()
+}
+
+// NewPath constructs a Path from a string expression. Any options modify the behaviour of the Path.
+//
+// NewPath lexes the whole expression, including any filters and the paths within them, compiles any regular
+// expressions, and parses each filter, so a syntax error, including an error in the structure of a filter such as a
+// missing operand, is always reported by NewPath rather than when the Path is applied.
+//
+// Leading and trailing ASCII whitespace, such as the trailing newline of a path read from a file, is ignored, so
+// `" $.a "` is equivalent to `$.a`, and the positions in any error are those in the expression without it. Whitespace
+// within the expression, such as in a filter or a quoted child name, is unaffected.
+func NewPath(path string, opts ...Option) (*Path, error) {
+	path = trimWhitespace(path)
+	o := newOptions(opts)
+	if o.requireExplicitRoot {
+		l := lex("Path lexer", path)
+		l.requireExplicitRoot = true
+		lexemes := lexemesOf(l)
+		if lx := lexemes[len(lexemes)-1]; lx.typ == lexemeError {
+			return nil, errors.New(lx.val)
+		}
+	}
+	return compile(path, o)
+}
+
+// NewRelativePath constructs a Path from a string expression which starts with `@`, such as `@.spec.replicas`. Any
+// options modify the behaviour of the Path, except that a relative path need not start with `$` even with
+// WithRequireExplicitRoot.
+//
+// A relative path is applied to a node in the same way as `@` in a filter is applied to the current node. So, unlike
+// `$`, `@` refers to a document node itself rather than its content. Any `$` in a filter of a relative path refers
+// to the node to which the relative path is applied.
+//
+// As with NewPath, leading and trailing ASCII whitespace is ignored.
+func NewRelativePath(path string, opts ...Option) (*Path, error) {
+	path = trimWhitespace(path)
+	if !strings.HasPrefix(path, filterAt) {
+		return nil, fmt.Errorf("relative path %q does not start with %s", path, filterAt)
+	}
+	return compile(path, newOptions(opts))
+}
+
+// pathWhitespace is the ASCII whitespace which is ignored before and after a path expression.
+const pathWhitespace = " \t\n\v\f\r"
+
+// trimWhitespace returns the given path expression without any leading and trailing ASCII whitespace, except for
+// trailing whitespace which is escaped, as in the child name of `$.a\ `.
+func trimWhitespace(path string) string {
+	path = strings.TrimLeft(path, pathWhitespace)
+	trimmed := strings.TrimRight(path, pathWhitespace)
+	if escapes := len(trimmed) - len(strings.TrimRight(trimmed, "\\")); escapes%2 == 1 && len(trimmed) < len(path) {
+		return path[:len(trimmed)+1]
+	}
+	return trimmed
+}
+
+func compile(path string, o *options) (*Path, error) {
+	var p *Path
+	ok := false
+	if !o.strictTypes {
+		p, ok = newSimplePath(lexAll(path))
+	}
+	if !ok {
+		var err error
+		p, err = newPath(lex("Path lexer", path), o)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := validateLexemes(lexAll(path), false); err != nil {
+		return nil, err
+	}
+	if o.strictSlices {
+		if err := checkSlices(lexAll(path)); err != nil {
+			return nil, err
+		}
+	}
+	p.expr = path
+	p.opts = o
+	return p, nil
+}
+
+// ErrNotRelative is returned by Then when the path to be applied to each match is not a relative path.
+var ErrNotRelative = errors.New("path is not relative")
+
+// Then returns a Path which applies the given relative path (see NewRelativePath) to each match of the Path. For
+// example, applying `$..containers[*]` followed by `@.image` is equivalent to applying `$..containers[*].image`,
+// and the String method of the returned Path produces the equivalent single path. Any `$` in a filter of the
+// relative path refers to the node to which the returned Path is applied. The returned Path has the options of the
+// Path, whereas the relative path retains its own options.
+//
+// If next is not a relative path, Then returns an error wrapping ErrNotRelative.
+func (p *Path) Then(next *Path) (*Path, error) {
+	if !strings.HasPrefix(next.expr, filterAt) {
+		return nil, fmt.Errorf("%w: Then cannot apply %q to each match", ErrNotRelative, next.expr)
+	}
+	first := p
+	then := new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		return compose(first.f(node, root, e), next, root, e)
+	})
+	if p.expr == "" {
+		then.expr = next.expr // the identity followed by a relative path is that relative path
+	} else {
+		then.expr = p.expr + strings.TrimPrefix(next.expr, filterAt)
+	}
+	then.opts = p.opts
+	return then, nil
+}
+
+// checkSlices returns an error if any array subscript, including those in filters, in the given lexemes contains an
+// inverted slice. See WithStrictSlices.
+func checkSlices(lexemes []lexeme) error {
+	for _, lx := range lexemes {
+		switch lx.typ {
+		case lexemeArraySubscript, lexemeArraySubscriptPropertyName:
+			subscript := strings.TrimSuffix(strings.TrimSuffix(lx.val, propertyName), rightBracket)
+			if err := checkInvertedSlices(strings.TrimPrefix(subscript, leftBracket)); err != nil {
+				return fmt.Errorf("invalid array index %s: %w", lx.val, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks that each function called in a filter of the Path is registered (see RegisterFilterFunc), so that
+// a misspelt function name may be detected before the Path is applied. Errors in the structure of a filter, such as
+// a missing operand, are reported by NewPath, rather than by Validate, since they are syntax errors, whereas a
+// function may be registered after the Path is constructed.
+func (p *Path) Validate() error {
+	return validateLexemes(lexAll(p.expr), true)
+}
+
+// validateLexemes checks each filter, including nested filters, in the given lexemes for errors in its structure
+// and, if checkFuncs is true, for calls of functions which are not registered.
+func validateLexemes(lexemes []lexeme, checkFuncs bool) error {
+	for i := 0; i < len(lexemes); i++ {
+		switch lexemes[i].typ {
+		case lexemeError:
+			return errors.New(lexemes[i].val)
+
+		case lexemeFilterBegin, lexemeRecursiveFilterBegin:
+			filterLexemes := []lexeme{}
+			filterNestingLevel := 1
+		f:
+			for i++; i < len(lexemes); i++ {
+				switch lexemes[i].typ {
+				case lexemeFilterBegin:
+					filterNestingLevel++
+				case lexemeFilterEnd:
+					filterNestingLevel--
+					if filterNestingLevel == 0 {
+						break f
+					}
+				}
+				filterLexemes = append(filterLexemes, lexemes[i])
+			}
+
+			parser := newParser(filterLexemes)
+			parser.checkFuncs = checkFuncs
+			tree := parser.parse()
+			if parser.err != nil {
+				return fmt.Errorf("invalid filter %q: %s", strings.TrimSpace(canonical(filterLexemes)), parser.err)
+			}
+			if err := validateFilterNode(tree, checkFuncs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateFilterNode checks the subpaths of the terms of the given filter parse tree for semantic errors.
+func validateFilterNode(n *filterNode, checkFuncs bool) error {
+	if n == nil {
+		return nil
+	}
+	if err := validateLexemes(n.subpath, checkFuncs); err != nil {
+		return err
+	}
+	for _, c := range n.children {
+		if err := validateFilterNode(c, checkFuncs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newPath(l *lexer, o *options) (*Path, error) {
 	lx := l.nextLexeme()
 
 	switch lx.typ {
@@ -45,20 +616,24 @@ func newPath(l *lexer) (*Path, error) {
 	case lexemeIdentity, lexemeEOF:
 		return new(identity), nil
 
+	case lexemeFilterAt:
+		// the start of a relative path, which applies to the given node even if it is a document
+		return newPath(l, o)
+
 	case lexemeRoot:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
-		return new(func(node, root *yaml.Node) yit.Iterator {
+		return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 			if node.Kind == yaml.DocumentNode {
 				node = node.Content[0]
 			}
-			return compose(yit.FromNode(node), subPath, root)
+			return compose(yit.FromNode(node), subPath, root, e)
 		}), nil
 
 	case lexemeRecursiveDescent:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, lenient(o))
 		if err != nil {
 			return nil, err
 		}
@@ -66,55 +641,87 @@ func newPath(l *lexer) (*Path, error) {
 		switch childName {
 		case "*":
 			// includes all nodes, not just mapping nodes
-			return new(func(node, root *yaml.Node) yit.Iterator {
-				return compose(yit.FromNode(node).RecurseNodes(), allChildrenThen(subPath), root)
+			return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+				return compose(recurseNodes(node, o.maxDepth, e), allChildrenThen(subPath), root, e)
 			}), nil
 
 		case "":
-			return new(func(node, root *yaml.Node) yit.Iterator {
-				return compose(yit.FromNode(node).RecurseNodes(), subPath, root)
+			return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+				return compose(recurseNodes(node, o.maxDepth, e), subPath, root, e)
 			}), nil
 
 		default:
-			return new(func(node, root *yaml.Node) yit.Iterator {
-				return compose(yit.FromNode(node).RecurseNodes(), childThen(childName, subPath), root)
+			return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+				return compose(recurseNodes(node, o.maxDepth, e), childThen(childName, subPath), root, e)
 			}), nil
 		}
 
 	case lexemeDotChild:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
 		childName := strings.TrimPrefix(lx.val, ".")
+		if childName == "*" {
+			return wildcardThen(childThen(childName, subPath), subPath, o), nil
+		}
 
-		return childThen(childName, subPath), nil
+		return typeCheckThen(childThen(childName, subPath), lx.val, isMapping, o), nil
 
 	case lexemeUndottedChild:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
+		if lx.val == "*" {
+			return wildcardThen(childThen(lx.val, subPath), subPath, o), nil
+		}
 
-		return childThen(lx.val, subPath), nil
+		return typeCheckThen(childThen(lx.val, subPath), lx.val, isMapping, o), nil
 
 	case lexemeBracketChild:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
 		childNames := strings.TrimSpace(lx.val)
 		childNames = strings.TrimSuffix(strings.TrimPrefix(childNames, "["), "]")
 		childNames = strings.TrimSpace(childNames)
-		return bracketChildThen(childNames, subPath), nil
+		return typeCheckThen(bracketChildThen(childNames, subPath), lx.val, isMapping, o), nil
+
+	case lexemeKeyRegularExpression:
+		subPath, err := newPath(l, o)
+		if err != nil {
+			return nil, err
+		}
+		// the lexer has checked that the regular expression compiles
+		re := regexp.MustCompile(sanitiseRegularExpressionLiteral(strings.TrimPrefix(lx.val, keyRegularExpression)))
+		return typeCheckThen(keyRegularExpressionThen(re, subPath), lx.val, isMapping, o), nil
+
+	case lexemeFirstOrLastElement:
+		subPath, err := newPath(l, o)
+		if err != nil {
+			return nil, err
+		}
+		return typeCheckThen(firstOrLastElementThen(lx.val == lastElement, subPath), lx.val, isSequence, o), nil
 
 	case lexemeArraySubscript:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
 		subscript := strings.TrimSuffix(strings.TrimPrefix(lx.val, "["), "]")
-		return arraySubscriptThen(subscript, subPath), nil
+		if strings.TrimSpace(subscript) == "*" {
+			return wildcardThen(arraySubscriptThen(subscript, subPath), subPath, o), nil
+		}
+		return typeCheckThen(arraySubscriptThen(subscript, subPath), lx.val, isIndexable(subscript), o), nil
+
+	case lexemeTag:
+		subPath, err := newPath(l, o)
+		if err != nil {
+			return nil, err
+		}
+		return tagThen(strings.TrimSuffix(strings.TrimPrefix(lx.val, "<"), tagEnd), subPath), nil
 
 	case lexemeFilterBegin, lexemeRecursiveFilterBegin:
 		var recursive bool
@@ -145,16 +752,16 @@ func newPath(l *lexer) (*Path, error) {
 			filterLexemes = append(filterLexemes, lx)
 		}
 
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
 		if recursive {
-			return recursiveFilterThen(filterLexemes, subPath), nil
+			return recursiveFilterThen(filterLexemes, subPath, o), nil
 		}
-		return filterThen(filterLexemes, subPath), nil
+		return filterThen(filterLexemes, subPath, o), nil
 	case lexemePropertyName:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
@@ -162,7 +769,7 @@ func newPath(l *lexer) (*Path, error) {
 		childName = strings.TrimSuffix(childName, propertyName)
 		return propertyNameChildThen(childName, subPath), nil
 	case lexemeBracketPropertyName:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
@@ -172,7 +779,7 @@ func newPath(l *lexer) (*Path, error) {
 		childNames = strings.TrimSpace(childNames)
 		return propertyNameBracketChildThen(childNames, subPath), nil
 	case lexemeArraySubscriptPropertyName:
-		subPath, err := newPath(l)
+		subPath, err := newPath(l, o)
 		if err != nil {
 			return nil, err
 		}
@@ -183,51 +790,68 @@ func newPath(l *lexer) (*Path, error) {
 	return nil, errors.New("invalid path syntax")
 }
 
-func identity(node, root *yaml.Node) yit.Iterator {
+func identity(node, root *yaml.Node, e *evaluation) yit.Iterator {
 	if node.Kind == 0 {
 		return yit.FromNodes()
 	}
 	return yit.FromNode(node)
 }
 
-func empty(node, root *yaml.Node) yit.Iterator {
+func empty(node, root *yaml.Node, e *evaluation) yit.Iterator {
 	return yit.FromNodes()
 }
 
-func compose(i yit.Iterator, p *Path, root *yaml.Node) yit.Iterator {
-	its := []yit.Iterator{}
-	for a, ok := i(); ok; a, ok = i() {
-		its = append(its, p.f(a, root))
+// compose returns an iterator over the matches of the given Path applied to each node of the given iterator in turn.
+// The Path is applied to a node only when the matches for the preceding nodes have been consumed, so that a caller
+// which stops consuming the matches early, such as FindNth, does not apply the Path to the remaining nodes.
+func compose(i yit.Iterator, p *Path, root *yaml.Node, e *evaluation) yit.Iterator {
+	var current yit.Iterator
+	done := false
+	return func() (*yaml.Node, bool) {
+		for !done {
+			if current != nil {
+				if n, ok := current(); ok {
+					return n, true
+				}
+				current = nil
+			}
+			a, ok := i()
+			if !ok || !e.step() {
+				done = true
+				break
+			}
+			current = p.f(a, root, e)
+		}
+		return nil, false
 	}
-	return yit.FromIterators(its...)
 }
 
-func new(f func(node, root *yaml.Node) yit.Iterator) *Path {
+func new(f func(node, root *yaml.Node, e *evaluation) yit.Iterator) *Path {
 	return &Path{f: f}
 }
 
 func propertyNameChildThen(childName string, p *Path) *Path {
 	childName = unescape(childName)
 
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		if node.Kind != yaml.MappingNode {
-			return empty(node, root)
+			return empty(node, root, e)
 		}
 		for i, n := range node.Content {
 			if i%2 == 0 && n.Value == childName {
-				return compose(yit.FromNode(node.Content[i]), p, root)
+				return compose(yit.FromNode(node.Content[i]), p, root, e)
 			}
 		}
-		return empty(node, root)
+		return empty(node, root, e)
 	})
 }
 
 func propertyNameBracketChildThen(childNames string, p *Path) *Path {
 	unquotedChildren := bracketChildNames(childNames)
 
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		if node.Kind != yaml.MappingNode {
-			return empty(node, root)
+			return empty(node, root, e)
 		}
 		its := []yit.Iterator{}
 		for _, childName := range unquotedChildren {
@@ -237,23 +861,23 @@ func propertyNameBracketChildThen(childNames string, p *Path) *Path {
 				}
 			}
 		}
-		return compose(yit.FromIterators(its...), p, root)
+		return compose(yit.FromIterators(its...), p, root, e)
 	})
 }
 
 func propertyNameArraySubscriptThen(subscript string, p *Path) *Path {
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		if node.Kind == yaml.MappingNode && subscript == "*" {
 			its := []yit.Iterator{}
 			for i, n := range node.Content {
 				if i%2 != 0 {
 					continue // skip child values
 				}
-				its = append(its, compose(yit.FromNode(n), p, root))
+				its = append(its, compose(yit.FromNode(n), p, root, e))
 			}
 			return yit.FromIterators(its...)
 		}
-		return empty(node, root)
+		return empty(node, root, e)
 	})
 }
 
@@ -263,16 +887,33 @@ func childThen(childName string, p *Path) *Path {
 	}
 	childName = unescape(childName)
 
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		if node.Kind != yaml.MappingNode {
-			return empty(node, root)
+			return empty(node, root, e)
 		}
 		for i, n := range node.Content {
 			if i%2 == 0 && n.Value == childName {
-				return compose(yit.FromNode(node.Content[i+1]), p, root)
+				return compose(yit.FromNode(node.Content[i+1]), p, root, e)
+			}
+		}
+		return empty(node, root, e)
+	})
+}
+
+// keyRegularExpressionThen returns a Path which applies the given Path to each value of a mapping whose key matches
+// the given regular expression, in the order of the mapping.
+func keyRegularExpressionThen(re *regexp.Regexp, p *Path) *Path {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		if node.Kind != yaml.MappingNode {
+			return empty(node, root, e)
+		}
+		its := []yit.Iterator{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if re.MatchString(node.Content[i].Value) {
+				its = append(its, compose(yit.FromNode(node.Content[i+1]), p, root, e))
 			}
 		}
-		return empty(node, root)
+		return yit.FromIterators(its...)
 	})
 }
 
@@ -338,9 +979,9 @@ func balanced(c string, q rune) bool {
 func bracketChildThen(childNames string, p *Path) *Path {
 	unquotedChildren := bracketChildNames(childNames)
 
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		if node.Kind != yaml.MappingNode {
-			return empty(node, root)
+			return empty(node, root, e)
 		}
 		its := []yit.Iterator{}
 		for _, childName := range unquotedChildren {
@@ -350,7 +991,7 @@ func bracketChildThen(childNames string, p *Path) *Path {
 				}
 			}
 		}
-		return compose(yit.FromIterators(its...), p, root)
+		return compose(yit.FromIterators(its...), p, root, e)
 	})
 }
 
@@ -374,8 +1015,79 @@ func unescape(raw string) string {
 	return esc
 }
 
+// wildcardThen returns the given Path for a wildcard, such as `.*` or `[*]`, followed by the given subpath or, with
+// WithScalarWildcardSelf, a Path which instead applies the subpath to a scalar node itself.
+func wildcardThen(wildcard, p *Path, o *options) *Path {
+	if !o.scalarWildcardSelf {
+		return wildcard
+	}
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		if node.Kind == yaml.ScalarNode {
+			return compose(yit.FromNode(node), p, root, e)
+		}
+		return wildcard.f(node, root, e)
+	})
+}
+
+// typeCheckThen returns the given Path for a segment, such as `.name` or `[0]`, or, with WithStrictTypes, a Path which
+// instead fails the evaluation if the segment is applied to a node which the given function does not accept.
+func typeCheckThen(segment *Path, text string, accept func(node *yaml.Node) (bool, string), o *options) *Path {
+	if !o.strictTypes {
+		return segment
+	}
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		if ok, expected := accept(node); !ok {
+			e.fail(fmt.Errorf("%w: %s requires %s but was applied to %s node%s", ErrTypeMismatch, text, expected, kindOf(node).withArticle(), positionOf(node)))
+			return empty(node, root, e)
+		}
+		return segment.f(node, root, e)
+	})
+}
+
+// positionOf returns the position of the given node in its source, such as " at line 2, column 9", or "" if the node
+// was not parsed from source.
+func positionOf(node *yaml.Node) string {
+	if node.Line > 0 {
+		return fmt.Sprintf(" at line %d, column %d", node.Line, node.Column)
+	}
+	return ""
+}
+
+// isMapping accepts a mapping node, to which a child may be applied. See typeCheckThen.
+func isMapping(node *yaml.Node) (bool, string) {
+	return node.Kind == yaml.MappingNode, "a mapping"
+}
+
+// isSequence accepts a sequence node, whose first or last element may be selected. See typeCheckThen.
+func isSequence(node *yaml.Node) (bool, string) {
+	return node.Kind == yaml.SequenceNode, "a sequence"
+}
+
+// isIndexable returns a function which accepts a node to which the given array subscript may be applied, that is a
+// sequence or, if the subscript is a single index, a mapping. See typeCheckThen.
+func isIndexable(subscript string) func(node *yaml.Node) (bool, string) {
+	if _, err := strconv.Atoi(strings.TrimSpace(subscript)); err == nil {
+		return func(node *yaml.Node) (bool, string) {
+			return node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode, "a sequence or a mapping"
+		}
+	}
+	return func(node *yaml.Node) (bool, string) {
+		return node.Kind == yaml.SequenceNode, "a sequence"
+	}
+}
+
+// lenient returns the given options without WithStrictTypes.
+func lenient(o *options) *options {
+	if !o.strictTypes {
+		return o
+	}
+	l := *o
+	l.strictTypes = false
+	return &l
+}
+
 func allChildrenThen(p *Path) *Path {
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		switch node.Kind {
 		case yaml.MappingNode:
 			its := []yit.Iterator{}
@@ -383,37 +1095,63 @@ func allChildrenThen(p *Path) *Path {
 				if i%2 == 0 {
 					continue // skip child names
 				}
-				its = append(its, compose(yit.FromNode(n), p, root))
+				its = append(its, compose(yit.FromNode(n), p, root, e))
 			}
 			return yit.FromIterators(its...)
 
 		case yaml.SequenceNode:
 			its := []yit.Iterator{}
 			for i := 0; i < len(node.Content); i++ {
-				its = append(its, compose(yit.FromNode(node.Content[i]), p, root))
+				its = append(its, compose(yit.FromNode(node.Content[i]), p, root, e))
 			}
 			return yit.FromIterators(its...)
 
 		default:
-			return empty(node, root)
+			return empty(node, root, e)
 		}
 	})
 }
 
+// firstOrLastElementThen returns a Path which applies the given Path to the first element or, if last is true, the
+// last element of a sequence. It matches nothing if the node is an empty sequence or is not a sequence.
+func firstOrLastElementThen(last bool, p *Path) *Path {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+			return empty(node, root, e)
+		}
+		element := node.Content[0]
+		if last {
+			element = node.Content[len(node.Content)-1]
+		}
+		return compose(yit.FromNode(element), p, root, e)
+	})
+}
+
 func arraySubscriptThen(subscript string, p *Path) *Path {
-	return new(func(node, root *yaml.Node) yit.Iterator {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		if node.Kind == yaml.MappingNode && subscript == "*" {
 			its := []yit.Iterator{}
 			for i, n := range node.Content {
 				if i%2 == 0 {
 					continue // skip child names
 				}
-				its = append(its, compose(yit.FromNode(n), p, root))
+				its = append(its, compose(yit.FromNode(n), p, root, e))
+			}
+			return yit.FromIterators(its...)
+		}
+		if node.Kind == yaml.MappingNode {
+			index, err := strconv.Atoi(strings.TrimSpace(subscript))
+			if err != nil {
+				return empty(node, root, e) // not a single index
+			}
+			its := []yit.Iterator{}
+			for _, n := range indexOfMapping(node, subscript, index, e) {
+				its = append(its, compose(yit.FromNode(n), p, root, e))
 			}
 			return yit.FromIterators(its...)
 		}
 		if node.Kind != yaml.SequenceNode {
-			return empty(node, root)
+			return empty(node, root, e)
 		}
 
 		slice, err := slice(subscript, len(node.Content))
@@ -424,39 +1162,92 @@ func arraySubscriptThen(subscript string, p *Path) *Path {
 		its := []yit.Iterator{}
 		for _, s := range slice {
 			if s >= 0 && s < len(node.Content) {
-				its = append(its, compose(yit.FromNode(node.Content[s]), p, root))
+				its = append(its, compose(yit.FromNode(node.Content[s]), p, root, e))
 			}
 		}
 		return yit.FromIterators(its...)
 	})
 }
 
-func filterThen(filterLexemes []lexeme, p *Path) *Path {
-	filter := newFilter(newFilterNode(filterLexemes))
-	return new(func(node, root *yaml.Node) yit.Iterator {
+// indexOfMapping returns the values of the given mapping node whose keys are integers equal to the given index, which
+// is the value of the given subscript, warning if there are none. See WarningIndexOfMapping.
+func indexOfMapping(node *yaml.Node, subscript string, index int, e *evaluation) []*yaml.Node {
+	values := integerKeyValues(node, index)
+	if len(values) == 0 {
+		e.warn(Warning{
+			Kind: WarningIndexOfMapping,
+			Message: fmt.Sprintf("array index [%s] applied to the mapping%s matches only integer keys; use ['%d'] to select the string key '%d'",
+				subscript, positionOf(node), index, index),
+		})
+	}
+	return values
+}
+
+// integerKeyValues returns the values of the given mapping node whose keys are integers equal to the given index, so
+// that a single array index, such as `[1]`, selects the value with the key `1` in a mapping.
+func integerKeyValues(node *yaml.Node, index int) []*yaml.Node {
+	values := []*yaml.Node{}
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Kind != yaml.ScalarNode || key.ShortTag() != intTag {
+			continue
+		}
+		var k int
+		if err := key.Decode(&k); err == nil && k == index {
+			values = append(values, node.Content[i+1])
+		}
+	}
+	return values
+}
+
+// tagThen selects the nodes with the given tag, such as `!!int` or `!custom`. Implicit tags, such as the `!!int` of an
+// unquoted `1`, are resolved before matching.
+func tagThen(tag string, p *Path) *Path {
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		if node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode || node.ShortTag() != tag {
+			return empty(node, root, e)
+		}
+		return compose(yit.FromNode(node), p, root, e)
+	})
+}
+
+func filterThen(filterLexemes []lexeme, p *Path, o *options) *Path {
+	filter := newFilter(newFilterNode(filterLexemes), o)
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		its := []yit.Iterator{}
 		if node.Kind == yaml.SequenceNode {
-			for _, c := range node.Content {
-				if filter(c, root) {
-					its = append(its, compose(yit.FromNode(c), p, root))
+			for i, c := range node.Content {
+				if filterElement(filter, c, i, root, e) {
+					its = append(its, compose(yit.FromNode(c), p, root, e))
 				}
 			}
 		} else {
-			if filter(node, root) {
-				its = append(its, compose(yit.FromNode(node), p, root))
+			if filter(node, root, e) {
+				its = append(its, compose(yit.FromNode(node), p, root, e))
 			}
 		}
 		return yit.FromIterators(its...)
 	})
 }
 
-func recursiveFilterThen(filterLexemes []lexeme, p *Path) *Path {
-	filter := newFilter(newFilterNode(filterLexemes))
-	return new(func(node, root *yaml.Node) yit.Iterator {
+// filterElement applies the given filter to the element of a sequence with the given index, which is the value of
+// @index while the filter is applied.
+func filterElement(f filter, element *yaml.Node, index int, root *yaml.Node, e *evaluation) bool {
+	saved := e.index
+	e.index = index
+	defer func() {
+		e.index = saved
+	}()
+	return f(element, root, e)
+}
+
+func recursiveFilterThen(filterLexemes []lexeme, p *Path, o *options) *Path {
+	filter := newFilter(newFilterNode(filterLexemes), o)
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
 		its := []yit.Iterator{}
 
-		if filter(node, root) {
-			its = append(its, compose(yit.FromNode(node), p, root))
+		if filter(node, root, e) {
+			its = append(its, compose(yit.FromNode(node), p, root, e))
 		}
 		return yit.FromIterators(its...)
 	})
@@ -8,7 +8,12 @@ package yamlpath_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
@@ -633,6 +638,15 @@ feather duster:
 			},
 			expectedPathErr: "",
 		},
+		{
+			name: "array subscript wildcard on mapping",
+			path: "$.store.bicycle[*]",
+			expectedStrings: []string{
+				"red\n",
+				"19.95\n",
+			},
+			expectedPathErr: "",
+		},
 		{
 			name: "array subscript wildcard",
 			path: "$.store.book[*]",
@@ -941,6 +955,18 @@ price: 22.99
 `},
 			expectedPathErr: "",
 		},
+		{
+			name:            "filter involving root paths with indices on both sides",
+			path:            "$.store.book[?(@.author==$.store.book[-1].author || $.store.book[0].price==@.price)].title",
+			expectedStrings: []string{"Sayings of the Century\n", "The Lord of the Rings\n"},
+			expectedPathErr: "",
+		},
+		{
+			name:            "filter involving root path with recursive descent",
+			path:            "$.store.book[?(@.price > $..bicycle.price)].title",
+			expectedStrings: []string{"The Lord of the Rings\n"},
+			expectedPathErr: "",
+		},
 		{
 			name: "nested filter (edge case)",
 			path: "$.x[?(@.y[?(@.z==1)].w==2)]",
@@ -1134,12 +1160,632 @@ another: entry`,
 			path:            `$[?(@>=42)]`,
 			expectedStrings: []string{"42\n", "100\n"},
 		},
+		{
+			name:            "filter involving bare current node",
+			input:           `[1,2,3,4]`,
+			path:            `$[?(@>2)]`,
+			expectedStrings: []string{"3\n", "4\n"},
+		},
+		{
+			name:            "filter involving bare current node on right hand side",
+			input:           `[1,2,3,4]`,
+			path:            `$[?( 2 < @ )]`,
+			expectedStrings: []string{"3\n", "4\n"},
+		},
+		{
+			name:            "filter involving bare current node of mixed sequence",
+			input:           `[1,{"a": 3},[4],"5",6]`,
+			path:            `$[?(@>2)]`,
+			expectedStrings: []string{"\"5\"\n", "6\n"},
+		},
+		{
+			name:  "filter comparing bare current node with itself",
+			input: `[1,{"a": 3},[4],"x"]`,
+			path:  `$[?(@==@)]`,
+			expectedStrings: []string{"1\n", `{"a": 3}
+`, "[4]\n", `"x"
+`},
+		},
+		{
+			name:            "filter comparing bare current node with itself for inequality",
+			input:           `[1,{"a": 3},[4],"x"]`,
+			path:            `$[?(@!=@)]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter comparing mappings",
+			input:           `[{"a": {"x": 1}, "b": {"x": 1}}, {"a": {"x": 1}, "b": {"x": 2}}, {"a": [1], "b": {"x": 1}}]`,
+			path:            `$[?(@.a!=@.b)].b`,
+			expectedStrings: []string{"{\"x\": 2}\n", "{\"x\": 1}\n"},
+		},
+		{
+			name:            "filter involving bare current node of nested sequences",
+			input:           `[[1,5],[2],[3,0]]`,
+			path:            `$[*][?(@>2)]`,
+			expectedStrings: []string{"5\n", "3\n"},
+		},
+		{
+			name:            "filter with index in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[0]=='first')].id`,
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "filter with negative index in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[-1]=='last')].id`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter with wildcard in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[*]=='first')].id`,
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "filter with slice in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[1:]=='first')].id`,
+			expectedStrings: []string{"2\n", "3\n"},
+		},
+		{
+			name:            "filter with union of indices in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[0,1]=='first')].id`,
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "filter with missing index in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[2]!='last')].id`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter with index existence in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@.items[1])].id`,
+			expectedStrings: []string{"1\n", "2\n", "3\n"},
+		},
+		{
+			name:            "filter with index after bracket child in relative path",
+			input:           `[{"id": 1, "items": ["first", "second", "last"]}, {"id": 2, "items": ["x", "first"]}, {"id": 3, "items": ["first", "first"]}, {"id": 4, "items": []}, {"id": 5}]`,
+			path:            `$[?(@['items'][0]=='x')].id`,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "filter with scientific notation float",
+			input:           `[{"n": 1, "t": 2000000}, {"n": 2, "t": 1.5e+3}, {"n": 3, "t": 0.002}]`,
+			path:            `$[?(@.t>1e6 || @.t<2.5E-3)].n`,
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "index of mapping with integer keys",
+			input:           `{1: a, 2: b, "1": c, -1: d}`,
+			path:            `$[1]`,
+			expectedStrings: []string{"a\n"},
+		},
+		{
+			name:            "negative index of mapping with integer keys",
+			input:           `{1: a, 2: b, "1": c, -1: d}`,
+			path:            `$[-1]`,
+			expectedStrings: []string{"d\n"},
+		},
+		{
+			name:            "index of mapping with hexadecimal integer key",
+			input:           `{0x10: a, 10: b}`,
+			path:            `$[16]`,
+			expectedStrings: []string{"a\n"},
+		},
+		{
+			name:            "index of mapping with no such integer key",
+			input:           `{1: a, "2": b}`,
+			path:            `$[2]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "quoted child of mapping with integer and string keys",
+			input:           `{1: a, "1": c}`,
+			path:            `$['1']`,
+			expectedStrings: []string{"a\n", "c\n"},
+		},
+		{
+			name:            "slice of mapping with integer keys",
+			input:           `{0: a, 1: b}`,
+			path:            `$[0:2]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "index of nested mapping and sequence",
+			input:           `{200: [x, y], 404: [z]}`,
+			path:            `$[200][1]`,
+			expectedStrings: []string{"y\n"},
+		},
+		{
+			name:            "children of mapping with boolean keys",
+			input:           `{true: a, false: b}`,
+			path:            `$['false','true']`,
+			expectedStrings: []string{"b\n", "a\n"},
+		},
+		{
+			name:            "dot child of mapping with boolean key",
+			input:           `{true: a, false: b}`,
+			path:            `$.true`,
+			expectedStrings: []string{"a\n"},
+		},
+		{
+			name:            "filter comparing child with field of grandparent",
+			input:           `{"order": {"total": 5, "items": [{"id": 1, "value": 5}, {"id": 2, "value": 3}]}}`,
+			path:            `$.order.items[?(@^^.total == @.value)].id`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter comparing child with sibling element",
+			input:           `{"items": [{"id": 1, "value": 3}, {"id": 2, "value": 3}, {"id": 3, "value": 4}]}`,
+			path:            `$.items[?(@^[0].value == @.value)].id`,
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "filter comparing child of mapping with field of parent",
+			input:           `{"limit": 2, "a": {"n": 1}, "b": {"n": 3}}`,
+			path:            `$.*[?(@.n < @^.limit)].n`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter on parent beyond root",
+			input:           `{"items": [1, 2]}`,
+			path:            `$.items[?(@^^^^)]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter on parent in nested filter",
+			input:           `[{"max": 2, "xs": [1, 2, 3]}, {"max": 5, "xs": [1]}]`,
+			path:            `$[?(@.xs[?(@ > @^^.max)])].max`,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "filter case-insensitive equality",
+			input:           `[{"id": 1, "status": "Active"}, {"id": 2, "status": "ACTIVE"}, {"id": 3, "status": "inactive"}, {"id": 4, "status": "active "}, {"id": 5, "status": "active"}]`,
+			path:            `$[?(@.status==~'active')].id`,
+			expectedStrings: []string{"1\n", "2\n", "5\n"},
+		},
+		{
+			name:            "filter case-insensitive equality with unicode case folding",
+			input:           `[STRASSE, Ǆ, ǆ, Σ, σ, x]`,
+			path:            `$[?('ǅ' ==~ @ || @ ==~ 'ς')]`,
+			expectedStrings: []string{"Ǆ\n", "ǆ\n", "Σ\n", "σ\n"},
+		},
+		{
+			name:            "filter case-insensitive equality of non-strings",
+			input:           `[1, "1", true, "True"]`,
+			path:            `$[?(@ ==~ 1 || @ ==~ true)]`,
+			expectedStrings: []string{"1\n", "true\n"},
+		},
+		{
+			name:            "filter negated case-insensitive equality",
+			input:           `[a, A, b]`,
+			path:            `$[?(!(@ ==~ 'a'))]`,
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "filter string literal with unicode escape",
+			input:           `[{"id": 1, "name": "école"}, {"id": 2, "name": "ecole"}]`,
+			path:            `$[?(@.name=='\u00e9cole')].id`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter string literal with hexadecimal escape",
+			input:           `[{"id": 1, "name": "école"}, {"id": 2, "name": "ecole"}]`,
+			path:            `$[?(@.name=="\xe9cole")].id`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter string literal with escaped quote and backslash",
+			input:           `[{"id": 1, "name": "it's"}, {"id": 2, "name": "a\\b"}, {"id": 3, "name": "its"}]`,
+			path:            `$[?(@.name=='it\'s' || @.name=='a\\b')].id`,
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "filter string literal with control character escapes",
+			input:           `[{"id": 1, "name": "a\tb\nc"}, {"id": 2, "name": "a b c"}]`,
+			path:            `$[?(@.name=='a\tb\nc')].id`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "implicit tag",
+			input:           `{"a": 1, "b": "1", "c": !MyTag x, "d": [2, !!str 3, 2001-12-14], "e": {"f": !MyTag {"g": 4}}}`,
+			path:            `$..<!!int>`,
+			expectedStrings: []string{"1\n", "2\n", "4\n"},
+		},
+		{
+			name:            "string tag including mapping keys",
+			input:           `{"a": 1, "b": "1", "d": [2, !!str 3]}`,
+			path:            `$..<!!str>`,
+			expectedStrings: []string{"\"a\"\n", "\"b\"\n", "\"1\"\n", "\"d\"\n", "!!str 3\n"},
+		},
+		{
+			name:            "string tag excluding mapping keys",
+			input:           `{"a": 1, "b": "1", "d": [2, !!str 3]}`,
+			path:            `$..*<!!str>`,
+			expectedStrings: []string{"\"1\"\n", "!!str 3\n"},
+		},
+		{
+			name:            "timestamp tag",
+			input:           `{"a": 1, "d": [2, 2001-12-14, "2001-12-14"]}`,
+			path:            `$..<!!timestamp>`,
+			expectedStrings: []string{"2001-12-14\n"},
+		},
+		{
+			name:            "custom tag",
+			input:           `{"a": 1, "c": !MyTag x, "e": {"f": !MyTag {"g": 4}}}`,
+			path:            `$..<!MyTag>`,
+			expectedStrings: []string{"!MyTag x\n", "!MyTag {\"g\": 4}\n"},
+		},
+		{
+			name:            "custom tag followed by child",
+			input:           `{"a": {"g": 1}, "e": {"f": !MyTag {"g": 4}}}`,
+			path:            `$..<!MyTag>.g`,
+			expectedStrings: []string{"4\n"},
+		},
+		{
+			name:            "tag of child",
+			input:           `{"a": 1, "b": "1"}`,
+			path:            `$.a<!!int>`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "tag of child not matching",
+			input:           `{"a": 1, "b": "1"}`,
+			path:            `$.b<!!int>`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter on tag",
+			input:           `[{"id": 1, "v": 1}, {"id": 2, "v": "1"}, {"id": 3, "v": !MyTag 1}]`,
+			path:            `$[?(@.v<!!int> || @.v<!MyTag>)].id`,
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "filter on tag of current node",
+			input:           `[1, "2", 3]`,
+			path:            `$[?(@<!!int> > 1)]`,
+			expectedStrings: []string{"3\n"},
+		},
+		{
+			name:            "filter on even index",
+			input:           `[a, b, c, d, e]`,
+			path:            `$[?(@index % 2 == 0)]`,
+			expectedStrings: []string{"a\n", "c\n", "e\n"},
+		},
+		{
+			name:            "filter on odd index",
+			input:           `[a, b, c, d, e]`,
+			path:            `$[?(1 == @index%2)]`,
+			expectedStrings: []string{"b\n", "d\n"},
+		},
+		{
+			name:            "filter on index range",
+			input:           `[a, b, c, d, e]`,
+			path:            `$[?(@index < 2 || @index >= 4)]`,
+			expectedStrings: []string{"a\n", "b\n", "e\n"},
+		},
+		{
+			name:            "filter on index in nested filter",
+			input:           `[{"n": 1, "x": [a, b]}, {"n": 2, "x": [c]}]`,
+			path:            `$[?(@.x[?(@index == 1)])].n`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter on index of mapping",
+			input:           `{"a": 1, "b": 2}`,
+			path:            `$[?(@index == 0)]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter on empty string",
+			input:           `[{n: 1, name: ""}, {n: 2, name: a}, {n: 3, name: ''}, {n: 4}, {n: 5, name: }]`,
+			path:            `$[?(@.name=='')].n`,
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "filter on empty collection",
+			input:           `[{n: 1, items: []}, {n: 2, items: [a]}, {n: 3, items: {}}, {n: 4, items: ""}, {n: 5}]`,
+			path:            `$[?(length(@.items)==0)].n`,
+			expectedStrings: []string{"1\n", "3\n", "4\n"},
+		},
+		{
+			name:            "keys matching regular expression",
+			input:           `{config: {feature_a: 1, other: 2, feature_b: {on: true}, x_feature_c: 3}}`,
+			path:            `$.config.~/^feature_/`,
+			expectedStrings: []string{"1\n", "{on: true}\n"},
+		},
+		{
+			name:            "keys matching regular expression followed by child",
+			input:           `{config: {feature_a: {on: false}, other: {on: true}, feature_b: {on: true}}}`,
+			path:            `$.config.~/^feature_/.on`,
+			expectedStrings: []string{"false\n", "true\n"},
+		},
+		{
+			name:            "keys matching regular expression containing dot and slash",
+			input:           `{a.b: 1, a/b: 2, ab: 3}`,
+			path:            `$.~/^a[./]b$/`,
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "keys matching regular expression with flag",
+			input:           `{Feature_a: 1, feature_b: 2, other: 3}`,
+			path:            `$.~/^feature_/i`,
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "keys matching regular expression applied to sequence",
+			input:           `[{feature_a: 1}]`,
+			path:            `$.~/^feature_/`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter on keys matching regular expression",
+			input:           `[{feature_a: 1, b: 2}, {feature_a: 2, b: 1}]`,
+			path:            `$[?(@.~/^feature_/ == 1)].b`,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "first element",
+			input:           `{items: [a, b, c], empty: [], mapping: {first: x}, scalar: first}`,
+			path:            `$.items.first()`,
+			expectedStrings: []string{"a\n"},
+		},
+		{
+			name:            "last element",
+			input:           `{items: [a, b, c]}`,
+			path:            `$.items.last()`,
+			expectedStrings: []string{"c\n"},
+		},
+		{
+			name:            "first element of empty sequence",
+			input:           `{items: []}`,
+			path:            `$.items.first()`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "last element of non-sequences",
+			input:           `{items: {first: x, last: y, 0: z}, scalar: last}`,
+			path:            `$.*.last()`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "last element followed by child",
+			input:           `{items: [{a: 1}, {a: 2}]}`,
+			path:            `$.items.last().a`,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "first element in filter",
+			input:           `[[1, 2], [3, 1], [], [1]]`,
+			path:            `$[?(@.first() == 1)]`,
+			expectedStrings: []string{"[1, 2]\n", "[1]\n"},
+		},
+		{
+			name:            "filter negating root path, path present",
+			input:           `{globalDisabled: true, items: [{a: 1}, {b: 2}]}`,
+			path:            `$.items[?(!($.globalDisabled))]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter negating root path, path absent",
+			input:           `{items: [{a: 1}, {b: 2}]}`,
+			path:            `$.items[?(!($.globalDisabled))]`,
+			expectedStrings: []string{"{a: 1}\n", "{b: 2}\n"},
+		},
+		{
+			name:            "filter negating root path, path false",
+			input:           `{globalDisabled: false, items: [{a: 1}, {b: 2}]}`,
+			path:            `$.items[?(!($.globalDisabled))]`,
+			expectedStrings: []string{"{a: 1}\n", "{b: 2}\n"},
+		},
+		{
+			name:            "root existence filter, path false",
+			input:           `{off: false, items: [{off: false}]}`,
+			path:            `$.items[?($.off)]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "relative existence filter, path false",
+			input:           `{off: false, items: [{off: false}]}`,
+			path:            `$.items[?(@.off)]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "parent existence filter, path false",
+			input:           `{off: false, items: [{off: false}]}`,
+			path:            `$.items[?(@^^.off)]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "negated root existence filter, path false",
+			input:           `{off: false, items: [{off: false}]}`,
+			path:            `$.items[?(!$.off)]`,
+			expectedStrings: []string{"{off: false}\n"},
+		},
+		{
+			name:            "negated relative existence filter, path false",
+			input:           `{off: false, items: [{off: false}]}`,
+			path:            `$.items[?(!@.off)]`,
+			expectedStrings: []string{"{off: false}\n"},
+		},
+		{
+			name:            "filter negating root and relative paths",
+			input:           `{items: [{a: 1}, {b: 2}]}`,
+			path:            `$.items[?(!($.globalDisabled) && !(@.a))]`,
+			expectedStrings: []string{"{b: 2}\n"},
+		},
+		{
+			name:            "filter on numeric range",
+			input:           `[{port: 80}, {port: 1023}, {port: 1024}, {port: 8080}, {port: 65535}, {port: 65536}, {name: a}]`,
+			path:            `$[?(@.port between 1024 and 65535)].port`,
+			expectedStrings: []string{"1024\n", "8080\n", "65535\n"},
+		},
+		{
+			name:            "filter on range with path bounds",
+			input:           `{limits: {min: 2, max: 4}, items: [1, 2, 3, 4, 5]}`,
+			path:            `$.items[?(@ between $.limits.min and $.limits.max)]`,
+			expectedStrings: []string{"2\n", "3\n", "4\n"},
+		},
+		{
+			name:            "filter on inequality with missing operands",
+			input:           `[{id: 1, a: 1, b: 1}, {id: 2, a: 1, b: 2}, {id: 3, a: 1}, {id: 4, b: 1}, {id: 5}]`,
+			path:            `$[?(@.a != @.b)].id`,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "filter on negated equality with missing operands",
+			input:           `[{id: 1, a: 1, b: 1}, {id: 2, a: 1, b: 2}, {id: 3, a: 1}, {id: 4, b: 1}, {id: 5}]`,
+			path:            `$[?(!(@.a == @.b))].id`,
+			expectedStrings: []string{"2\n", "3\n", "4\n", "5\n"},
+		},
+		{
+			name:            "padded path",
+			input:           `{a: {"b c": 1, b: 2}}`,
+			path:            " $.a['b c']\n",
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter comparing any descendant",
+			input:           `[{id: 1, a: {b: {name: x}}}, {id: 2, a: {name: y, b: {name: x}}}, {id: 3, a: {b: {name: y}}}, {id: 4, name: x}, {id: 5}]`,
+			path:            `$[?(@..name == 'x')].id`,
+			expectedStrings: []string{"1\n", "2\n", "4\n"},
+		},
+		{
+			name:            "filter comparing any descendant, no match",
+			input:           `[{id: 1, a: {b: {name: y}}}, {id: 2, a: [{name: z}]}, {id: 3}]`,
+			path:            `$[?(@..name == 'x')].id`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter negating comparison of any descendant",
+			input:           `[{id: 1, a: {b: {name: x}}}, {id: 2, a: {name: y, b: {name: x}}}, {id: 3, a: {b: {name: y}}}, {id: 4}]`,
+			path:            `$[?(!(@..name == 'x'))].id`,
+			expectedStrings: []string{"3\n", "4\n"},
+		},
+		{
+			name:            "filter on range of any descendant",
+			input:           `[{id: 1, a: [{v: 3}, {v: 12}]}, {id: 2, a: [{v: 3}, {v: 30}]}]`,
+			path:            `$[?(@..v between 10 and 20)].id`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "filter on key matching regular expression",
+			input:           `{"config": {"a_enabled": true, "b": true, "c_enabled": false, "d_enabled_x": true}}`,
+			path:            `$.config.*[?(@# =~ /_enabled$/)]`,
+			expectedStrings: []string{"true\n", "false\n"},
+		},
+		{
+			name:            "key regular expression selecting values of any kind",
+			input:           `{"config": {"a_enabled": true, "b_enabled": {x: 1}, "c": 2, "d_enabled": [y, z]}}`,
+			path:            `$.config.~/_enabled$/`,
+			expectedStrings: []string{"true\n", "{x: 1}\n", "[y, z]\n"},
+		},
+		{
+			name:            "filter on key and value",
+			input:           `{"config": {"a_enabled": 1, "b": 2, "c_enabled": 3, "d_enabled": 4}}`,
+			path:            `$.config.*[?(@# =~ /_enabled$/ && @ > 2)]`,
+			expectedStrings: []string{"3\n", "4\n"},
+		},
+		{
+			name:            "filter on key compared with child",
+			input:           `{users: {alice: {name: alice}, bob: {name: robert}}}`,
+			path:            `$.users.*[?(@.name == @#)].name`,
+			expectedStrings: []string{"alice\n"},
+		},
+		{
+			name:            "filter on non-string key",
+			input:           `{200: ok, 404: missing}`,
+			path:            `$.*[?(@# == '404')]`,
+			expectedStrings: []string{"missing\n"},
+		},
+		{
+			name:            "filter on key after recursive descent",
+			input:           `{"a": {"id": 1, "b": [{"id": 2}]}}`,
+			path:            `$..[?(@# == 'id')]`,
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "filter on key of mapping",
+			input:           `{"a": {"x": 1}, "b": {"x": 2}}`,
+			path:            `$.b[?(@# == 'b')].x`,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "filter on key of sequence element",
+			input:           `{"a": [x, y]}`,
+			path:            `$.a[?(@# == 'a' || @# != 'a')]`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "filter on modulo of value",
+			input:           `[{"n": 1}, {"n": 2}, {"n": 4}, {"n": "7"}, {"n": 7.0}]`,
+			path:            `$[?(@.n % 3 == 1)].n`,
+			expectedStrings: []string{"1\n", "4\n"},
+		},
 		{
 			name:            "filter with fractional float",
 			input:           `[0,-4.2,100]`,
 			path:            `$[?(@==-42E-1)]`,
 			expectedStrings: []string{"-4.2\n"},
 		},
+		{
+			name:            "regular expression filter on sequence",
+			input:           `[{"n": 1, "tags": ["dev-1", "prod-2"]}, {"n": 2, "tags": ["dev-3"]}, {"n": 3, "tags": "prod-4"}]`,
+			path:            `$[?(@.tags=~/^prod-/)].n`,
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "regular expression filter with / in character class",
+			input:           `[{"n": 1, "path": "/usr/bin"}, {"n": 2, "path": "Usr"}, {"n": 3, "path": "a/b-c"}]`,
+			path:            `$[?(@.path=~/^[/a-z]+$/)].n`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "regular expression filter with \\ and / in character class",
+			input:           `[{"n": 1, "path": "a\\b"}, {"n": 2, "path": "a/b"}, {"n": 3, "path": "ab"}]`,
+			path:            `$[?(@.path=~/^a[\\/]b$/)].n`,
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "regular expression non-match filter",
+			input:           `[{"n": 1, "name": "tmp-1"}, {"n": 2, "name": "prod-2"}, {"n": 3}, {"n": 4, "name": "prod-tmp"}]`,
+			path:            `$[?(@.name!~/^tmp/)].n`,
+			expectedStrings: []string{"2\n", "3\n", "4\n"},
+		},
+		{
+			name:            "dot children with non-ASCII names",
+			input:           `{"café": {"ключ": {"名前": 1}}}`,
+			path:            `$.café.ключ.名前`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "dot child with escaped dot",
+			input:           `{"a.b": 1, "a": {"b": 2}}`,
+			path:            `$.a\.b`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "dot child with escaped left bracket",
+			input:           `{"a[0]": 1, "a": [2]}`,
+			path:            `$.a\[0]`,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "nested existence filter",
+			input:           `[{"y": [{"z": 1}, {"w": 2}]}, {"y": [{"w": 3}]}, {"y": []}, {"x": 1}]`,
+			path:            `$[?(@.y[?(@.z)])]`,
+			expectedStrings: []string{"{\"y\": [{\"z\": 1}, {\"w\": 2}]}\n"},
+		},
+		{
+			name:            "nested comparison filter used as existence filter",
+			input:           `[{"y": [{"z": 1}, {"z": 5}]}, {"y": [{"z": 2}]}]`,
+			path:            `$[?(@.y[?(@.z>3)])]`,
+			expectedStrings: []string{"{\"y\": [{\"z\": 1}, {\"z\": 5}]}\n"},
+		},
 		{
 			name:            "filter with boolean predicate",
 			input:           `[0]`,
@@ -1202,3 +1848,4012 @@ another: entry`,
 		t.Fatalf("testcase(s) still focussed")
 	}
 }
+
+func TestFindRecursiveDescentWithTail(t *testing.T) {
+	y := `---
+spec:
+  containers:
+  - image: a
+  - name: no-image
+  template:
+    spec:
+      containers:
+      - image: b
+      - image: c
+items:
+- spec:
+    containers:
+    - image: d
+- metadata:
+    spec:
+      containers: []
+- spec:
+    containers: not-a-sequence
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		path            string
+		expectedStrings []string
+	}{
+		{
+			path:            "$..spec.containers[*].image",
+			expectedStrings: []string{"a\n", "b\n", "c\n", "d\n"},
+		},
+		{
+			path:            "$..spec.containers[0].image",
+			expectedStrings: []string{"a\n", "b\n", "d\n"},
+		},
+		{
+			path:            "$..spec['containers'][-1].image",
+			expectedStrings: []string{"c\n", "d\n"},
+		},
+		{
+			path:            "$..spec.containers[?(@.image)].image",
+			expectedStrings: []string{"a\n", "b\n", "c\n", "d\n"},
+		},
+		{
+			path:            "$.items..spec.containers[*].image",
+			expectedStrings: []string{"d\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			// each candidate of the recursive descent is distinct, so the tail produces each node at most once
+			seen := map[*yaml.Node]bool{}
+			for _, a := range actual {
+				require.False(t, seen[a], "duplicate match")
+				seen[a] = true
+			}
+		})
+	}
+}
+
+func TestFindOrError(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`a:
+  b: 1
+c: []
+`), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		path          string
+		expectedCount int
+		expectedErr   error
+	}{
+		{
+			name:          "match",
+			path:          "$.a.b",
+			expectedCount: 1,
+		},
+		{
+			name:        "no match",
+			path:        "$.a.x",
+			expectedErr: yamlpath.ErrNoMatch,
+		},
+		{
+			name:        "empty sequence",
+			path:        "$.c[*]",
+			expectedErr: yamlpath.ErrNoMatch,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.FindOrError(&n)
+			if tc.expectedErr != nil {
+				require.True(t, errors.Is(err, tc.expectedErr))
+				require.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, actual, tc.expectedCount)
+		})
+	}
+}
+
+func TestFindFirstOf(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`spec:
+  name: web
+  ports: [80, 443]
+defaults:
+  timeout: 30
+  ports: [8080]
+`), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name        string
+		paths       []string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "first path matches",
+			paths:    []string{"$.spec.name", "$.defaults.name"},
+			expected: "web\n",
+		},
+		{
+			name:     "first path misses and second matches",
+			paths:    []string{"$.spec.timeout", "$.defaults.timeout"},
+			expected: "30\n",
+		},
+		{
+			name:     "first match of several",
+			paths:    []string{"$.spec.nosuch", "$.spec.ports[*]", "$.defaults.ports[*]"},
+			expected: "80\n",
+		},
+		{
+			name:     "empty sequence is no match",
+			paths:    []string{"$.spec.ports[5:]", "$.defaults.ports[*]"},
+			expected: "8080\n",
+		},
+		{
+			name:        "no path matches",
+			paths:       []string{"$.spec.timeout", "$.defaults.name"},
+			expectedErr: yamlpath.ErrNoMatch,
+		},
+		{
+			name:        "single path which misses",
+			paths:       []string{"$.nosuch"},
+			expectedErr: yamlpath.ErrNoMatch,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			paths := []*yamlpath.Path{}
+			for _, path := range tc.paths {
+				p, err := yamlpath.NewPath(path)
+				require.NoError(t, err)
+				paths = append(paths, p)
+			}
+
+			actual, err := paths[0].FindFirstOf(&n, paths[1:]...)
+			if tc.expectedErr != nil {
+				require.True(t, errors.Is(err, tc.expectedErr))
+				require.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{tc.expected}, encodeNodes(t, []*yaml.Node{actual}))
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.nosuch")
+		require.NoError(t, err)
+		q, err := yamlpath.NewPath("$.spec[?(@.name == $x)]")
+		require.NoError(t, err)
+		r, err := yamlpath.NewPath("$.spec.name")
+		require.NoError(t, err)
+
+		_, err = p.FindFirstOf(&n, q, r)
+		require.EqualError(t, err, "no binding for $x")
+	})
+}
+
+func TestType(t *testing.T) {
+	y := `---
+scalar: x
+sequence: [1, 2]
+mapping: {a: 1}
+anchored: &anchor y
+alias: *anchor
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		path         string
+		expected     yamlpath.NodeKind
+		expectedName string
+		expectedErr  error
+	}{
+		{path: "", expected: yamlpath.DocumentKind, expectedName: "document"},
+		{path: "$", expected: yamlpath.MappingKind, expectedName: "mapping"},
+		{path: "$.scalar", expected: yamlpath.ScalarKind, expectedName: "scalar"},
+		{path: "$.sequence", expected: yamlpath.SequenceKind, expectedName: "sequence"},
+		{path: "$.sequence[*]", expected: yamlpath.ScalarKind, expectedName: "scalar"},
+		{path: "$.mapping", expected: yamlpath.MappingKind, expectedName: "mapping"},
+		{path: "$.alias", expected: yamlpath.AliasKind, expectedName: "alias"},
+		{path: "$.nosuch", expected: yamlpath.UnknownKind, expectedName: "unknown", expectedErr: yamlpath.ErrNoMatch},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			kind, err := p.Type(&n)
+			require.Equal(t, tc.expectedErr, err)
+			require.Equal(t, tc.expected, kind)
+			require.Equal(t, tc.expectedName, kind.String())
+		})
+	}
+}
+
+func TestFindValues(t *testing.T) {
+	y := `---
+mixed:
+- text
+- 42
+- 1.5
+- true
+- null
+- [a, 1]
+- {k: v, n: 2}
+- {1: one}
+- &anchor x
+- *anchor
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	p, err := yamlpath.NewPath("$.mixed[*]")
+	require.NoError(t, err)
+
+	values, err := p.FindValues(&n)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		"text",
+		42,
+		1.5,
+		true,
+		nil,
+		[]interface{}{"a", 1},
+		map[string]interface{}{"k": "v", "n": 2},
+		map[interface{}]interface{}{1: "one"},
+		"x",
+		"x",
+	}, values)
+
+	p, err = yamlpath.NewPath("$.nosuch")
+	require.NoError(t, err)
+	values, err = p.FindValues(&n)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{}, values)
+
+	p, err = yamlpath.NewPath("$.mixed[?(@==$x)]")
+	require.NoError(t, err)
+	_, err = p.FindValues(&n)
+	require.EqualError(t, err, "no binding for $x")
+}
+
+func TestFindValuesWithTimestamps(t *testing.T) {
+	y := `---
+implicit: 2023-01-02
+explicit: !!timestamp 2023-01-02
+datetime: 2023-01-02T10:30:00Z
+spaced: 2023-01-02 10:30:00.5
+explicit quoted: !!timestamp '2023-01-02'
+quoted: '2023-01-02'
+string: !!str 2023-01-02
+nested: {created: 2023-01-02, dates: [2023-01-02]}
+invalid: !!timestamp yesterday
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	date := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		path     string
+		expected interface{}
+	}{
+		{name: "implicit timestamp", path: "$.implicit", expected: date},
+		{name: "explicit timestamp", path: "$.explicit", expected: date},
+		{name: "timestamp with time", path: "$.datetime", expected: time.Date(2023, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{name: "timestamp with space", path: "$.spaced", expected: time.Date(2023, 1, 2, 10, 30, 0, 5e8, time.UTC)},
+		{name: "explicit timestamp quoted", path: "$['explicit quoted']", expected: date},
+		{name: "quoted date", path: "$.quoted", expected: "2023-01-02"},
+		{name: "date tagged as string", path: "$.string", expected: "2023-01-02"},
+		{name: "timestamps in mapping", path: "$.nested", expected: map[string]interface{}{"created": date, "dates": []interface{}{date}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			values, err := p.FindValues(&n)
+			require.NoError(t, err)
+			require.Len(t, values, 1)
+			require.IsType(t, tc.expected, values[0])
+			if expected, ok := tc.expected.(time.Time); ok {
+				require.True(t, expected.Equal(values[0].(time.Time)), "expected %v, got %v", expected, values[0])
+				return
+			}
+			require.Equal(t, tc.expected, values[0])
+		})
+	}
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.invalid")
+		require.NoError(t, err)
+		_, err = p.FindValues(&n)
+		require.EqualError(t, err, "cannot decode match at line 10, column 10: yaml: cannot decode !!str `yesterday` as a !!timestamp")
+	})
+}
+
+func TestFindScalar(t *testing.T) {
+	y := `---
+spec:
+  replicas: 3
+  name: web
+  ratio: 0.5
+  enabled: true
+  empty:
+  quoted: '3'
+  containers:
+  - image: nginx
+  labels: {app: web}
+  default: &default info
+  level: *default
+  dup: first
+  dup: second
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		path          string
+		expected      interface{}
+		expectedFound bool
+		expectedError string
+	}{
+		{name: "integer", path: "$.spec.replicas", expected: 3, expectedFound: true},
+		{name: "string", path: "$.spec.name", expected: "web", expectedFound: true},
+		{name: "float", path: "$.spec.ratio", expected: 0.5, expectedFound: true},
+		{name: "boolean", path: "$.spec.enabled", expected: true, expectedFound: true},
+		{name: "null", path: "$.spec.empty", expected: nil, expectedFound: true},
+		{name: "quoted number", path: "$.spec.quoted", expected: "3", expectedFound: true},
+		{name: "element of sequence", path: "$.spec.containers[0].image", expected: "nginx", expectedFound: true},
+		{name: "alias", path: "$.spec.level", expected: "info", expectedFound: true},
+		{name: "duplicate keys", path: "$.spec.dup", expected: "first", expectedFound: true},
+		{name: "implicit root", path: "spec.name", expected: "web", expectedFound: true},
+		{name: "missing child", path: "$.spec.nosuch", expectedFound: false},
+		{name: "missing element", path: "$.spec.containers[1].image", expectedFound: false},
+		{name: "child of scalar", path: "$.spec.name.first", expectedFound: false},
+		{
+			name:          "mapping",
+			path:          "$.spec.labels",
+			expectedError: `node type mismatch: "$.spec.labels" requires a scalar but matched a mapping node at line 11, column 11`,
+		},
+		{
+			name:          "sequence",
+			path:          "$.spec.containers",
+			expectedError: `node type mismatch: "$.spec.containers" requires a scalar but matched a sequence node at line 10, column 3`,
+		},
+		{
+			name:          "wildcard",
+			path:          "$.spec.*",
+			expectedError: `path is not singular: "$.spec.*" may match more than one node`,
+		},
+		{
+			name:          "filter",
+			path:          "$.spec.containers[?(@.image)].image",
+			expectedError: `path is not singular: "$.spec.containers[?(@.image)].image" may match more than one node`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			value, found, err := p.FindScalar(&n)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				require.False(t, found)
+				require.Nil(t, value)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expected, value)
+		})
+	}
+
+	t.Run("errors are typed", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.spec.labels")
+		require.NoError(t, err)
+		_, _, err = p.FindScalar(&n)
+		require.True(t, errors.Is(err, yamlpath.ErrTypeMismatch))
+
+		p, err = yamlpath.NewPath("$..name")
+		require.NoError(t, err)
+		_, _, err = p.FindScalar(&n)
+		require.True(t, errors.Is(err, yamlpath.ErrNotSingular))
+	})
+}
+
+func TestFindSortedBy(t *testing.T) {
+	y := `---
+items:
+- {name: cherry, priority: 2}
+- {name: apple, priority: 10}
+- {name: banana}
+- {name: date, priority: 2.5}
+- {name: elderberry, priority: 2}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		subpath       string
+		ascending     bool
+		expectedNames []string
+	}{
+		{
+			name:          "numeric ascending",
+			subpath:       ".priority",
+			ascending:     true,
+			expectedNames: []string{"cherry", "elderberry", "date", "apple", "banana"},
+		},
+		{
+			name:          "numeric descending",
+			subpath:       "priority",
+			ascending:     false,
+			expectedNames: []string{"apple", "date", "cherry", "elderberry", "banana"},
+		},
+		{
+			name:          "string ascending",
+			subpath:       "$.name",
+			ascending:     true,
+			expectedNames: []string{"apple", "banana", "cherry", "date", "elderberry"},
+		},
+		{
+			name:          "string descending",
+			subpath:       "name",
+			ascending:     false,
+			expectedNames: []string{"elderberry", "date", "cherry", "banana", "apple"},
+		},
+		{
+			name:          "missing key",
+			subpath:       "nosuch",
+			ascending:     true,
+			expectedNames: []string{"cherry", "apple", "banana", "date", "elderberry"},
+		},
+	}
+
+	p, err := yamlpath.NewPath("$.items[*]")
+	require.NoError(t, err)
+	name, err := yamlpath.NewPath("name")
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := p.FindSortedBy(&n, tc.subpath, tc.ascending)
+			require.NoError(t, err)
+			names := []string{}
+			for _, a := range actual {
+				nameNodes, err := name.Find(a)
+				require.NoError(t, err)
+				names = append(names, nameNodes[0].Value)
+			}
+			require.Equal(t, tc.expectedNames, names)
+		})
+	}
+
+	t.Run("numbers before strings", func(t *testing.T) {
+		var m yaml.Node
+		err := yaml.Unmarshal([]byte(`[{k: b}, {k: [1]}, {k: 3}, {k: a}, {k: 1}]`), &m)
+		require.NoError(t, err)
+		p, err := yamlpath.NewPath("$[*]")
+		require.NoError(t, err)
+
+		actual, err := p.FindSortedBy(&m, "k", true)
+		require.NoError(t, err)
+		require.Equal(t, []string{"{k: 1}\n", "{k: 3}\n", "{k: a}\n", "{k: b}\n", "{k: [1]}\n"}, encodeNodes(t, actual))
+
+		actual, err = p.FindSortedBy(&m, "k", false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"{k: b}\n", "{k: a}\n", "{k: 3}\n", "{k: 1}\n", "{k: [1]}\n"}, encodeNodes(t, actual))
+	})
+
+	t.Run("invalid subpath", func(t *testing.T) {
+		_, err := p.FindSortedBy(&n, "[", true)
+		require.EqualError(t, err, `invalid sort subpath "[": unmatched [ at position 1, following "["`)
+	})
+}
+
+func TestFindFunc(t *testing.T) {
+	y := `---
+deployments:
+- name: web
+  containers:
+  - {image: nginx, ports: [80, 443]}
+  - {image: sidecar}
+- name: worker
+  containers:
+  - {image: worker, ports: [8080]}
+- name: cron
+  containers: []
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	// the number of ports of all the containers of a deployment
+	ports := func(d *yaml.Node) int {
+		p, err := yamlpath.NewPath("$.containers[*].ports[*]")
+		require.NoError(t, err)
+		ports, err := p.Find(d)
+		require.NoError(t, err)
+		return len(ports)
+	}
+
+	cases := []struct {
+		name            string
+		path            string
+		pred            func(*yaml.Node) bool
+		expectedStrings []string
+	}{
+		{
+			name: "predicate on matches",
+			path: "$.deployments[*]",
+			pred: func(d *yaml.Node) bool {
+				return ports(d) > 1
+			},
+			expectedStrings: []string{"web\n"},
+		},
+		{
+			name: "predicate on matches of filter",
+			path: "$.deployments[?(@.containers[*])]",
+			pred: func(d *yaml.Node) bool {
+				return ports(d) < 2
+			},
+			expectedStrings: []string{"worker\n"},
+		},
+		{
+			name: "predicate rejecting every match",
+			path: "$.deployments[*]",
+			pred: func(d *yaml.Node) bool {
+				return false
+			},
+			expectedStrings: []string{},
+		},
+		{
+			name: "no matches",
+			path: "$.nosuch[*]",
+			pred: func(d *yaml.Node) bool {
+				return true
+			},
+			expectedStrings: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.FindFunc(&n, tc.pred)
+			require.NoError(t, err)
+
+			names := []*yaml.Node{}
+			for _, d := range actual {
+				names = append(names, d.Content[1])
+			}
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, names))
+		})
+	}
+
+	t.Run("predicate on scalars", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$..image")
+		require.NoError(t, err)
+		actual, err := p.FindFunc(&n, func(image *yaml.Node) bool {
+			return len(image.Value) > 5
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"sidecar\n", "worker\n"}, encodeNodes(t, actual))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.deployments[?(@.name==$unbound)]")
+		require.NoError(t, err)
+		_, err = p.FindFunc(&n, func(*yaml.Node) bool {
+			return true
+		})
+		require.EqualError(t, err, "no binding for $unbound")
+	})
+}
+
+func TestFindDistinctBy(t *testing.T) {
+	y := `---
+items:
+- {name: a, n: 1}
+- {name: b, n: 2}
+- {name: a, n: 3}
+- {name: "a", n: 4}
+- {name: "1", n: 5}
+- {name: 1, n: 6}
+- {n: 7}
+- {name: b, n: 8}
+- {n: 9}
+- {name: {x: [1, 2]}, n: 10}
+- {name: {x: [1, 2]}, n: 11}
+- {name: null, n: 12}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	p, err := yamlpath.NewPath("$.items[*]")
+	require.NoError(t, err)
+	num, err := yamlpath.NewPath("n")
+	require.NoError(t, err)
+	numbers := func(nodes []*yaml.Node) []string {
+		ns := []string{}
+		for _, node := range nodes {
+			m, err := num.Find(node)
+			require.NoError(t, err)
+			ns = append(ns, m[0].Value)
+		}
+		return ns
+	}
+
+	actual, err := p.FindDistinctBy(&n, ".name")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2", "5", "6", "7", "10", "12"}, numbers(actual))
+
+	// unique keys
+	actual, err = p.FindDistinctBy(&n, "$.n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}, numbers(actual))
+
+	_, err = p.FindDistinctBy(&n, "[")
+	require.EqualError(t, err, `invalid key subpath "[": unmatched [ at position 1, following "["`)
+}
+
+func TestWalk(t *testing.T) {
+	y := `---
+spec:
+  containers:
+  - name: a
+    image: nginx
+  - name: b
+    image: redis
+  labels: {app: web}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	type matchContext struct {
+		node   string
+		parent string
+		key    string
+		index  int
+	}
+	value := func(node *yaml.Node) string {
+		if node == nil {
+			return "<nil>"
+		}
+		if node.Kind == yaml.ScalarNode {
+			return node.Value
+		}
+		return map[yaml.Kind]string{
+			yaml.DocumentNode: "Document",
+			yaml.SequenceNode: "Sequence",
+			yaml.MappingNode:  "Mapping",
+		}[node.Kind]
+	}
+
+	cases := []struct {
+		name     string
+		path     string
+		expected []matchContext
+	}{
+		{
+			name: "values in mappings",
+			path: "$..image",
+			expected: []matchContext{
+				{node: "nginx", parent: "Mapping", key: "image", index: -1},
+				{node: "redis", parent: "Mapping", key: "image", index: -1},
+			},
+		},
+		{
+			name: "elements of a sequence",
+			path: "$.spec.containers[*]",
+			expected: []matchContext{
+				{node: "Mapping", parent: "Sequence", key: "<nil>", index: 0},
+				{node: "Mapping", parent: "Sequence", key: "<nil>", index: 1},
+			},
+		},
+		{
+			name: "mapping keys",
+			path: "$.spec.labels~",
+			expected: []matchContext{
+				{node: "labels", parent: "Mapping", key: "<nil>", index: -1},
+			},
+		},
+		{
+			name: "top level mapping",
+			path: "$",
+			expected: []matchContext{
+				{node: "Mapping", parent: "Document", key: "<nil>", index: -1},
+			},
+		},
+		{
+			name: "document",
+			path: "",
+			expected: []matchContext{
+				{node: "Document", parent: "<nil>", key: "<nil>", index: -1},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual := []matchContext{}
+			err = p.Walk(&n, func(ctx yamlpath.MatchContext) error {
+				actual = append(actual, matchContext{
+					node:   value(ctx.Node),
+					parent: value(ctx.Parent),
+					key:    value(ctx.Key),
+					index:  ctx.Index,
+				})
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("modify parent", func(t *testing.T) {
+		var m yaml.Node
+		err := yaml.Unmarshal([]byte(`[1, 2, 3, 4]`), &m)
+		require.NoError(t, err)
+		p, err := yamlpath.NewPath("$[?(@ > 2)]")
+		require.NoError(t, err)
+
+		err = p.Walk(&m, func(ctx yamlpath.MatchContext) error {
+			ctx.Parent.Content[ctx.Index] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "big"}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"[1, 2, big, big]\n"}, encodeNodes(t, []*yaml.Node{&m}))
+	})
+
+	t.Run("visit error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$..name")
+		require.NoError(t, err)
+		visits := 0
+		err = p.Walk(&n, func(ctx yamlpath.MatchContext) error {
+			visits++
+			return errors.New("stop")
+		})
+		require.EqualError(t, err, "stop")
+		require.Equal(t, 1, visits)
+	})
+}
+
+func TestFindEntries(t *testing.T) {
+	y := `---
+config:
+  server: {port: 80}
+  debug: true
+list: [a, {b: c}]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	type entry struct {
+		key   string
+		value string
+	}
+
+	cases := []struct {
+		name     string
+		path     string
+		expected []entry
+	}{
+		{
+			name: "members of a mapping",
+			path: "$.config.*",
+			expected: []entry{
+				{key: "server", value: "{port: 80}\n"},
+				{key: "debug", value: "true\n"},
+			},
+		},
+		{
+			name: "elements of a sequence",
+			path: "$.list[*]",
+			expected: []entry{
+				{key: "<nil>", value: "a\n"},
+				{key: "<nil>", value: "{b: c}\n"},
+			},
+		},
+		{
+			name: "values and elements",
+			path: "$..[?(@ == 80 || @ == 'a')]",
+			expected: []entry{
+				{key: "port", value: "80\n"},
+				{key: "<nil>", value: "a\n"},
+			},
+		},
+		{
+			name:     "no matches",
+			path:     "$.nosuch",
+			expected: []entry{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			entries, err := p.FindEntries(&n)
+			require.NoError(t, err)
+
+			actual := []entry{}
+			for _, e := range entries {
+				key := "<nil>"
+				if e.Key != nil {
+					key = e.Key.Value
+				}
+				actual = append(actual, entry{key: key, value: encodeNodes(t, []*yaml.Node{e.Value})[0]})
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.list[?(@ == $unbound)]")
+		require.NoError(t, err)
+
+		_, err = p.FindEntries(&n)
+		require.Error(t, err)
+	})
+}
+
+func TestFindWithAdjacentFilters(t *testing.T) {
+	y := `---
+items:
+- {n: 1, a: x, b: y}
+- {n: 2, a: x}
+- {n: 3, b: y}
+- {n: 4, a: x, b: y, c: {a: 1, b: 2}}
+rows:
+- [1, 5, 9]
+- [2, 6]
+- [1, 3]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		equivalent      string
+		expectedStrings []string
+	}{
+		{
+			name:            "filters applied to mappings",
+			path:            "$.items[?(@.a)][?(@.b)].n",
+			equivalent:      "$.items[?(@.a && @.b)].n",
+			expectedStrings: []string{"1\n", "4\n"},
+		},
+		{
+			name:            "three filters applied to mappings",
+			path:            "$.items[?(@.a)][?(@.b)][?(@.c)].n",
+			equivalent:      "$.items[?(@.a && @.b && @.c)].n",
+			expectedStrings: []string{"4\n"},
+		},
+		{
+			name:            "second filter rejects every match of the first",
+			path:            "$.items[?(@.n > 2)][?(@.n < 3)]",
+			equivalent:      "$.items[?(@.n > 2 && @.n < 3)]",
+			expectedStrings: []string{},
+		},
+		{
+			// unlike a conjunction, the second filter is applied to the elements of each sequence selected by the first
+			name:            "filters applied to sequences",
+			path:            "$.rows[?(@[0]==1)][?(@ > 2)]",
+			equivalent:      "$.rows[?(@[0]==1)][*][?(@ > 2)]",
+			expectedStrings: []string{"5\n", "9\n", "3\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			q, err := yamlpath.NewPath(tc.equivalent)
+			require.NoError(t, err)
+			equivalent, err := q.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, actual, equivalent)
+		})
+	}
+
+	// a conjunction tests the sequence itself, whose elements are not all greater than 2
+	p, err := yamlpath.NewPath("$.rows[?(@[0]==1 && @ > 2)]")
+	require.NoError(t, err)
+	actual, err := p.Find(&n)
+	require.NoError(t, err)
+	require.Empty(t, actual)
+}
+
+func TestFindWithSpecialKeys(t *testing.T) {
+	y := `---
+"@": at
+"$": dollar
+items:
+- "@": 1
+  "$": 2
+  name: a
+- "@": 3
+  "$": 3
+  name: b
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+	}{
+		{
+			name:            "key named at sign",
+			path:            "$['@']",
+			expectedStrings: []string{"at\n"},
+		},
+		{
+			name:            "key named dollar sign",
+			path:            `$["$"]`,
+			expectedStrings: []string{"dollar\n"},
+		},
+		{
+			name:            "union of keys named at sign and dollar sign",
+			path:            "$['@','$']",
+			expectedStrings: []string{"at\n", "dollar\n"},
+		},
+		{
+			name:            "key named at sign of each element",
+			path:            "$.items[*]['@']",
+			expectedStrings: []string{"1\n", "3\n"},
+		},
+		{
+			name:            "key named at sign in filter",
+			path:            "$.items[?(@['@']==1)].name",
+			expectedStrings: []string{"a\n"},
+		},
+		{
+			name:            "keys named at sign and dollar sign compared in filter",
+			path:            "$.items[?(@['@']==@['$'])].name",
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "key named dollar sign in filter compared with root",
+			path:            `$.items[?(@["$"]==2 && $['@']=='at')].name`,
+			expectedStrings: []string{"a\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindWithWildcardThenChildren(t *testing.T) {
+	y := `---
+first:
+  metadata: {name: one, labels: {app: web}}
+second:
+  metadata: {labels: {app: db}}
+third: scalar
+fourth:
+  metadata: {name: four}
+fifth:
+  spec: {name: not metadata}
+sixth:
+  metadata: {name: [six, seis]}
+seventh:
+- metadata: {name: seven}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+	}{
+		{
+			name:            "some entries missing the chain",
+			path:            "$.*.metadata.name",
+			expectedStrings: []string{"one\n", "four\n", "[six, seis]\n"},
+		},
+		{
+			name:            "bracket wildcard",
+			path:            "$[*].metadata.name",
+			expectedStrings: []string{"one\n", "four\n", "[six, seis]\n"},
+		},
+		{
+			name:            "chain ending in wildcard",
+			path:            "$.*.metadata.name.*",
+			expectedStrings: []string{"six\n", "seis\n"},
+		},
+		{
+			name:            "wildcard in middle of chain",
+			path:            "$.*.metadata.*.app",
+			expectedStrings: []string{"web\n", "db\n"},
+		},
+		{
+			name:            "repeated wildcards",
+			path:            "$.*.*.name",
+			expectedStrings: []string{"one\n", "four\n", "not metadata\n", "[six, seis]\n"},
+		},
+		{
+			name:            "sequence entry",
+			path:            "$.*[*].metadata.name",
+			expectedStrings: []string{"seven\n"},
+		},
+		{
+			name:            "no entry has the chain",
+			path:            "$.*.metadata.namespace",
+			expectedStrings: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindWithBracketWildcard(t *testing.T) {
+	y := `---
+config: {a: 1, b: {c: 2}, d: [3]}
+list: [x, {y: z}]
+empty: {}
+scalar: s
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		equivalent      string
+		expectedStrings []string
+	}{
+		{
+			name:            "mapping",
+			path:            "$.config[*]",
+			equivalent:      "$.config.*",
+			expectedStrings: []string{"1\n", "{c: 2}\n", "[3]\n"},
+		},
+		{
+			name:            "sequence",
+			path:            "$.list[*]",
+			equivalent:      "$.list.*",
+			expectedStrings: []string{"x\n", "{y: z}\n"},
+		},
+		{
+			name:            "empty mapping",
+			path:            "$.empty[*]",
+			equivalent:      "$.empty.*",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "scalar",
+			path:            "$.scalar[*]",
+			equivalent:      "$.scalar.*",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "mappings and sequences",
+			path:            "$[*][*]",
+			equivalent:      "$.*.*",
+			expectedStrings: []string{"1\n", "{c: 2}\n", "[3]\n", "x\n", "{y: z}\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			q, err := yamlpath.NewPath(tc.equivalent)
+			require.NoError(t, err)
+			equivalent, err := q.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, actual, equivalent)
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	y := `---
+spec:
+  containers:
+  - name: nginx
+    image: nginx
+  - name: sidecar
+    image: envoy
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+	spec := n.Content[0].Content[1]
+	containers := spec.Content[1]
+	nginx := containers.Content[0]
+	sidecarImage := containers.Content[1].Content[3]
+
+	cases := []struct {
+		name      string
+		path      string
+		candidate *yaml.Node
+		expected  bool
+	}{
+		{name: "root", path: "$", candidate: n.Content[0], expected: true},
+		{name: "document", path: "", candidate: &n, expected: true},
+		{name: "child", path: "$.spec", candidate: spec, expected: true},
+		{name: "other child", path: "$.spec", candidate: containers, expected: false},
+		{name: "index", path: "$.spec.containers[0]", candidate: nginx, expected: true},
+		{name: "wildcard", path: "$.spec.containers[*].image", candidate: sidecarImage, expected: true},
+		{name: "filter", path: "$.spec.containers[?(@.name=='sidecar')]", candidate: nginx, expected: false},
+		{name: "recursive descent", path: "$..image", candidate: sidecarImage, expected: true},
+		{name: "recursive descent not matching", path: "$..image", candidate: nginx, expected: false},
+		{name: "equal but distinct node", path: "$..image", candidate: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "envoy"}, expected: false},
+		{name: "nil", path: "$..*", candidate: nil, expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			matches, err := p.Matches(&n, tc.candidate)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, matches)
+		})
+	}
+
+	p, err := yamlpath.NewPath("$..*[?(@==$x)]")
+	require.NoError(t, err)
+	_, err = p.Matches(&n, nginx)
+	require.EqualError(t, err, "no binding for $x")
+}
+
+func TestFindNth(t *testing.T) {
+	y := `---
+items:
+- name: a
+- name: b
+- name: c
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		path     string
+		opts     []yamlpath.Option
+		n        int
+		expected []string
+	}{
+		{
+			name:     "first",
+			path:     "$.items[*].name",
+			n:        0,
+			expected: []string{"a\n"},
+		},
+		{
+			name:     "last",
+			path:     "$.items[*].name",
+			n:        2,
+			expected: []string{"c\n"},
+		},
+		{
+			name:     "out of range",
+			path:     "$.items[*].name",
+			n:        3,
+			expected: []string{},
+		},
+		{
+			name:     "no matches",
+			path:     "$.nosuch",
+			n:        0,
+			expected: []string{},
+		},
+		{
+			name:     "negative index",
+			path:     "$.items[*].name",
+			n:        -1,
+			expected: []string{"c\n"},
+		},
+		{
+			name:     "negative index of first match",
+			path:     "$.items[*].name",
+			n:        -3,
+			expected: []string{"a\n"},
+		},
+		{
+			name:     "negative index out of range",
+			path:     "$.items[*].name",
+			n:        -4,
+			expected: []string{},
+		},
+		{
+			name:     "duplicate matches",
+			path:     "$.items[0,0,1].name",
+			n:        1,
+			expected: []string{"a\n"},
+		},
+		{
+			name:     "distinct results",
+			path:     "$.items[0,0,1].name",
+			opts:     []yamlpath.Option{yamlpath.WithDistinctResults()},
+			n:        1,
+			expected: []string{"b\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			actual, err := p.FindNth(&n, tc.n)
+			require.NoError(t, err)
+			if len(tc.expected) == 0 {
+				require.Nil(t, actual)
+				return
+			}
+			require.Equal(t, tc.expected, encodeNodes(t, []*yaml.Node{actual}))
+		})
+	}
+
+	t.Run("stops at the match", func(t *testing.T) {
+		// the filter is applied to each item in turn and refers to an unbound name only when applied to the third
+		p, err := yamlpath.NewPath("$.items[*][?(@.name != 'c' || @ == $x)].name")
+		require.NoError(t, err)
+
+		actual, err := p.FindNth(&n, 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b\n"}, encodeNodes(t, []*yaml.Node{actual}))
+
+		_, err = p.FindNth(&n, 2)
+		require.EqualError(t, err, "no binding for $x")
+
+		_, err = p.FindNth(&n, -1)
+		require.EqualError(t, err, "no binding for $x")
+	})
+}
+
+func TestFindRange(t *testing.T) {
+	y := `---
+items:
+- name: a
+- name: b
+- name: c
+- name: d
+- name: e
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name          string
+		path          string
+		opts          []yamlpath.Option
+		offset, limit int
+		expected      []string
+	}{
+		{
+			name:     "first page",
+			path:     "$.items[*].name",
+			offset:   0,
+			limit:    2,
+			expected: []string{"a\n", "b\n"},
+		},
+		{
+			name:     "middle page",
+			path:     "$.items[*].name",
+			offset:   2,
+			limit:    2,
+			expected: []string{"c\n", "d\n"},
+		},
+		{
+			name:     "last page partly beyond range",
+			path:     "$.items[*].name",
+			offset:   4,
+			limit:    2,
+			expected: []string{"e\n"},
+		},
+		{
+			name:     "beyond range",
+			path:     "$.items[*].name",
+			offset:   5,
+			limit:    2,
+			expected: []string{},
+		},
+		{
+			name:     "limit beyond range",
+			path:     "$.items[*].name",
+			offset:   0,
+			limit:    10,
+			expected: []string{"a\n", "b\n", "c\n", "d\n", "e\n"},
+		},
+		{
+			name:     "zero limit",
+			path:     "$.items[*].name",
+			offset:   1,
+			limit:    0,
+			expected: []string{},
+		},
+		{
+			name:     "no matches",
+			path:     "$.nosuch",
+			offset:   0,
+			limit:    2,
+			expected: []string{},
+		},
+		{
+			name:     "duplicate matches",
+			path:     "$.items[0,0,1].name",
+			offset:   1,
+			limit:    2,
+			expected: []string{"a\n", "b\n"},
+		},
+		{
+			name:     "distinct results",
+			path:     "$.items[0,0,1,2].name",
+			opts:     []yamlpath.Option{yamlpath.WithDistinctResults()},
+			offset:   1,
+			limit:    2,
+			expected: []string{"b\n", "c\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			actual, err := p.FindRange(&n, tc.offset, tc.limit)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, encodeNodes(t, actual))
+		})
+	}
+
+	t.Run("stops at the end of the range", func(t *testing.T) {
+		// the filter is applied to each item in turn and refers to an unbound name only when applied to the third
+		p, err := yamlpath.NewPath("$.items[*][?(@.name != 'c' || @ == $x)].name")
+		require.NoError(t, err)
+
+		actual, err := p.FindRange(&n, 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b\n"}, encodeNodes(t, actual))
+
+		_, err = p.FindRange(&n, 1, 2)
+		require.EqualError(t, err, "no binding for $x")
+	})
+
+	t.Run("negative offset or limit", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.items[*]")
+		require.NoError(t, err)
+
+		_, err = p.FindRange(&n, -1, 2)
+		require.EqualError(t, err, "invalid range with offset -1 and limit 2")
+
+		_, err = p.FindRange(&n, 0, -1)
+		require.EqualError(t, err, "invalid range with offset 0 and limit -1")
+	})
+}
+
+func TestCountInDocuments(t *testing.T) {
+	y := `---
+items:
+- name: a
+  enabled: true
+- name: b
+  enabled: true
+- name: c
+  enabled: true
+---
+items: []
+---
+items:
+- name: d
+  enabled: false
+- name: e
+  enabled: true
+---
+other: x
+`
+	docs := []*yaml.Node{}
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(y)))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+		docs = append(docs, &doc)
+	}
+	require.Len(t, docs, 4)
+
+	cases := []struct {
+		name     string
+		path     string
+		opts     []yamlpath.Option
+		docs     []*yaml.Node
+		expected []int
+	}{
+		{
+			name:     "varying counts",
+			path:     "$.items[*]",
+			docs:     docs,
+			expected: []int{3, 0, 2, 0},
+		},
+		{
+			name:     "filter",
+			path:     "$.items[?(@.enabled == true)].name",
+			docs:     docs,
+			expected: []int{3, 0, 1, 0},
+		},
+		{
+			name:     "root matches every document",
+			path:     "$",
+			docs:     docs,
+			expected: []int{1, 1, 1, 1},
+		},
+		{
+			name:     "duplicate matches",
+			path:     "$.items[0,0]",
+			docs:     docs,
+			expected: []int{2, 0, 2, 0},
+		},
+		{
+			name:     "distinct results",
+			path:     "$.items[0,0]",
+			opts:     []yamlpath.Option{yamlpath.WithDistinctResults()},
+			docs:     docs,
+			expected: []int{1, 0, 1, 0},
+		},
+		{
+			name:     "no documents",
+			path:     "$.items[*]",
+			docs:     []*yaml.Node{},
+			expected: []int{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			counts, err := p.CountInDocuments(tc.docs)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, counts)
+
+			for i, doc := range tc.docs {
+				results, err := p.Find(doc)
+				require.NoError(t, err)
+				count, err := p.Count(doc)
+				require.NoError(t, err)
+				require.Equal(t, len(results), count, "document %d", i)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.items[?(@.name == 'd')][?(@ == $x)]")
+		require.NoError(t, err)
+		_, err = p.CountInDocuments(docs)
+		require.EqualError(t, err, "document 2: no binding for $x")
+	})
+}
+
+func TestFindInStreamWithContext(t *testing.T) {
+	y := `---
+name: web
+replicas: 5
+env: prod
+ports: [80, 8080]
+---
+name: db
+replicas: 1
+env: dev
+ports: [5432]
+---
+name: cache
+replicas: 3
+env: prod
+ports: [6379]
+`
+	docs := []*yaml.Node{}
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(y)))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+		docs = append(docs, &doc)
+	}
+	require.Len(t, docs, 3)
+
+	var contextDoc yaml.Node
+	err := yaml.Unmarshal([]byte("maxReplicas: 3\nenvs: [dev, test]\nminPort: 1024\n"), &contextDoc)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		docs            []*yaml.Node
+		expectedStrings []string
+	}{
+		{
+			name:            "filter referring to context",
+			path:            "$[?(@.replicas > $.maxReplicas)].name",
+			docs:            docs,
+			expectedStrings: []string{"web\n"},
+		},
+		{
+			name:            "filter on children referring to context",
+			path:            "$.ports[?(@ >= $.minPort)]",
+			docs:            docs,
+			expectedStrings: []string{"8080\n", "5432\n", "6379\n"},
+		},
+		{
+			name:            "negated filter referring to context",
+			path:            "$[?(!(@.env == $.envs[0] || @.env == $.envs[1]))].name",
+			docs:            docs,
+			expectedStrings: []string{"web\n", "cache\n"},
+		},
+		{
+			name:            "parent refers to document",
+			path:            "$.ports[?(@^^.replicas <= $.maxReplicas)]",
+			docs:            docs,
+			expectedStrings: []string{"5432\n", "6379\n"},
+		},
+		{
+			name:            "path without filters",
+			path:            "$.name",
+			docs:            docs,
+			expectedStrings: []string{"web\n", "db\n", "cache\n"},
+		},
+		{
+			name:            "no documents",
+			path:            "$.name",
+			docs:            []*yaml.Node{},
+			expectedStrings: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			results, err := p.FindInStreamWithContext(tc.docs, &contextDoc)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, results))
+		})
+	}
+
+	t.Run("root refers to document in Find", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$[?(@.replicas > $.maxReplicas)].name")
+		require.NoError(t, err)
+		results, err := p.Find(docs[0])
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$[?(@.name == 'cache' && @.env == $x)]")
+		require.NoError(t, err)
+		_, err = p.FindInStreamWithContext(docs, &contextDoc)
+		require.EqualError(t, err, "document 2: no binding for $x")
+	})
+}
+
+func TestIsSingular(t *testing.T) {
+	cases := []struct {
+		path     string
+		singular bool
+	}{
+		{path: "", singular: true},
+		{path: "$", singular: true},
+		{path: "a.b", singular: true},
+		{path: "$.a.b", singular: true},
+		{path: "$['a'].b", singular: true},
+		{path: `$.a\.b`, singular: true},
+		{path: "$.a[0][-1]", singular: true},
+		{path: "$.*", singular: false},
+		{path: "$.a[*]", singular: false},
+		{path: "$.a[1:2]", singular: false},
+		{path: "$.a[0,1]", singular: false},
+		{path: "$['a','b']", singular: false},
+		{path: "$..a", singular: false},
+		{path: "$.a[?(@.b)]", singular: false},
+		{path: "$.a~", singular: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			require.Equal(t, tc.singular, p.IsSingular())
+		})
+	}
+}
+
+func TestToJSONPointer(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "identity",
+			path:     "",
+			expected: "",
+		},
+		{
+			name:     "root",
+			path:     "$",
+			expected: "",
+		},
+		{
+			name:     "nested bracket children",
+			path:     "$['spec']['replicas']",
+			expected: "/spec/replicas",
+		},
+		{
+			name:     "nested dot children",
+			path:     "spec.template.spec",
+			expected: "/spec/template/spec",
+		},
+		{
+			name:     "array indices",
+			path:     "$.spec.containers[1].ports[0]",
+			expected: "/spec/containers/1/ports/0",
+		},
+		{
+			name:     "escaping",
+			path:     `$['a/b']['m~n'].c\.d`,
+			expected: "/a~1b/m~0n/c.d",
+		},
+		{
+			name:     "empty name",
+			path:     "$['']",
+			expected: "/",
+		},
+		{
+			name:        "wildcard",
+			path:        "$.spec.containers[*]",
+			expectedErr: `path is not singular: "$.spec.containers[*]" has no JSON Pointer equivalent`,
+		},
+		{
+			name:        "filter",
+			path:        "$.spec.containers[?(@.name=='x')]",
+			expectedErr: `path is not singular: "$.spec.containers[?(@.name=='x')]" has no JSON Pointer equivalent`,
+		},
+		{
+			name:        "negative array index",
+			path:        "$.spec.containers[-1]",
+			expectedErr: "negative array index -1 has no JSON Pointer equivalent",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			pointer, err := p.ToJSONPointer()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, pointer)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+
+	p, err := yamlpath.NewPath("$..a")
+	require.NoError(t, err)
+	_, err = p.ToJSONPointer()
+	require.True(t, errors.Is(err, yamlpath.ErrNotSingular))
+}
+
+func TestNewPathFromJSONPointer(t *testing.T) {
+	y := `---
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.19
+  - name: sidecar
+    image: envoy
+  0: zero
+  "1": one
+  a/b: slash
+  m~n: tilde
+  "": empty
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		pointer         string
+		expectedPath    string
+		expectedStrings []string
+		expectedErr     string
+	}{
+		{
+			name:            "empty pointer",
+			pointer:         "",
+			expectedPath:    "$",
+			expectedStrings: []string{"spec:\n  containers:\n    - name: nginx\n      image: nginx:1.19\n    - name: sidecar\n      image: envoy\n  0: zero\n  \"1\": one\n  a/b: slash\n  m~n: tilde\n  \"\": empty\n"},
+		},
+		{
+			name:            "nested keys and array index",
+			pointer:         "/spec/containers/1/image",
+			expectedPath:    "$.spec.containers[1].image",
+			expectedStrings: []string{"envoy\n"},
+		},
+		{
+			name:            "index as integer mapping key",
+			pointer:         "/spec/0",
+			expectedPath:    "$.spec[0]",
+			expectedStrings: []string{"zero\n"},
+		},
+		{
+			name:            "index does not select string mapping key",
+			pointer:         "/spec/1",
+			expectedPath:    "$.spec[1]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "escaped slash",
+			pointer:         "/spec/a~1b",
+			expectedPath:    "$.spec['a/b']",
+			expectedStrings: []string{"slash\n"},
+		},
+		{
+			name:            "escaped tilde",
+			pointer:         "/spec/m~0n",
+			expectedPath:    "$.spec['m~n']",
+			expectedStrings: []string{"tilde\n"},
+		},
+		{
+			name:            "empty key",
+			pointer:         "/spec/",
+			expectedPath:    "$.spec['']",
+			expectedStrings: []string{"empty\n"},
+		},
+		{
+			name:            "index out of range",
+			pointer:         "/spec/containers/2",
+			expectedPath:    "$.spec.containers[2]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "leading zero is not an index",
+			pointer:         "/spec/containers/01",
+			expectedPath:    "$.spec.containers.01",
+			expectedStrings: []string{},
+		},
+		{
+			name:        "missing leading slash",
+			pointer:     "spec/containers",
+			expectedErr: `invalid JSON Pointer "spec/containers": must be empty or start with "/"`,
+		},
+		{
+			name:        "invalid escape",
+			pointer:     "/spec/a~2",
+			expectedErr: `invalid JSON Pointer "/spec/a~2": "~" must be followed by "0" or "1"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPathFromJSONPointer(tc.pointer)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedPath, p.String())
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			pointer, err := p.ToJSONPointer()
+			require.NoError(t, err)
+			require.Equal(t, tc.pointer, pointer)
+		})
+	}
+}
+
+func TestNewPathFromJSONPointerRoundTrip(t *testing.T) {
+	cases := []struct {
+		name                string
+		yaml                string
+		pointer             string
+		expectedStrings     []string
+		expectedUpsert      string
+		expectedUpsertError string
+	}{
+		{
+			name:            "index into sequence",
+			yaml:            "a: [x]\n",
+			pointer:         "/a/0",
+			expectedStrings: []string{"x\n"},
+			expectedUpsert:  "a: [y]\n",
+		},
+		{
+			name:                "index into mapping with string key",
+			yaml:                "a: {\"0\": x}\n",
+			pointer:             "/a/0",
+			expectedStrings:     []string{},
+			expectedUpsertError: `node type mismatch: [0] requires a sequence but was applied to a mapping node at line 1, column 4`,
+		},
+		{
+			name:            "named child",
+			yaml:            "a: {b: x}\n",
+			pointer:         "/a/b",
+			expectedStrings: []string{"x\n"},
+			expectedUpsert:  "a: {b: y}\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPathFromJSONPointer(tc.pointer)
+			require.NoError(t, err)
+			q, err := yamlpath.NewPath(p.String())
+			require.NoError(t, err)
+			require.True(t, p.Equal(q))
+
+			for _, path := range []*yamlpath.Path{p, q} {
+				var n yaml.Node
+				err = yaml.Unmarshal([]byte(tc.yaml), &n)
+				require.NoError(t, err)
+
+				actual, err := path.Find(&n)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+				err = path.Upsert(&n, &yaml.Node{Kind: yaml.ScalarNode, Value: "y"})
+				if tc.expectedUpsertError != "" {
+					require.EqualError(t, err, tc.expectedUpsertError)
+					continue
+				}
+				require.NoError(t, err)
+				out, err := yaml.Marshal(&n)
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedUpsert, string(out))
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		expectedErr string
+	}{
+		{
+			name: "simple path",
+			path: "$.a.b[0]",
+		},
+		{
+			name: "valid filters",
+			path: `$[?(@.a && (@.b || !@.c) && @.d=~/x/)][?(@.y[?(@.z>1)])]`,
+		},
+		{
+			name: "boolean literal predicate",
+			path: `$[?(true)]`,
+		},
+		{
+			name: "function call",
+			path: `$[?(lower(@.name)=='admin' && hasPrefix(lower(@.id), 'x'))]`,
+		},
+		{
+			name:        "unknown function",
+			path:        `$[?(nosuch(@.a))]`,
+			expectedErr: `invalid filter "nosuch(@.a)": unknown filter function nosuch`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			err = p.Validate()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRootInNestedFilter(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{limit: 1, groups: [{limit: 2, items: [{v: 1}]}, {limit: 2, items: [{v: 2}]}]}`), &n)
+	require.NoError(t, err)
+
+	// $ in a filter nested in the path of @ refers to the root of the document, so the first group is selected
+	p, err := yamlpath.NewPath(`$.groups[?(@.items[?(@.v == $.limit)])].items`)
+	require.NoError(t, err)
+	actual, err := p.Find(&n)
+	require.NoError(t, err)
+	require.Equal(t, []string{"[{v: 1}]\n"}, encodeNodes(t, actual))
+
+	// earlier versions applied the path of @ as a separate path, so that $ referred to each group and the second
+	// group, whose own limit is 2, was selected instead
+	groups, err := yamlpath.NewPath(`$.groups[*]`)
+	require.NoError(t, err)
+	items, err := yamlpath.NewPath(`$.items[?(@.v == $.limit)]`)
+	require.NoError(t, err)
+	selected := []*yaml.Node{}
+	gs, err := groups.Find(&n)
+	require.NoError(t, err)
+	for _, g := range gs {
+		matches, err := items.Find(g)
+		require.NoError(t, err)
+		if len(matches) > 0 {
+			selected = append(selected, g.Content[3])
+		}
+	}
+	require.Equal(t, []string{"[{v: 2}]\n"}, encodeNodes(t, selected))
+}
+
+func TestSyntaxErrorsDetectedByNewPath(t *testing.T) {
+	y := `---
+items:
+- {name: a, tags: [x]}
+- {name: b}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	syntaxErrors := []struct {
+		name        string
+		path        string
+		expectedErr string
+	}{
+		{
+			name:        "invalid regular expression in filter",
+			path:        `$.items[?(@.name=~/(a/)]`,
+			expectedErr: "invalid regular expression at position 18, following \"=~\": error parsing regexp: missing closing ): `(a`",
+		},
+		{
+			name:        "invalid string literal in filter",
+			path:        `$.items[?(@.name=='\q')]`,
+			expectedErr: `invalid string literal '\q': unsupported escape sequence \q before position 22`,
+		},
+		{
+			name:        "invalid number literal in filter",
+			path:        `$.items[?(@.size>1x)]`,
+			expectedErr: `invalid number literal "1x" at position 17`,
+		},
+		{
+			name:        "invalid subpath in filter",
+			path:        `$.items[?(@.tags[)]`,
+			expectedErr: `invalid array index [)] before position 19: non-integer array index`,
+		},
+		{
+			name:        "invalid subpath in nested filter",
+			path:        `$.items[?(@.tags[?(@[1:2:0])])]`,
+			expectedErr: `invalid array index [1:2:0] before position 27: array index step value must be non-zero`,
+		},
+		{
+			name:        "function call missing close bracket",
+			path:        `$[?(lower(@.a)]`,
+			expectedErr: `invalid filter "lower(@.a": missing ) after arguments of function lower`,
+		},
+		{
+			name:        "missing second operand of conjunction",
+			path:        `$[?(@.a && )]`,
+			expectedErr: `invalid filter "@.a &&": missing second operand for binary operator &&`,
+		},
+		{
+			name:        "missing second operand of disjunction",
+			path:        `$[?(@.a || )]`,
+			expectedErr: `invalid filter "@.a ||": missing second operand for binary operator ||`,
+		},
+		{
+			name:        "missing operand of negation",
+			path:        `$[?(!)]`,
+			expectedErr: `invalid filter "!": missing operand for unary operator !`,
+		},
+		{
+			name:        "empty parentheses",
+			path:        `$[?(())]`,
+			expectedErr: `invalid filter "()": missing filter expression inside parentheses`,
+		},
+		{
+			name:        "unclosed parenthesis",
+			path:        `$[?((@.a)]`,
+			expectedErr: `invalid filter "(@.a": missing )`,
+		},
+		{
+			name:        "numeric literal predicate",
+			path:        `$[?(1)]`,
+			expectedErr: `invalid filter "1": literal 1 cannot be used as a filter predicate`,
+		},
+		{
+			name:        "string literal predicate",
+			path:        `$[?('x')]`,
+			expectedErr: `invalid filter "'x'": literal 'x' cannot be used as a filter predicate`,
+		},
+		{
+			name:        "chained comparison",
+			path:        `$[?(@.a > 1 > 2)]`,
+			expectedErr: `invalid filter "@.a>1>2": unexpected ">" in filter`,
+		},
+		{
+			name:        "index predicate",
+			path:        `$[?(@index)]`,
+			expectedErr: `invalid filter "@index": numeric term cannot be used as a filter predicate`,
+		},
+		{
+			name:        "key predicate",
+			path:        `$.*[?(@#)]`,
+			expectedErr: `invalid filter "@#": key term cannot be used as a filter predicate`,
+		},
+		{
+			name:        "comparison of range test",
+			path:        `$[?(@.a between 1 and 3 == true)]`,
+			expectedErr: `invalid filter "@.a between 1 and 3==true": unexpected "==" in filter`,
+		},
+		{
+			name:        "modulo by zero in subpath term",
+			path:        `$[?(@.a % 0 == 1)]`,
+			expectedErr: `invalid filter "@.a % 0==1": integer literal after % must be non-zero`,
+		},
+		{
+			name:        "modulo by zero",
+			path:        `$[?(@index % 0 == 0)]`,
+			expectedErr: `invalid filter "@index % 0==0": integer literal after % must be non-zero`,
+		},
+		{
+			name:        "invalid nested filter",
+			path:        `$[?(@.a[?(@.b && )])]`,
+			expectedErr: `invalid filter "@.b &&": missing second operand for binary operator &&`,
+		},
+		{
+			name:        "invalid filter after recursive descent",
+			path:        `$..[?(!)]`,
+			expectedErr: `invalid filter "!": missing operand for unary operator !`,
+		},
+		{
+			name:        "missing end of filter",
+			path:        `$.items[?(@.name=='a'`,
+			expectedErr: `missing end of filter at position 21, following "'a'"`,
+		},
+	}
+
+	for _, tc := range syntaxErrors {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.EqualError(t, err, tc.expectedErr)
+			require.Nil(t, p)
+		})
+	}
+
+	t.Run("evaluation errors", func(t *testing.T) {
+		for path, expectedErr := range map[string]string{
+			`$.items[?(@.name==$unbound)]`:     "no binding for $unbound",
+			`$.items[?(unregistered(@.name))]`: "unknown filter function unregistered",
+		} {
+			p, err := yamlpath.NewPath(path)
+			require.NoError(t, err, path)
+			_, err = p.Find(&n)
+			require.EqualError(t, err, expectedErr, path)
+		}
+	})
+}
+
+func TestPathString(t *testing.T) {
+	cases := []struct {
+		name     string
+		paths    []string
+		expected string
+	}{
+		{
+			name:     "identity",
+			paths:    []string{""},
+			expected: "",
+		},
+		{
+			name:     "root",
+			paths:    []string{"$"},
+			expected: "$",
+		},
+		{
+			name:     "children",
+			paths:    []string{"a.b", "$.a.b", "$['a'].b", `$["a"]['b']`, "$[ 'a' ]['b']"},
+			expected: "$.a.b",
+		},
+		{
+			name:     "range test",
+			paths:    []string{"$[?(@.port  between 1024\tand 65535)]", "$[?(@.port between 1024 and 65535)]"},
+			expected: "$[?(@.port between 1024 and 65535)]",
+		},
+		{
+			name:     "modulo",
+			paths:    []string{"$[?(@.n % 2 == 0)]", "$[?(@.n %2==0)]", "$[?( @.n  %  2 ==0 )]"},
+			expected: "$[?(@.n % 2==0)]",
+		},
+		{
+			name:     "function calls",
+			paths:    []string{"$[?(hasPrefix( lower(@.name) , 'x' ))]", "$[?(hasPrefix(lower(@.name),'x'))]"},
+			expected: "$[?(hasPrefix(lower(@.name),'x'))]",
+		},
+		{
+			name:     "ambiguous child names",
+			paths:    []string{`$.a\.b['c d']`, `$['a.b']["c d"]`},
+			expected: "$['a.b']['c d']",
+		},
+		{
+			name:     "quotes and backslashes in child names",
+			paths:    []string{`$["it's"]`, `$['it\'s']`},
+			expected: `$['it\'s']`,
+		},
+		{
+			name:     "union of children",
+			paths:    []string{`$[ 'a' , "b" ]`, `$['a','b']`},
+			expected: "$['a','b']",
+		},
+		{
+			name:     "wildcards",
+			paths:    []string{"$.*[*]", "$.*[ * ]"},
+			expected: "$.*[*]",
+		},
+		{
+			name:     "slice",
+			paths:    []string{"$[1:3]", "$[ 1 : 3 ]", "$[1:3:1]", "$[+1:3:]"},
+			expected: "$[1:3]",
+		},
+		{
+			name:     "slice with step",
+			paths:    []string{"$[::-1]", "$[ : : -1 ]"},
+			expected: "$[::-1]",
+		},
+		{
+			name:     "union of indices",
+			paths:    []string{"$[0, 2:4]", "$[0,2:4:1]"},
+			expected: "$[0,2:4]",
+		},
+		{
+			name:     "recursive descent",
+			paths:    []string{"$..a..*", "..a..*"},
+			expected: "$..a..*",
+		},
+		{
+			name:     "filter",
+			paths:    []string{`$.a[?(@.b==1&&@['c']=='x')]`, `$.a[?( @.b == 1 && @.c == 'x' )]`},
+			expected: `$.a[?(@.b==1 && @.c=='x')]`,
+		},
+		{
+			name:     "filter after recursive descent",
+			paths:    []string{`$..[?(!@.a || $.b=~/x/)]`, `$..[?( ! @.a||$.b =~ /x/ )]`},
+			expected: `$..[?(!@.a || $.b=~/x/)]`,
+		},
+		{
+			name:     "property names",
+			paths:    []string{"$.a.b~", "$.a['b']~"},
+			expected: "$.a.b~",
+		},
+		{
+			name:     "bracket property names",
+			paths:    []string{`$['a b']~`, `$["a b"]~`},
+			expected: "$['a b']~",
+		},
+		{
+			name:     "surrounding whitespace",
+			paths:    []string{" $.a.b", "$.a.b\n", "\t a.b \r\n"},
+			expected: "$.a.b",
+		},
+		{
+			name:     "surrounding whitespace with spaces in quoted names",
+			paths:    []string{" $['a b'] ", "$[\"a b\"]\n"},
+			expected: "$['a b']",
+		},
+		{
+			name:     "surrounding whitespace with spaces in filter",
+			paths:    []string{" $[?(@.a == 'x y')]\n"},
+			expected: "$[?(@.a=='x y')]",
+		},
+		{
+			name:     "escaped trailing whitespace",
+			paths:    []string{`$.a\ `, "$.a\\ \n"},
+			expected: "$['a ']",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, path := range tc.paths {
+				p, err := yamlpath.NewPath(path)
+				require.NoError(t, err, path)
+				require.Equal(t, tc.expected, p.String(), path)
+
+				// the canonical form is a valid path with the same canonical form
+				q, err := yamlpath.NewPath(p.String())
+				require.NoError(t, err, path)
+				require.Equal(t, tc.expected, q.String(), path)
+			}
+		})
+	}
+}
+
+func TestPathEqual(t *testing.T) {
+	cases := []struct {
+		name     string
+		lhs      string
+		rhs      string
+		lhsOpts  []yamlpath.Option
+		rhsOpts  []yamlpath.Option
+		expected bool
+	}{
+		{
+			name:     "identical",
+			lhs:      "$.a.b",
+			rhs:      "$.a.b",
+			expected: true,
+		},
+		{
+			name:     "dot and bracket children",
+			lhs:      "$.a['b']",
+			rhs:      "$['a'].b",
+			expected: true,
+		},
+		{
+			name:     "implicit root",
+			lhs:      "a.b",
+			rhs:      `$["a"]["b"]`,
+			expected: true,
+		},
+		{
+			name:     "whitespace in subscripts and filters",
+			lhs:      "$[ 0 : 2 ][?( @.a == 1 )]",
+			rhs:      "$[0:2][?(@.a==1)]",
+			expected: true,
+		},
+		{
+			name:     "different children",
+			lhs:      "$.a.b",
+			rhs:      "$.a.c",
+			expected: false,
+		},
+		{
+			name:     "child and recursive descent",
+			lhs:      "$.a.b",
+			rhs:      "$.a..b",
+			expected: false,
+		},
+		{
+			name:     "escaped dot and separate children",
+			lhs:      `$.a\.b`,
+			rhs:      "$.a.b",
+			expected: false,
+		},
+		{
+			name:     "different filters",
+			lhs:      "$[?(@.a==1)]",
+			rhs:      "$[?(@.a==2)]",
+			expected: false,
+		},
+		{
+			name:     "root and identity",
+			lhs:      "$",
+			rhs:      "",
+			expected: false,
+		},
+		{
+			name:     "same options",
+			lhs:      "$[?(@.a==1)]",
+			rhs:      "$[?(@.a==1)]",
+			lhsOpts:  []yamlpath.Option{yamlpath.WithNumericCoercion()},
+			rhsOpts:  []yamlpath.Option{yamlpath.WithNumericCoercion()},
+			expected: true,
+		},
+		{
+			name:     "different options",
+			lhs:      "$[?(@.a==1)]",
+			rhs:      "$[?(@.a==1)]",
+			lhsOpts:  []yamlpath.Option{yamlpath.WithNumericCoercion()},
+			expected: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lhs, err := yamlpath.NewPath(tc.lhs, tc.lhsOpts...)
+			require.NoError(t, err)
+			rhs, err := yamlpath.NewPath(tc.rhs, tc.rhsOpts...)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expected, lhs.Equal(rhs))
+			require.Equal(t, tc.expected, rhs.Equal(lhs))
+		})
+	}
+
+	p, err := yamlpath.NewPath("$.a")
+	require.NoError(t, err)
+	var nilPath *yamlpath.Path
+	require.False(t, p.Equal(nil))
+	require.False(t, nilPath.Equal(p))
+	require.True(t, nilPath.Equal(nil))
+}
+
+func TestPathStringRoundTrip(t *testing.T) {
+	y := `---
+a:
+  b c: [1, 2, 3]
+  d.e:
+  - f: x
+  - f: y
+g: 'it''s'
+h: [{n: 1}, {n: 2}]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	paths := []string{
+		`a['b c'][ 0 : 3 : 2 ]`,
+		`$.a.d\.e[?( @.f == 'y' )].f`,
+		`$..f`,
+		`$.*.*[-1]`,
+		`$.a[?(@['b c'][1] > 1)]['b c'][*]`,
+		`$["g"]`,
+		`$.h[?(@.n % 2 == 0)].n`,
+	}
+	for _, path := range paths {
+		p, err := yamlpath.NewPath(path)
+		require.NoError(t, err, path)
+		q, err := yamlpath.NewPath(p.String())
+		require.NoError(t, err, path)
+
+		expected, err := p.Find(&n)
+		require.NoError(t, err)
+		actual, err := q.Find(&n)
+		require.NoError(t, err)
+		require.NotEmpty(t, expected, path)
+		require.Equal(t, expected, actual, path)
+	}
+}
+
+func TestFindWithNumericCoercion(t *testing.T) {
+	y := `---
+- name: quoted
+  port: "8080"
+- name: unquoted
+  port: 8080
+- name: quoted float
+  port: "8080.0"
+- name: not a number
+  port: "http"
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		coerce          bool
+		expectedStrings []string
+	}{
+		{
+			name:            "equality without coercion",
+			path:            `$[?(@.port==8080)].name`,
+			expectedStrings: []string{"unquoted\n"},
+		},
+		{
+			name:            "equality with coercion",
+			path:            `$[?(@.port==8080)].name`,
+			coerce:          true,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "quoted float\n"},
+		},
+		{
+			name:            "literal on left with coercion",
+			path:            `$[?(8080.0==@.port)].name`,
+			coerce:          true,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "quoted float\n"},
+		},
+		{
+			name:            "inequality without coercion",
+			path:            `$[?(@.port!=8080)].name`,
+			expectedStrings: []string{"quoted\n", "quoted float\n", "not a number\n"},
+		},
+		{
+			name:            "inequality with coercion",
+			path:            `$[?(@.port!=8080)].name`,
+			coerce:          true,
+			expectedStrings: []string{"not a number\n"},
+		},
+		{
+			name:            "ordering with coercion",
+			path:            `$[?(@.port>8000)].name`,
+			coerce:          true,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "quoted float\n"},
+		},
+		{
+			name:            "string comparison without coercion",
+			path:            `$[?(@.port=='8080')].name`,
+			expectedStrings: []string{"quoted\n"},
+		},
+		{
+			name:            "string literal compared with number with coercion",
+			path:            `$[?(@.port=='8080')].name`,
+			coerce:          true,
+			expectedStrings: []string{"quoted\n", "unquoted\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []yamlpath.Option{}
+			if tc.coerce {
+				opts = append(opts, yamlpath.WithNumericCoercion())
+			}
+			p, err := yamlpath.NewPath(tc.path, opts...)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindWithNumericStringOrdering(t *testing.T) {
+	y := `---
+- name: quoted
+  value: "10"
+- name: unquoted
+  value: 10
+- name: quoted float
+  value: "9.5"
+- name: padded
+  value: " 11 "
+- name: not a number
+  value: "ten"
+- name: version
+  value: "1.10"
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+	}{
+		{
+			name:            "greater than number",
+			path:            `$[?(@.value > 9)].name`,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "quoted float\n", "padded\n"},
+		},
+		{
+			name:            "greater than or equal to number",
+			path:            `$[?(@.value >= 10)].name`,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "padded\n"},
+		},
+		{
+			name:            "less than number",
+			path:            `$[?(@.value < 10)].name`,
+			expectedStrings: []string{"quoted float\n", "version\n"},
+		},
+		{
+			name:            "less than or equal to number on the left",
+			path:            `$[?(10 <= @.value)].name`,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "padded\n"},
+		},
+		{
+			name:            "numeric string literal",
+			path:            `$[?(@.value > '9.75')].name`,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "padded\n"},
+		},
+		{
+			name:            "numeric strings compared numerically rather than lexically",
+			path:            `$[?(@.value < "2")].name`,
+			expectedStrings: []string{"version\n"},
+		},
+		{
+			name:            "equality is not affected",
+			path:            `$[?(@.value == 10)].name`,
+			expectedStrings: []string{"unquoted\n"},
+		},
+		{
+			name:            "string which is not a number",
+			path:            `$[?(@.value > 0 || @.value < 0)].name`,
+			expectedStrings: []string{"quoted\n", "unquoted\n", "quoted float\n", "padded\n", "version\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+
+	t.Run("strings which are not numbers are not ordered", func(t *testing.T) {
+		var n yaml.Node
+		err := yaml.Unmarshal([]byte(`[{v: b, w: a}, {v: a, w: b}, {v: "10", w: "9"}, {v: "10", w: x}]`), &n)
+		require.NoError(t, err)
+		p, err := yamlpath.NewPath(`$[?(@.v > @.w)]`)
+		require.NoError(t, err)
+		actual, err := p.Find(&n)
+		require.NoError(t, err)
+		require.Equal(t, []string{"{v: \"10\", w: \"9\"}\n"}, encodeNodes(t, actual))
+	})
+}
+
+func TestFindWithTimeComparison(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`---
+- name: old
+  created: "2022-12-31T23:59:59Z"
+- name: zoned
+  created: "2023-01-01T01:30:00+02:00"
+- name: new
+  created: "2023-06-30 12:00:00.5"
+- name: date
+  created: 2023-03-01
+- name: invalid
+  created: "yesterday"
+- name: number
+  created: 20230101
+`), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		timeComparison  bool
+		expectedStrings []string
+	}{
+		{
+			name:            "greater than",
+			path:            `$[?(@.created > '2023-01-01T00:00:00Z')].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"new\n", "date\n"},
+		},
+		{
+			name:            "less than or equal with literal on left hand side",
+			path:            `$[?('2023-01-01' <= @.created)].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"new\n", "date\n"},
+		},
+		{
+			name:            "less than",
+			path:            `$[?(@.created < "2023-01-01")].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"old\n", "zoned\n"},
+		},
+		{
+			name:            "equality of the same instant in different time zones",
+			path:            `$[?(@.created == '2022-12-31T23:30:00Z')].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"zoned\n"},
+		},
+		{
+			name:            "timestamps compared with each other",
+			path:            `$[?(@.created >= $[3].created)].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"new\n", "date\n"},
+		},
+		{
+			name:            "equality falls back to string comparison",
+			path:            `$[?(@.created == 'yesterday')].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"invalid\n"},
+		},
+		{
+			name:            "ordering of non-timestamp is false",
+			path:            `$[?(@.created <= '2030-01-01' || @.created > 20230000)].name`,
+			timeComparison:  true,
+			expectedStrings: []string{"old\n", "zoned\n", "new\n", "date\n", "number\n"},
+		},
+		{
+			name:            "no time comparison",
+			path:            `$[?(@.created > '2023-01-01T00:00:00Z')].name`,
+			expectedStrings: []string{},
+		},
+		{
+			name:            "string equality without time comparison",
+			path:            `$[?(@.created == '2022-12-31T23:59:59Z')].name`,
+			expectedStrings: []string{"old\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []yamlpath.Option{}
+			if tc.timeComparison {
+				opts = append(opts, yamlpath.WithTimeComparison())
+			}
+			p, err := yamlpath.NewPath(tc.path, opts...)
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+
+	_, err = yamlpath.NewPath(`$[?(@.created > 'yesterday')]`, yamlpath.WithTimeComparison())
+	require.EqualError(t, err, `strings cannot be compared using > at position 16, following "> "`)
+}
+
+func TestNewPathWithRequireExplicitRoot(t *testing.T) {
+	cases := []struct {
+		name          string
+		path          string
+		expectedError string
+	}{
+		{
+			name: "root",
+			path: "$",
+		},
+		{
+			name: "explicit root",
+			path: "$.a[?(@.b == $.c)].d",
+		},
+		{
+			name:          "dotted child",
+			path:          ".child",
+			expectedError: `path ".child" does not start with $`,
+		},
+		{
+			name:          "undotted child",
+			path:          "child.grandchild",
+			expectedError: `path "child.grandchild" does not start with $`,
+		},
+		{
+			name:          "array subscript",
+			path:          "[0]",
+			expectedError: `path "[0]" does not start with $`,
+		},
+		{
+			name:          "empty path",
+			path:          "",
+			expectedError: `path "" does not start with $`,
+		},
+	}
+
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{child: {grandchild: 1}}`), &n)
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// without the option, every path parses
+			_, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			_, err = yamlpath.NewPath(tc.path, yamlpath.WithRequireExplicitRoot())
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	p, err := yamlpath.NewPath("$.child[?(@.grandchild)]", yamlpath.WithRequireExplicitRoot())
+	require.NoError(t, err)
+	actual, err := p.Find(&n)
+	require.NoError(t, err)
+	require.Equal(t, []string{"{grandchild: 1}\n"}, encodeNodes(t, actual))
+
+	p, err = yamlpath.NewPath(".child", yamlpath.WithRequireExplicitRoot())
+	require.Nil(t, p)
+	require.Error(t, err)
+}
+
+func TestNewPathWithStrictSlices(t *testing.T) {
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+		expectedError   string
+	}{
+		{
+			name:            "ascending slice",
+			path:            "$[1:3]",
+			expectedStrings: []string{"1\n", "2\n"},
+		},
+		{
+			name:            "descending slice",
+			path:            "$[3:1:-1]",
+			expectedStrings: []string{"3\n", "2\n"},
+		},
+		{
+			name:            "empty slice",
+			path:            "$[2:2]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "open slices",
+			path:            "$[3:][:-4]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "mixed signs",
+			path:            "$[-1:2]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "slice beyond end of array",
+			path:            "$[7:9]",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "start greater than end",
+			path:            "$[5:2]",
+			expectedStrings: []string{},
+			expectedError:   "invalid array index [5:2]: slice 5:2 is empty since its start is greater than its end and its step is positive",
+		},
+		{
+			name:            "negative start greater than negative end",
+			path:            "$[-1:-3:2]",
+			expectedStrings: []string{},
+			expectedError:   "invalid array index [-1:-3:2]: slice -1:-3:2 is empty since its start is greater than its end and its step is positive",
+		},
+		{
+			name:            "start less than end with negative step",
+			path:            "$[0,1:4:-1]",
+			expectedStrings: []string{"0\n"},
+			expectedError:   "invalid array index [0,1:4:-1]: slice 1:4:-1 is empty since its start is less than its end and its step is negative",
+		},
+		{
+			name:            "inverted slice in filter",
+			path:            "$[?(@[2:1])]",
+			expectedStrings: []string{},
+			expectedError:   "invalid array index [2:1]: slice 2:1 is empty since its start is greater than its end and its step is positive",
+		},
+	}
+
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`[0, 1, 2, 3, 4]`), &n)
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// without the option, an inverted slice matches nothing
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			p, err = yamlpath.NewPath(tc.path, yamlpath.WithStrictSlices())
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				require.Nil(t, p)
+				return
+			}
+			require.NoError(t, err)
+			actual, err = p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindWithScalarWildcardSelf(t *testing.T) {
+	y := `---
+scalar: s
+mapping: {a: 1, b: 2}
+sequence: [x, y]
+tags:
+- one: 1
+  tags: single
+- one: 1
+  tags: [first, second]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedDefault []string
+		expectedSelf    []string
+	}{
+		{
+			name:            "dot wildcard on scalar",
+			path:            "$.scalar.*",
+			expectedDefault: []string{},
+			expectedSelf:    []string{"s\n"},
+		},
+		{
+			name:            "bracket wildcard on scalar",
+			path:            "$.scalar[*]",
+			expectedDefault: []string{},
+			expectedSelf:    []string{"s\n"},
+		},
+		{
+			name:            "quoted asterisk is not a wildcard",
+			path:            "$.scalar['*']",
+			expectedDefault: []string{},
+			expectedSelf:    []string{},
+		},
+		{
+			name:            "wildcard on mapping",
+			path:            "$.mapping.*",
+			expectedDefault: []string{"1\n", "2\n"},
+			expectedSelf:    []string{"1\n", "2\n"},
+		},
+		{
+			name:            "wildcard on sequence",
+			path:            "$.sequence[*]",
+			expectedDefault: []string{"x\n", "y\n"},
+			expectedSelf:    []string{"x\n", "y\n"},
+		},
+		{
+			name:            "repeated wildcard",
+			path:            "$.scalar.*.*",
+			expectedDefault: []string{},
+			expectedSelf:    []string{"s\n"},
+		},
+		{
+			name:            "scalar or sequence",
+			path:            "$.tags[*].tags[*]",
+			expectedDefault: []string{"first\n", "second\n"},
+			expectedSelf:    []string{"single\n", "first\n", "second\n"},
+		},
+		{
+			name:            "wildcard followed by child",
+			path:            "$.scalar.*.a",
+			expectedDefault: []string{},
+			expectedSelf:    []string{},
+		},
+		{
+			name:            "recursive descent is not affected",
+			path:            "$.mapping..*",
+			expectedDefault: []string{"1\n", "2\n"},
+			expectedSelf:    []string{"1\n", "2\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDefault, encodeNodes(t, actual))
+
+			p, err = yamlpath.NewPath(tc.path, yamlpath.WithScalarWildcardSelf())
+			require.NoError(t, err)
+			actual, err = p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSelf, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindWithStrictTypes(t *testing.T) {
+	y := `---
+scalar: s
+mapping: {a: 1, 2: two}
+sequence: [x, {a: y}]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+		expectedError   string
+	}{
+		{
+			name:            "child of mapping",
+			path:            "$.mapping.a",
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "missing child of mapping",
+			path:            "$.mapping.b",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "child of scalar",
+			path:            "$.scalar.a",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: .a requires a mapping but was applied to a scalar node at line 2, column 9",
+		},
+		{
+			name:            "child of sequence",
+			path:            "$.sequence.a",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: .a requires a mapping but was applied to a sequence node at line 4, column 11",
+		},
+		{
+			name:            "bracket child of scalar",
+			path:            "$.scalar['a']",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: ['a'] requires a mapping but was applied to a scalar node at line 2, column 9",
+		},
+		{
+			name:            "child of some elements",
+			path:            "$.sequence[*].a",
+			expectedStrings: []string{"y\n"},
+			expectedError:   "node type mismatch: .a requires a mapping but was applied to a scalar node at line 4, column 12",
+		},
+		{
+			name:            "index of sequence",
+			path:            "$.sequence[0]",
+			expectedStrings: []string{"x\n"},
+		},
+		{
+			name:            "index of scalar",
+			path:            "$.scalar[0]",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: [0] requires a sequence or a mapping but was applied to a scalar node at line 2, column 9",
+		},
+		{
+			name:            "index of mapping with integer keys",
+			path:            "$.mapping[2]",
+			expectedStrings: []string{"two\n"},
+		},
+		{
+			name:            "slice of scalar",
+			path:            "$.scalar[0:2]",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: [0:2] requires a sequence but was applied to a scalar node at line 2, column 9",
+		},
+		{
+			name:            "slice of mapping",
+			path:            "$.mapping[0:2]",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: [0:2] requires a sequence but was applied to a mapping node at line 3, column 10",
+		},
+		{
+			name:            "union of indices of mapping",
+			path:            "$.mapping[0,2]",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: [0,2] requires a sequence but was applied to a mapping node at line 3, column 10",
+		},
+		{
+			name:            "first element of sequence",
+			path:            "$.sequence.first()",
+			expectedStrings: []string{"x\n"},
+		},
+		{
+			name:            "last element of mapping",
+			path:            "$.mapping.last()",
+			expectedStrings: []string{},
+			expectedError:   "node type mismatch: .last() requires a sequence but was applied to a mapping node at line 3, column 10",
+		},
+		{
+			name:            "wildcard of scalar",
+			path:            "$.scalar.*",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "recursive descent",
+			path:            "$..a",
+			expectedStrings: []string{"1\n", "y\n"},
+		},
+		{
+			name:            "segments following recursive descent",
+			path:            "$..[1].a",
+			expectedStrings: []string{"y\n"},
+		},
+		{
+			name:            "filter",
+			path:            "$.sequence[?(@.a == 'y' || @[0] == 'x')]",
+			expectedStrings: []string{"{a: y}\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// without the option, a mismatch matches nothing
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			p, err = yamlpath.NewPath(tc.path, yamlpath.WithStrictTypes())
+			require.NoError(t, err)
+			actual, err = p.Find(&n)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				require.True(t, errors.Is(err, yamlpath.ErrTypeMismatch))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindWithAnchoredRegex(t *testing.T) {
+	y := `---
+- name: admin
+- name: admin-user
+- name: sysadmin
+- name: Admin
+- roles: [reader, admin]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name             string
+		path             string
+		expectedDefault  []string
+		expectedAnchored []string
+	}{
+		{
+			name:             "substring",
+			path:             "$[?(@.name =~ /admin/)].name",
+			expectedDefault:  []string{"admin\n", "admin-user\n", "sysadmin\n"},
+			expectedAnchored: []string{"admin\n"},
+		},
+		{
+			name:             "alternation",
+			path:             "$[?(@.name =~ /admin|sys/)].name",
+			expectedDefault:  []string{"admin\n", "admin-user\n", "sysadmin\n"},
+			expectedAnchored: []string{"admin\n"},
+		},
+		{
+			name:             "entire string",
+			path:             "$[?(@.name =~ /.*admin/)].name",
+			expectedDefault:  []string{"admin\n", "admin-user\n", "sysadmin\n"},
+			expectedAnchored: []string{"admin\n", "sysadmin\n"},
+		},
+		{
+			name:             "flags",
+			path:             "$[?(@.name =~ /(?i)admin/)].name",
+			expectedDefault:  []string{"admin\n", "admin-user\n", "sysadmin\n", "Admin\n"},
+			expectedAnchored: []string{"admin\n", "Admin\n"},
+		},
+		{
+			name:             "trailing flag",
+			path:             "$[?(@.name =~ /admin/i)].name",
+			expectedDefault:  []string{"admin\n", "admin-user\n", "sysadmin\n", "Admin\n"},
+			expectedAnchored: []string{"admin\n", "Admin\n"},
+		},
+		{
+			name:             "negated match",
+			path:             "$[?(@.name !~ /admin/)].name",
+			expectedDefault:  []string{"Admin\n"},
+			expectedAnchored: []string{"admin-user\n", "sysadmin\n", "Admin\n"},
+		},
+		{
+			name:             "element of sequence",
+			path:             "$[?(@.roles =~ /read/)].roles[0]",
+			expectedDefault:  []string{"reader\n"},
+			expectedAnchored: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDefault, encodeNodes(t, actual))
+
+			p, err = yamlpath.NewPath(tc.path, yamlpath.WithAnchoredRegex())
+			require.NoError(t, err)
+			actual, err = p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedAnchored, encodeNodes(t, actual))
+		})
+	}
+
+	t.Run("bound regular expression", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$[?(@.name =~ $pattern)].name", yamlpath.WithAnchoredRegex())
+		require.NoError(t, err)
+		actual, err := p.FindWithBindings(&n, map[string]*yaml.Node{
+			"pattern": {Kind: yaml.ScalarNode, Tag: "!!str", Value: "admin"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin\n"}, encodeNodes(t, actual))
+	})
+}
+
+func TestNewRelativePath(t *testing.T) {
+	y := `---
+deployments:
+- metadata: {name: web}
+  spec: {replicas: 3, selector: {app: web}}
+- metadata: {name: db}
+  spec: {replicas: 1}
+- metadata: {name: cache}
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	deployments, err := yamlpath.NewPath("$.deployments[*]")
+	require.NoError(t, err)
+	subtrees, err := deployments.Find(&n)
+	require.NoError(t, err)
+	require.Len(t, subtrees, 3)
+
+	cases := []struct {
+		name     string
+		path     string
+		expected [][]string
+	}{
+		{
+			name:     "child",
+			path:     "@.spec.replicas",
+			expected: [][]string{{"3\n"}, {"1\n"}, {}},
+		},
+		{
+			name:     "current node",
+			path:     "@",
+			expected: [][]string{{"metadata: {name: web}\nspec: {replicas: 3, selector: {app: web}}\n"}, {"metadata: {name: db}\nspec: {replicas: 1}\n"}, {"metadata: {name: cache}\n"}},
+		},
+		{
+			name:     "recursive descent",
+			path:     "@..name",
+			expected: [][]string{{"web\n"}, {"db\n"}, {"cache\n"}},
+		},
+		{
+			name:     "filter",
+			path:     "@.spec[?(@.replicas > 1)].replicas",
+			expected: [][]string{{"3\n"}, {}, {}},
+		},
+		{
+			name:     "root in filter refers to subtree",
+			path:     "@.metadata[?($.spec.selector)].name",
+			expected: [][]string{{"web\n"}, {}, {}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rp, err := yamlpath.NewRelativePath(tc.path)
+			require.NoError(t, err)
+			for i, subtree := range subtrees {
+				actual, err := rp.Find(subtree)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected[i], encodeNodes(t, actual), "subtree %d", i)
+			}
+		})
+	}
+
+	t.Run("document", func(t *testing.T) {
+		rp, err := yamlpath.NewRelativePath("@")
+		require.NoError(t, err)
+		actual, err := rp.Find(&n)
+		require.NoError(t, err)
+		require.Equal(t, []*yaml.Node{&n}, actual)
+	})
+
+	t.Run("canonical form", func(t *testing.T) {
+		rp, err := yamlpath.NewRelativePath("@['spec'].replicas")
+		require.NoError(t, err)
+		require.Equal(t, "@.spec.replicas", rp.String())
+		require.True(t, rp.IsSingular())
+	})
+
+	t.Run("explicit root not required", func(t *testing.T) {
+		_, err := yamlpath.NewRelativePath("@.a", yamlpath.WithRequireExplicitRoot())
+		require.NoError(t, err)
+	})
+
+	t.Run("not relative", func(t *testing.T) {
+		_, err := yamlpath.NewRelativePath("$.spec")
+		require.EqualError(t, err, `relative path "$.spec" does not start with @`)
+		_, err = yamlpath.NewRelativePath("spec")
+		require.EqualError(t, err, `relative path "spec" does not start with @`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := yamlpath.NewRelativePath("@x")
+		require.EqualError(t, err, `invalid path syntax at position 1, following "@"`)
+	})
+}
+
+func TestThen(t *testing.T) {
+	y := `---
+spec:
+  containers:
+  - name: a
+    image: nginx
+    ports: [{port: 80}, {port: 443}]
+  - name: b
+    image: redis
+    ports: [{port: 6379}]
+  initContainers:
+  - name: c
+    image: busybox
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		first      string
+		next       string
+		equivalent string
+	}{
+		{
+			name:       "child of each match",
+			first:      "$.spec.containers[*]",
+			next:       "@.image",
+			equivalent: "$.spec.containers[*].image",
+		},
+		{
+			name:       "recursive descent then array access",
+			first:      "$..containers",
+			next:       "@[*].ports[*].port",
+			equivalent: "$..containers[*].ports[*].port",
+		},
+		{
+			name:       "filter in relative path",
+			first:      "$.spec.*[*]",
+			next:       "@[?(@.image=~/^[nb]/)].name",
+			equivalent: "$.spec.*[*][?(@.image=~/^[nb]/)].name",
+		},
+		{
+			name:       "root in filter of relative path",
+			first:      "$.spec.containers[*]",
+			next:       "@[?(@.name==$.spec.initContainers[0].name || @.image=='redis')].name",
+			equivalent: "$.spec.containers[*][?(@.name==$.spec.initContainers[0].name || @.image=='redis')].name",
+		},
+		{
+			name:       "current node",
+			first:      "$.spec.initContainers[0]",
+			next:       "@",
+			equivalent: "$.spec.initContainers[0]",
+		},
+		{
+			name:       "identity",
+			first:      "",
+			next:       "@",
+			equivalent: "@",
+		},
+		{
+			name:       "no matches",
+			first:      "$.nosuch",
+			next:       "@.image",
+			equivalent: "$.nosuch.image",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			first, err := yamlpath.NewPath(tc.first)
+			require.NoError(t, err)
+			next, err := yamlpath.NewRelativePath(tc.next)
+			require.NoError(t, err)
+			equivalent, err := yamlpath.NewPath(tc.equivalent)
+			require.NoError(t, err)
+
+			composite, err := first.Then(next)
+			require.NoError(t, err)
+			expected, err := equivalent.Find(&n)
+			require.NoError(t, err)
+			actual, err := composite.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual)
+			require.Equal(t, equivalent.String(), composite.String())
+			require.True(t, composite.Equal(equivalent))
+		})
+	}
+
+	t.Run("not relative", func(t *testing.T) {
+		first, err := yamlpath.NewPath("$.spec")
+		require.NoError(t, err)
+		next, err := yamlpath.NewPath("$.containers")
+		require.NoError(t, err)
+		composite, err := first.Then(next)
+		require.EqualError(t, err, `path is not relative: Then cannot apply "$.containers" to each match`)
+		require.True(t, errors.Is(err, yamlpath.ErrNotRelative))
+		require.Nil(t, composite)
+	})
+}
+
+func TestFindWithMaxDepth(t *testing.T) {
+	y := `---
+a:
+  b:
+    c:
+      d:
+        e: 1
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		maxDepth        int
+		expectedStrings []string
+		expectedErr     bool
+	}{
+		{
+			name:            "recursive descent within limit",
+			path:            "$..e",
+			maxDepth:        10,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:            "recursive descent at limit",
+			path:            "$..e",
+			maxDepth:        5, // the scalar 1 is five levels below the top level mapping
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:        "recursive descent beyond limit",
+			path:        "$..e",
+			maxDepth:    4,
+			expectedErr: true,
+		},
+		{
+			name:            "recursive descent starting below the top",
+			path:            "$.a.b..e",
+			maxDepth:        3,
+			expectedStrings: []string{"1\n"},
+		},
+		{
+			name:        "recursive descent in filter beyond limit",
+			path:        "$[?(@..d[*])]",
+			maxDepth:    4,
+			expectedErr: true,
+		},
+		{
+			name:            "no recursive descent",
+			path:            "$.a.b.c.d.e",
+			maxDepth:        1,
+			expectedStrings: []string{"1\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, yamlpath.WithMaxDepth(tc.maxDepth))
+			require.NoError(t, err)
+
+			actual, err := p.Find(&n)
+			if tc.expectedErr {
+				require.True(t, errors.Is(err, yamlpath.ErrMaxDepthExceeded), "unexpected error %v", err)
+				require.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindContext(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{a: [{b: 1}, {b: 2}]}`), &n)
+	require.NoError(t, err)
+
+	p, err := yamlpath.NewPath("$..*[?(@.b>1)].b")
+	require.NoError(t, err)
+
+	actual, err := p.FindContext(context.Background(), &n)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2\n"}, encodeNodes(t, actual))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	actual, err = p.FindContext(ctx, &n)
+	require.Equal(t, context.Canceled, err)
+	require.Nil(t, actual)
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	actual, err = p.FindContext(ctx, &n)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Nil(t, actual)
+}
+
+func TestFindWithDocumentNode(t *testing.T) {
+	y := `---
+foo: {bar: 1, name: a}
+items:
+- name: b
+  count: 2
+- name: c
+`
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(y), &doc)
+	require.NoError(t, err)
+	require.Equal(t, yaml.DocumentNode, doc.Kind)
+	content := doc.Content[0]
+
+	paths := []string{
+		"$",
+		"$.foo",
+		"$.foo.bar",
+		"foo.bar",
+		"$['foo', 'items']",
+		"$.*",
+		"$..name",
+		"$..*",
+		"$.items[0]",
+		"$.items[?(@.count)]",
+		"$[?(@.foo)]",
+		"$.items[?(@.name == $.foo.name || @.count > $.foo.bar)]",
+		"$.items[?(@.name == $..name)]",
+		"$.nosuch",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			p, err := yamlpath.NewPath(path)
+			require.NoError(t, err)
+
+			fromDocument, err := p.Find(&doc)
+			require.NoError(t, err)
+			fromContent, err := p.Find(content)
+			require.NoError(t, err)
+			require.Equal(t, fromContent, fromDocument)
+		})
+	}
+}
+
+func TestFindInYAML(t *testing.T) {
+	cases := []struct {
+		name            string
+		path            string
+		yaml            string
+		opts            []yamlpath.Option
+		expectedStrings []string
+		expectedError   string
+	}{
+		{
+			name:            "child",
+			path:            "$.spec.replicas",
+			yaml:            "spec: {replicas: 2}\n",
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "root",
+			path:            "$",
+			yaml:            "spec: {replicas: 2}\n",
+			expectedStrings: []string{"spec: {replicas: 2}\n"},
+		},
+		{
+			name:            "relative path applies to top level node",
+			path:            "@.spec.replicas",
+			yaml:            "spec: {replicas: 2}\n",
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "explicit document start",
+			path:            "$[1]",
+			yaml:            "---\n- a\n- b\n",
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "options",
+			path:            "$.a[*]",
+			yaml:            "a: x\n",
+			opts:            []yamlpath.Option{yamlpath.WithScalarWildcardSelf()},
+			expectedStrings: []string{"x\n"},
+		},
+		{
+			name:            "empty YAML",
+			path:            "$.a",
+			yaml:            "",
+			expectedStrings: []string{},
+		},
+		{
+			name:          "multiple documents",
+			path:          "$.a",
+			yaml:          "a: 1\n---\na: 2\n",
+			expectedError: "YAML contains more than one document: the second document starts at line 2",
+		},
+		{
+			name:          "invalid YAML",
+			path:          "$.a",
+			yaml:          "a: [1\n",
+			expectedError: "yaml: line 1: did not find expected ',' or ']'",
+		},
+		{
+			name:          "invalid path",
+			path:          "$.a[",
+			yaml:          "a: 1\n",
+			expectedError: `unmatched [ at position 4, following ".a["`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := yamlpath.FindInYAML(tc.path, []byte(tc.yaml), tc.opts...)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+
+	t.Run("multiple documents error", func(t *testing.T) {
+		_, err := yamlpath.FindInYAML("$", []byte("a: 1\n---\na: 2\n"))
+		require.True(t, errors.Is(err, yamlpath.ErrMultipleDocuments))
+	})
+}
+
+func TestFindWithBindings(t *testing.T) {
+	y := `---
+servers:
+- name: a
+  env: us-east
+  cpus: 2
+- name: b
+  env: eu-west
+  cpus: 8
+- name: c
+  env: us-east
+  cpus: 16
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	var params yaml.Node
+	err = yaml.Unmarshal([]byte(`{region: us-east, minCpus: 4, regions: [eu-west, ap-south], patterns: {europe: "^eu-"}}`), &params)
+	require.NoError(t, err)
+	var limit yaml.Node
+	err = yaml.Unmarshal([]byte(`8`), &limit)
+	require.NoError(t, err)
+	bindings := map[string]*yaml.Node{
+		"params":   &params,
+		"limit":    &limit,
+		"pattern":  {Kind: yaml.ScalarNode, Tag: "!!str", Value: "^us-"},
+		"other":    {Kind: yaml.ScalarNode, Tag: "!!str", Value: "^ap-"},
+		"invalid":  {Kind: yaml.ScalarNode, Tag: "!!str", Value: "(us"},
+		"disabled": {Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"},
+	}
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+		expectedErr     string
+	}{
+		{
+			name:            "compare with child of binding",
+			path:            "$.servers[?(@.env==$params.region)].name",
+			expectedStrings: []string{"a\n", "c\n"},
+		},
+		{
+			name:            "combine comparisons with bindings",
+			path:            "$.servers[?(@.env==$params.region && @.cpus>=$params.minCpus)].name",
+			expectedStrings: []string{"c\n"},
+		},
+		{
+			name:            "compare with bare binding",
+			path:            "$.servers[?($limit <= @.cpus)].name",
+			expectedStrings: []string{"b\n", "c\n"},
+		},
+		{
+			name:            "compare with index of binding",
+			path:            "$.servers[?(@.env==$params.regions[0])].name",
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "binding existence",
+			path:            "$.servers[?($params.region)].name",
+			expectedStrings: []string{"a\n", "b\n", "c\n"},
+		},
+		{
+			name:            "missing child of binding",
+			path:            "$.servers[?($params.nosuch)].name",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "binding existence, binding false",
+			path:            "$.servers[?($disabled)].name",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "negated binding existence, binding false",
+			path:            "$.servers[?(!$disabled)].name",
+			expectedStrings: []string{"a\n", "b\n", "c\n"},
+		},
+		{
+			name:            "root is unaffected by bindings",
+			path:            "$.servers[?(@.cpus>$.servers[0].cpus)].name",
+			expectedStrings: []string{"b\n", "c\n"},
+		},
+		{
+			name:            "match bound regular expression",
+			path:            "$.servers[?(@.env=~$pattern)].name",
+			expectedStrings: []string{"a\n", "c\n"},
+		},
+		{
+			name:            "negated match of bound regular expression",
+			path:            "$.servers[?(!(@.env =~ $pattern))].name",
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "no match of bound regular expression",
+			path:            "$.servers[?(@.env=~$other)].name",
+			expectedStrings: []string{},
+		},
+		{
+			name:            "match regular expression in child of binding",
+			path:            "$.servers[?(@.env=~$params.patterns.europe)].name",
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "match bound non-string",
+			path:            "$.servers[?(@.env=~$limit)].name",
+			expectedStrings: []string{},
+		},
+		{
+			name:        "match invalid bound regular expression",
+			path:        "$.servers[?(@.env=~$invalid)].name",
+			expectedErr: "invalid regular expression bound to $invalid: error parsing regexp: missing closing ): `(us`",
+		},
+		{
+			name:        "unbound name",
+			path:        "$.servers[?(@.env==$nosuch.region)].name",
+			expectedErr: "no binding for $nosuch",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, err := p.FindWithBindings(&n, bindings)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				require.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+
+	// Find has no bindings
+	p, err := yamlpath.NewPath("$.servers[?(@.env==$params.region)]")
+	require.NoError(t, err)
+	_, err = p.Find(&n)
+	require.EqualError(t, err, "no binding for $params")
+}
+
+func TestFindWithRegexpBindings(t *testing.T) {
+	y := `---
+servers:
+- name: a
+  env: us-west
+- name: b
+  env: EU-west
+- name: c
+  env: us-east
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	bindings := map[string]*yaml.Node{
+		"region":  {Kind: yaml.ScalarNode, Tag: "!!str", Value: "us-east"},
+		"pattern": {Kind: yaml.ScalarNode, Tag: "!!str", Value: "^ap-"},
+		"suffix":  {Kind: yaml.ScalarNode, Tag: "!!str", Value: "-east$"},
+	}
+	regexps := map[string]*regexp.Regexp{
+		"pattern": regexp.MustCompile(`(?i)^eu-`),
+		"west":    regexp.MustCompile(`west`),
+	}
+
+	cases := []struct {
+		name            string
+		path            string
+		opts            []yamlpath.Option
+		expectedStrings []string
+		expectedErr     string
+	}{
+		{
+			name:            "match compiled regular expression",
+			path:            "$.servers[?(@.env=~$west)].name",
+			expectedStrings: []string{"a\n", "b\n"},
+		},
+		{
+			name:            "compiled regular expression takes precedence over binding",
+			path:            "$.servers[?(@.env=~$pattern)].name",
+			expectedStrings: []string{"b\n"},
+		},
+		{
+			name:            "negated match of compiled regular expression",
+			path:            "$.servers[?(@.env!~$west)].name",
+			expectedStrings: []string{"c\n"},
+		},
+		{
+			name:            "match anchored compiled regular expression",
+			path:            "$.servers[?(@.env=~$west)].name",
+			opts:            []yamlpath.Option{yamlpath.WithAnchoredRegex()},
+			expectedStrings: []string{},
+		},
+		{
+			name:            "match bound string",
+			path:            "$.servers[?(@.env=~$suffix)].name",
+			expectedStrings: []string{"c\n"},
+		},
+		{
+			name:            "compare with binding",
+			path:            "$.servers[?(@.env==$region)].name",
+			expectedStrings: []string{"c\n"},
+		},
+		{
+			name:        "compiled regular expression is not a node",
+			path:        "$.servers[?(@.env==$west)].name",
+			expectedErr: "no binding for $west",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			actual, err := p.FindWithRegexpBindings(&n, bindings, regexps)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				require.Nil(t, actual)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func TestFindComments(t *testing.T) {
+	y := `# head of document
+
+# head of a
+a: 1 # line of a
+# foot of a
+
+b:
+  # head of c
+  c: x # line of c
+  d: [1, 2] # line of d
+list:
+  # head of one
+  - one # line of one
+  - two
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	type comments struct {
+		head string
+		line string
+		foot string
+	}
+	cases := []struct {
+		name     string
+		path     string
+		expected []comments
+	}{
+		{
+			name: "value of top level mapping",
+			path: "$.a",
+			expected: []comments{
+				{head: "# head of a", line: "# line of a", foot: "# foot of a"},
+			},
+		},
+		{
+			name: "value of nested mapping",
+			path: "$.b.c",
+			expected: []comments{
+				{head: "# head of c", line: "# line of c"},
+			},
+		},
+		{
+			name: "sequence with line comment",
+			path: "$.b.d",
+			expected: []comments{
+				{line: "# line of d"},
+			},
+		},
+		{
+			name: "elements of sequence",
+			path: "$.list[*]",
+			expected: []comments{
+				{head: "# head of one", line: "# line of one"},
+				{},
+			},
+		},
+		{
+			name: "document",
+			path: "",
+			expected: []comments{
+				{head: "# head of document"},
+			},
+		},
+		{
+			name:     "no match",
+			path:     "$.nosuch",
+			expected: []comments{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			found, err := p.FindComments(&n)
+			require.NoError(t, err)
+
+			nodes, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Len(t, found, len(nodes))
+
+			actual := []comments{}
+			for i, f := range found {
+				require.Same(t, nodes[i], f.Node)
+				actual = append(actual, comments{head: f.HeadComment, line: f.LineComment, foot: f.FootComment})
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestFindWithDistinctResults(t *testing.T) {
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(`{a: {a: {b: 1}, c: [2, 3]}}`), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		expectedStrings []string
+		distinctStrings []string
+	}{
+		{
+			name:            "nested recursive descent",
+			path:            "$..a..b",
+			expectedStrings: []string{"1\n", "1\n"},
+			distinctStrings: []string{"1\n"},
+		},
+		{
+			name:            "recursive descent of wildcards",
+			path:            "$..*..*",
+			expectedStrings: []string{"{b: 1}\n", "[2, 3]\n", "1\n", "2\n", "3\n", "1\n", "2\n", "3\n"},
+			distinctStrings: []string{"{b: 1}\n", "[2, 3]\n", "1\n", "2\n", "3\n"},
+		},
+		{
+			name:            "union of indices",
+			path:            "$.a.c[1,0,1]",
+			expectedStrings: []string{"3\n", "2\n", "3\n"},
+			distinctStrings: []string{"3\n", "2\n"},
+		},
+		{
+			name:            "no duplicates",
+			path:            "$.a.c[*]",
+			expectedStrings: []string{"2\n", "3\n"},
+			distinctStrings: []string{"2\n", "3\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+			actual, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+
+			p, err = yamlpath.NewPath(tc.path, yamlpath.WithDistinctResults())
+			require.NoError(t, err)
+			actual, err = p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.distinctStrings, encodeNodes(t, actual))
+		})
+	}
+}
+
+func encodeNodes(t *testing.T, nodes []*yaml.Node) []string {
+	strs := []string{}
+	for _, a := range nodes {
+		var buf bytes.Buffer
+		e := yaml.NewEncoder(&buf)
+		e.SetIndent(2)
+
+		err := e.Encode(a)
+		require.NoError(t, err)
+		e.Close()
+		strs = append(strs, buf.String())
+	}
+	return strs
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PathTo returns the canonical path, as rendered by the String method of Path, which selects the given target node
+// when applied to the given node, for example `$.spec.containers[0].image`. The target is compared by address. The
+// path of the given node itself, or of its content if it is a document node, is `$`, and the path of a key in a
+// mapping selects the key by its property name, for example `$.spec~`.
+//
+// PathTo returns an error if the target is not reachable from the given node by a path, for example because it is
+// not in the document, it is reached only through an alias, or it is a key which duplicates an earlier key in the
+// same mapping or the value of such a key.
+func PathTo(node, target *yaml.Node) (string, error) {
+	if target != nil {
+		if target == node {
+			return root, nil
+		}
+		top := node
+		if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+			top = node.Content[0]
+		}
+		if path, ok := pathTo(top, target, root); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("node %s is not reachable from the root", describeNode(target))
+}
+
+// pathTo returns the path to the target from the given node, whose path is the given prefix, and true, or false if
+// the target is not reachable from the node.
+func pathTo(node, target *yaml.Node, prefix string) (string, bool) {
+	if node == target {
+		return prefix, true
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		seen := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yaml.ScalarNode || seen[key.Value] {
+				continue // not selectable by a child name
+			}
+			seen[key.Value] = true
+			child := prefix + canonicalChild(key.Value)
+			if key == target {
+				return child + propertyName, true
+			}
+			if path, ok := pathTo(node.Content[i+1], target, child); ok {
+				return path, true
+			}
+		}
+
+	case yaml.SequenceNode:
+		for i, c := range node.Content {
+			if path, ok := pathTo(c, target, prefix+leftBracket+strconv.Itoa(i)+rightBracket); ok {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+func describeNode(n *yaml.Node) string {
+	if n == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("at line %d, column %d", n.Line, n.Column)
+}
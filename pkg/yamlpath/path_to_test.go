@@ -0,0 +1,151 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPathTo(t *testing.T) {
+	y := `---
+spec:
+  replicas: 2
+  containers:
+  - name: nginx
+    image: nginx:1.19
+    ports: [80, 443]
+  - name: sidecar
+    args: [[a, b], [c]]
+"a key": &anchored
+  "it's": x
+  "": empty
+  1: one
+alias: *anchored
+dup: first
+dup: second
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+	top := n.Content[0]
+	spec := top.Content[1]
+	containers := spec.Content[3]
+	nginx := containers.Content[0]
+	sidecar := containers.Content[1]
+	aKey := top.Content[3]
+
+	cases := []struct {
+		name     string
+		target   *yaml.Node
+		expected string
+	}{
+		{name: "top level node", target: top, expected: "$"},
+		{name: "child", target: spec, expected: "$.spec"},
+		{name: "grandchild", target: spec.Content[1], expected: "$.spec.replicas"},
+		{name: "sequence element", target: nginx, expected: "$.spec.containers[0]"},
+		{name: "child of sequence element", target: nginx.Content[3], expected: "$.spec.containers[0].image"},
+		{name: "element of flow sequence", target: nginx.Content[5].Content[1], expected: "$.spec.containers[0].ports[1]"},
+		{name: "nested sequences", target: sidecar.Content[3].Content[0].Content[1], expected: "$.spec.containers[1].args[0][1]"},
+		{name: "key", target: spec.Content[2], expected: "$.spec.containers~"},
+		{name: "key of sequence element", target: sidecar.Content[0], expected: "$.spec.containers[1].name~"},
+		{name: "child name requiring brackets", target: aKey, expected: "$['a key']"},
+		{name: "child name with quote", target: aKey.Content[1], expected: `$['a key']['it\'s']`},
+		{name: "empty child name", target: aKey.Content[3], expected: "$['a key']['']"},
+		{name: "numeric child name", target: aKey.Content[5], expected: "$['a key'].1"},
+		{name: "alias", target: top.Content[5], expected: "$.alias"},
+		{name: "first of duplicate keys", target: top.Content[7], expected: "$.dup"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := yamlpath.PathTo(&n, tc.target)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, path)
+
+			p, err := yamlpath.NewPath(path)
+			require.NoError(t, err)
+			require.Equal(t, path, p.String())
+			results, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, []*yaml.Node{tc.target}, results)
+
+			path, err = yamlpath.PathTo(top, tc.target)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, path)
+		})
+	}
+
+	t.Run("every node", func(t *testing.T) {
+		var visit func(node *yaml.Node)
+		visit = func(node *yaml.Node) {
+			path, err := yamlpath.PathTo(&n, node)
+			if node == top.Content[8] || node == top.Content[9] {
+				require.Error(t, err) // a duplicate key and its value
+				return
+			}
+			require.NoError(t, err)
+			p, err := yamlpath.NewPath(path)
+			require.NoError(t, err)
+			results, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, []*yaml.Node{node}, results, "path %s", path)
+			for _, c := range node.Content {
+				visit(c)
+			}
+		}
+		visit(top)
+	})
+
+	t.Run("document node", func(t *testing.T) {
+		path, err := yamlpath.PathTo(&n, &n)
+		require.NoError(t, err)
+		require.Equal(t, "$", path)
+	})
+
+	errorCases := []struct {
+		name     string
+		node     *yaml.Node
+		target   *yaml.Node
+		expected string
+	}{
+		{
+			name:     "node reached only through an alias",
+			node:     top.Content[5],
+			target:   aKey.Content[1],
+			expected: "node at line 11, column 11 is not reachable from the root",
+		},
+		{
+			name:     "value of duplicate key",
+			node:     &n,
+			target:   top.Content[9],
+			expected: "node at line 16, column 6 is not reachable from the root",
+		},
+		{
+			name:     "node not in the document",
+			node:     &n,
+			target:   &yaml.Node{Kind: yaml.ScalarNode, Value: "x"},
+			expected: "node at line 0, column 0 is not reachable from the root",
+		},
+		{
+			name:     "nil",
+			node:     &n,
+			target:   nil,
+			expected: "node nil is not reachable from the root",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := yamlpath.PathTo(tc.node, tc.target)
+			require.EqualError(t, err, tc.expected)
+		})
+	}
+}
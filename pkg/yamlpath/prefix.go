@@ -0,0 +1,83 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// MatchDepth applies successively longer prefixes of the Path to the given root node and returns the number of
+// leading segments of the Path which matched at least one node, together with the nodes matched by those segments.
+// A segment is a child, array subscript, filter, recursive descent, or other step following the root, so, for
+// example, `$.spec.containers[0].image` has four segments. If the whole Path matches, MatchDepth returns the number
+// of segments and the matches of the Path. If the first segment matches nothing, MatchDepth returns 0 and the nodes
+// matched by the root alone.
+//
+// MatchDepth is intended for suggesting how a path which matches nothing might be continued, for example by
+// listing the keys of the nodes it returns. A segment whose evaluation fails, for instance with an unbound binding,
+// is treated as matching nothing.
+func (p *Path) MatchDepth(root *yaml.Node) (int, []*yaml.Node) {
+	lexemes := lexAll(p.expr)
+	ends := segmentEnds(lexemes)
+
+	reached, ok := p.findPrefix(lexemes[:ends[0]], root)
+	if !ok || len(reached) == 0 {
+		return 0, reached
+	}
+	for depth, end := range ends[1:] {
+		matches, ok := p.findPrefix(lexemes[:end], root)
+		if !ok || len(matches) == 0 {
+			return depth, reached
+		}
+		reached = matches
+	}
+	return len(ends) - 1, reached
+}
+
+// findPrefix applies the path consisting of the given lexemes, with the options of the Path, to the given root node.
+func (p *Path) findPrefix(lexemes []lexeme, root *yaml.Node) ([]*yaml.Node, bool) {
+	prefix, err := compile(canonical(lexemes), p.opts)
+	if err != nil {
+		return nil, false
+	}
+	matches, err := prefix.Find(root)
+	if err != nil {
+		return nil, false
+	}
+	return matches, true
+}
+
+// segmentEnds returns the index just past the root of the given lexemes, if any, followed by the index just past
+// each segment following the root. A filter, including any nested filters, is a single segment, as is a recursive
+// descent `..` together with the array subscript or filter which follows it.
+func segmentEnds(lexemes []lexeme) []int {
+	ends := []int{}
+	start := 0
+	if len(lexemes) > 0 && (lexemes[0].typ == lexemeRoot || lexemes[0].typ == lexemeFilterAt) {
+		start = 1
+	}
+	ends = append(ends, start)
+
+	filterNestingLevel := 0
+	for i := start; i < len(lexemes); i++ {
+		switch lexemes[i].typ {
+		case lexemeError, lexemeIdentity, lexemeEOF:
+			return ends
+
+		case lexemeFilterBegin, lexemeRecursiveFilterBegin:
+			filterNestingLevel++
+
+		case lexemeFilterEnd:
+			filterNestingLevel--
+		}
+		if filterNestingLevel > 0 || lexemes[i].typ == lexemeRecursiveDescent && lexemes[i].val == recursiveDescent {
+			continue
+		}
+		ends = append(ends, i+1)
+	}
+	return ends
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMatchDepth(t *testing.T) {
+	y := `---
+spec:
+  replicas: 2
+  containers:
+  - name: nginx
+    image: nginx:1.19
+  - name: sidecar
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name            string
+		path            string
+		opts            []yamlpath.Option
+		expectedDepth   int
+		expectedStrings []string
+	}{
+		{
+			name:            "full match",
+			path:            "$.spec.containers[0].image",
+			expectedDepth:   4,
+			expectedStrings: []string{"nginx:1.19\n"},
+		},
+		{
+			name:            "partial match",
+			path:            "$.spec.containers[1].image",
+			expectedDepth:   3,
+			expectedStrings: []string{"name: sidecar\n"},
+		},
+		{
+			name:            "partial match of several nodes",
+			path:            "$.spec.containers[*].ports[0]",
+			expectedDepth:   3,
+			expectedStrings: []string{"name: nginx\nimage: nginx:1.19\n", "name: sidecar\n"},
+		},
+		{
+			name:            "no match",
+			path:            "$.metadata.name",
+			expectedDepth:   0,
+			expectedStrings: []string{"spec:\n  replicas: 2\n  containers:\n    - name: nginx\n      image: nginx:1.19\n    - name: sidecar\n"},
+		},
+		{
+			name:            "root only",
+			path:            "$",
+			expectedDepth:   0,
+			expectedStrings: []string{"spec:\n  replicas: 2\n  containers:\n    - name: nginx\n      image: nginx:1.19\n    - name: sidecar\n"},
+		},
+		{
+			name:            "implicit root",
+			path:            "spec.replicas.value",
+			expectedDepth:   2,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "filter is a single segment",
+			path:            "$.spec.containers[?(@.name == 'nginx' && @.image)].ports",
+			expectedDepth:   3,
+			expectedStrings: []string{"name: nginx\nimage: nginx:1.19\n"},
+		},
+		{
+			name:            "filter with modulo",
+			path:            "$.spec[?(@.replicas % 2 == 0)].replicas.value",
+			expectedDepth:   3,
+			expectedStrings: []string{"2\n"},
+		},
+		{
+			name:            "filter matching nothing",
+			path:            "$.spec.containers[?(@.name == 'other')].image",
+			expectedDepth:   2,
+			expectedStrings: []string{"- name: nginx\n  image: nginx:1.19\n- name: sidecar\n"},
+		},
+		{
+			name:            "recursive descent with array subscript is a single segment",
+			path:            "$..[0].image.tag",
+			expectedDepth:   2,
+			expectedStrings: []string{"nginx:1.19\n"},
+		},
+		{
+			name:            "recursive descent with child name",
+			path:            "$..name.first",
+			expectedDepth:   1,
+			expectedStrings: []string{"nginx\n", "sidecar\n"},
+		},
+		{
+			name:            "property name",
+			path:            "$.spec.replicas~",
+			expectedDepth:   2,
+			expectedStrings: []string{"replicas\n"},
+		},
+		{
+			name:            "options apply to prefixes",
+			path:            "$.spec.replicas.*.value",
+			opts:            []yamlpath.Option{yamlpath.WithScalarWildcardSelf()},
+			expectedDepth:   3,
+			expectedStrings: []string{"2\n"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			depth, nodes := p.MatchDepth(&n)
+			require.Equal(t, tc.expectedDepth, depth)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, nodes))
+		})
+	}
+
+	t.Run("relative path", func(t *testing.T) {
+		p, err := yamlpath.NewRelativePath("@.spec.containers[0].ports")
+		require.NoError(t, err)
+
+		depth, nodes := p.MatchDepth(n.Content[0])
+		require.Equal(t, 3, depth)
+		require.Equal(t, []string{"name: nginx\nimage: nginx:1.19\n"}, encodeNodes(t, nodes))
+	})
+}
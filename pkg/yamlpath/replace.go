@@ -0,0 +1,53 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReplaceRegex applies the Path to the given node, as Find does, and replaces the value of each matched scalar with
+// the result of applying the given regular expression's ReplaceAllString, with the given replacement, to the value.
+// So, for example, applying `$..image` with the regular expression `:1\.19$` and the replacement `:1.20` bumps the tag
+// of every `nginx:1.19` image. Matches which are not scalars are skipped and a scalar matched more than once is
+// replaced only once.
+//
+// The tag of a scalar which is not explicitly tagged is resolved again from its new value, just as if the new value
+// had been parsed, so that replacing the plain scalar `v2` by `2` produces an integer. ReplaceRegex returns the number
+// of scalars whose values were changed.
+func (p *Path) ReplaceRegex(root *yaml.Node, re *regexp.Regexp, repl string) (int, error) {
+	results, err := p.Find(root)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	seen := map[*yaml.Node]struct{}{}
+	for _, r := range results {
+		if r.Kind != yaml.ScalarNode {
+			continue
+		}
+		if _, ok := seen[r]; ok {
+			continue
+		}
+		seen[r] = struct{}{}
+
+		value := re.ReplaceAllString(r.Value, repl)
+		if value == r.Value {
+			continue
+		}
+		r.Value = value
+		if r.Style&yaml.TaggedStyle == 0 {
+			r.Tag = ""
+			r.Tag = r.ShortTag()
+		}
+		changed++
+	}
+	return changed, nil
+}
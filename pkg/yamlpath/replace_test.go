@@ -0,0 +1,137 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestReplaceRegex(t *testing.T) {
+	cases := []struct {
+		name            string
+		yaml            string
+		path            string
+		re              string
+		repl            string
+		expected        string
+		expectedChanged int
+	}{
+		{
+			name:            "bumping image tags",
+			yaml:            "containers:\n  - image: nginx:1.19\n  - image: redis:6\n  - image: nginx:1.19-alpine\nimage: nginx:1.19\n",
+			path:            "$.containers[*].image",
+			re:              `^nginx:1\.19`,
+			repl:            "nginx:1.20",
+			expected:        "containers:\n  - image: nginx:1.20\n  - image: redis:6\n  - image: nginx:1.20-alpine\nimage: nginx:1.19\n",
+			expectedChanged: 2,
+		},
+		{
+			name:            "submatches",
+			yaml:            "a: [x-1, y-2, z]\n",
+			path:            "$.a[*]",
+			re:              `^(\w)-(\d)$`,
+			repl:            "$2-$1",
+			expected:        "a: [1-x, 2-y, z]\n",
+			expectedChanged: 2,
+		},
+		{
+			name:            "no scalar changed",
+			yaml:            "a: [x, y]\n",
+			path:            "$.a[*]",
+			re:              `z`,
+			repl:            "w",
+			expected:        "a: [x, y]\n",
+			expectedChanged: 0,
+		},
+		{
+			name:            "non-scalar matches skipped",
+			yaml:            "a: {b: ab, c: [ab]}\n",
+			path:            "$.a.*",
+			re:              `a`,
+			repl:            "x",
+			expected:        "a: {b: xb, c: [ab]}\n",
+			expectedChanged: 1,
+		},
+		{
+			name:            "scalar matched more than once",
+			yaml:            "a: [b]\n",
+			path:            "$.a[0,0]",
+			re:              `b`,
+			repl:            "bb",
+			expected:        "a: [bb]\n",
+			expectedChanged: 1,
+		},
+		{
+			name:            "tag resolved from new value",
+			yaml:            "a: [v2, 'v3', !!str v4]\n",
+			path:            "$.a[*]",
+			re:              `^v`,
+			repl:            "",
+			expected:        "a: [2, '3', !!str 4]\n",
+			expectedChanged: 3,
+		},
+		{
+			name:            "no match",
+			yaml:            "a: x\n",
+			path:            "$.b",
+			re:              `x`,
+			repl:            "y",
+			expected:        "a: x\n",
+			expectedChanged: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var n yaml.Node
+			err := yaml.Unmarshal([]byte(tc.yaml), &n)
+			require.NoError(t, err)
+
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			changed, err := p.ReplaceRegex(&n, regexp.MustCompile(tc.re), tc.repl)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedChanged, changed)
+			require.Equal(t, tc.expected, encodeNodes(t, []*yaml.Node{&n})[0])
+		})
+	}
+
+	t.Run("tag of changed value", func(t *testing.T) {
+		var n yaml.Node
+		err := yaml.Unmarshal([]byte("a: [v2, 'v3']\n"), &n)
+		require.NoError(t, err)
+
+		p, err := yamlpath.NewPath("$.a[*]")
+		require.NoError(t, err)
+		_, err = p.ReplaceRegex(&n, regexp.MustCompile(`^v`), "")
+		require.NoError(t, err)
+
+		q, err := yamlpath.NewPath("$.a[?(@ == 2)]")
+		require.NoError(t, err)
+		results, err := q.Find(&n)
+		require.NoError(t, err)
+		require.Equal(t, []string{"2\n"}, encodeNodes(t, results))
+	})
+
+	t.Run("path which fails", func(t *testing.T) {
+		var n yaml.Node
+		err := yaml.Unmarshal([]byte("a: x\n"), &n)
+		require.NoError(t, err)
+
+		p, err := yamlpath.NewPath("$[?(@.a == $unbound)]")
+		require.NoError(t, err)
+		changed, err := p.ReplaceRegex(&n, regexp.MustCompile(`x`), "y")
+		require.EqualError(t, err, "no binding for $unbound")
+		require.Equal(t, 0, changed)
+	})
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dprotaso/go-yit"
+	"gopkg.in/yaml.v3"
+)
+
+// simpleStep appends to matches the nodes selected from the given node by a single segment of a simple path.
+type simpleStep func(node *yaml.Node, matches []*yaml.Node, e *evaluation) []*yaml.Node
+
+// lexAll returns all the lexemes of the given path up to and including the first EOF or error lexeme.
+func lexAll(path string) []lexeme {
+	return lexemesOf(lex("Path lexer", path))
+}
+
+// lexemesOf returns all the lexemes scanned by the given lexer up to and including the first EOF or error lexeme.
+func lexemesOf(l *lexer) []lexeme {
+	lexemes := []lexeme{}
+	for {
+		lx := l.nextLexeme()
+		lexemes = append(lexemes, lx)
+		if lx.typ == lexemeEOF || lx.typ == lexemeError {
+			return lexemes
+		}
+	}
+}
+
+// IsSingular returns true if and only if the Path is definite, that is it consists solely of an optional root followed by
+// named children and single array indices and so matches at most one node in any document. Paths containing a
+// wildcard, slice, union, filter, recursive descent, or property name operator are not singular.
+//
+// Note that a bracket child, such as `['a']`, matches every child with the given name and so may match more than one
+// node in a mapping with duplicate keys.
+func (p *Path) IsSingular() bool {
+	_, ok := newSimplePath(lexAll(p.expr))
+	return ok
+}
+
+// newSimplePath returns a Path equivalent to the given lexemes, and true, if and only if the lexemes consist solely of
+// an optional root followed by named children and single array indices. Such paths are evaluated by walking the
+// YAML nodes directly rather than by composing iterators.
+func newSimplePath(lexemes []lexeme) (*Path, bool) {
+	rooted := false
+	steps := []simpleStep{}
+	for i, lx := range lexemes {
+		switch lx.typ {
+		case lexemeRoot:
+			if i != 0 {
+				return nil, false
+			}
+			rooted = true
+
+		case lexemeFilterAt:
+			if i != 0 {
+				return nil, false
+			}
+
+		case lexemeDotChild, lexemeUndottedChild:
+			childName := strings.TrimPrefix(lx.val, ".")
+			if lx.typ == lexemeUndottedChild {
+				childName = lx.val
+			}
+			if childName == "*" {
+				return nil, false
+			}
+			steps = append(steps, simpleChild(unescape(childName)))
+
+		case lexemeBracketChild:
+			childNames := strings.TrimSpace(lx.val)
+			childNames = strings.TrimSuffix(strings.TrimPrefix(childNames, "["), "]")
+			unquotedChildren := bracketChildNames(strings.TrimSpace(childNames))
+			if len(unquotedChildren) != 1 {
+				return nil, false
+			}
+			steps = append(steps, simpleBracketChild(unquotedChildren[0]))
+
+		case lexemeArraySubscript:
+			index, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(lx.val, "["), "]")))
+			if err != nil {
+				return nil, false // not a single index
+			}
+			steps = append(steps, simpleIndex(index))
+
+		case lexemeIdentity, lexemeEOF:
+
+		default:
+			return nil, false
+		}
+	}
+
+	return new(func(node, root *yaml.Node, e *evaluation) yit.Iterator {
+		if rooted && node.Kind == yaml.DocumentNode {
+			node = node.Content[0]
+		}
+		matches := []*yaml.Node{node}
+		for _, step := range steps {
+			next := []*yaml.Node{}
+			for _, m := range matches {
+				next = step(m, next, e)
+			}
+			matches = next
+		}
+		results := []*yaml.Node{}
+		for _, m := range matches {
+			if m.Kind != 0 { // consistent with identity
+				results = append(results, m)
+			}
+		}
+		return yit.FromNodes(results...)
+	}), true
+}
+
+func simpleChild(childName string) simpleStep {
+	return func(node *yaml.Node, matches []*yaml.Node, e *evaluation) []*yaml.Node {
+		if node.Kind != yaml.MappingNode {
+			return matches
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == childName {
+				return append(matches, node.Content[i+1])
+			}
+		}
+		return matches
+	}
+}
+
+func simpleBracketChild(childName string) simpleStep {
+	return func(node *yaml.Node, matches []*yaml.Node, e *evaluation) []*yaml.Node {
+		if node.Kind != yaml.MappingNode {
+			return matches
+		}
+		// unlike a dot child, a bracket child matches all the children with the given name
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == childName {
+				matches = append(matches, node.Content[i+1])
+			}
+		}
+		return matches
+	}
+}
+
+func simpleIndex(index int) simpleStep {
+	return func(node *yaml.Node, matches []*yaml.Node, e *evaluation) []*yaml.Node {
+		if node.Kind == yaml.MappingNode {
+			return append(matches, indexOfMapping(node, strconv.Itoa(index), index, e)...)
+		}
+		if node.Kind != yaml.SequenceNode {
+			return matches
+		}
+		i := index
+		if i < 0 {
+			i += len(node.Content)
+		}
+		if i < 0 || i >= len(node.Content) {
+			return matches
+		}
+		return append(matches, node.Content[i])
+	}
+}
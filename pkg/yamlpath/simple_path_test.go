@@ -0,0 +1,208 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const simplePathDoc = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: sample-deployment
+  labels:
+    app.kubernetes.io/name: sample
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx
+      - name: nginy
+        image: nginy
+  ports:
+    80: http
+    443: https
+`
+
+func TestSimplePath(t *testing.T) {
+	cases := []struct {
+		name   string
+		path   string
+		simple bool
+		focus  bool // if true, run only tests with focus set to true
+	}{
+		{
+			name:   "identity",
+			path:   "",
+			simple: true,
+		},
+		{
+			name:   "root",
+			path:   "$",
+			simple: true,
+		},
+		{
+			name:   "dot children",
+			path:   "$.spec.template.spec.containers",
+			simple: true,
+		},
+		{
+			name:   "implicit root",
+			path:   "spec.template",
+			simple: true,
+		},
+		{
+			name:   "missing child",
+			path:   "$.spec.nosuch.spec",
+			simple: true,
+		},
+		{
+			name:   "bracket child",
+			path:   "$.metadata.labels['app.kubernetes.io/name']",
+			simple: true,
+		},
+		{
+			name:   "escaped dot child",
+			path:   `$.metadata.labels.app\.kubernetes\.io/name`,
+			simple: true,
+		},
+		{
+			name:   "index",
+			path:   "$.spec.template.spec.containers[1].image",
+			simple: true,
+		},
+		{
+			name:   "negative index",
+			path:   "$.spec.template.spec.containers[-2].name",
+			simple: true,
+		},
+		{
+			name:   "index out of range",
+			path:   "$.spec.template.spec.containers[2]",
+			simple: true,
+		},
+		{
+			name:   "index of mapping",
+			path:   "$.spec[0]",
+			simple: true,
+		},
+		{
+			name:   "index of mapping with integer keys",
+			path:   "$.spec.ports[80]",
+			simple: true,
+		},
+		{
+			name:   "wildcard",
+			path:   "$.spec.template.spec.containers[*].image",
+			simple: false,
+		},
+		{
+			name:   "dot wildcard",
+			path:   "$.*",
+			simple: false,
+		},
+		{
+			name:   "slice",
+			path:   "$.spec.template.spec.containers[0:1]",
+			simple: false,
+		},
+		{
+			name:   "union of children",
+			path:   "$['kind','apiVersion']",
+			simple: false,
+		},
+		{
+			name:   "recursive descent",
+			path:   "$..image",
+			simple: false,
+		},
+		{
+			name:   "filter",
+			path:   "$.spec.template.spec.containers[?(@.name=='nginx')]",
+			simple: false,
+		},
+		{
+			name:   "property name",
+			path:   "$.spec~",
+			simple: false,
+		},
+		{
+			name:   "syntax error",
+			path:   "$.spec.",
+			simple: false,
+		},
+	}
+
+	focussed := false
+	for _, tc := range cases {
+		if tc.focus {
+			focussed = true
+			break
+		}
+	}
+
+	for _, tc := range cases {
+		if focussed && !tc.focus {
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			var n yaml.Node
+			err := yaml.Unmarshal([]byte(simplePathDoc), &n)
+			require.NoError(t, err)
+
+			simple, ok := newSimplePath(lexAll(tc.path))
+			require.Equal(t, tc.simple, ok)
+			if !ok {
+				return
+			}
+
+			general, err := newPath(lex("Path lexer", tc.path), newOptions(nil))
+			require.NoError(t, err)
+
+			require.Equal(t, general.find(&n, &n, &evaluation{}), simple.find(&n, &n, &evaluation{}))
+		})
+	}
+
+	if focussed {
+		t.Fatalf("testcase(s) still focussed")
+	}
+}
+
+func BenchmarkSimplePath(b *testing.B) {
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(simplePathDoc), &n); err != nil {
+		b.Fatal(err)
+	}
+	const path = "$.spec.template.spec.containers[1].image"
+
+	b.Run("fast path", func(b *testing.B) {
+		p, ok := newSimplePath(lexAll(path))
+		if !ok {
+			b.Fatal("not a simple path")
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p.find(&n, &n, &evaluation{})
+		}
+	})
+
+	b.Run("general path", func(b *testing.B) {
+		p, err := newPath(lex("Path lexer", path), newOptions(nil))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p.find(&n, &n, &evaluation{})
+		}
+	})
+}
@@ -141,3 +141,39 @@ func indices(from, to, step, length int) []int {
 	}
 	return slice
 }
+
+// checkInvertedSlices returns an error if any slice in the given array subscript, such as `5:2`, has explicit start
+// and end indices of the same sign which are inverted with respect to the step, so that the slice is empty
+// regardless of the length of the array. See WithStrictSlices.
+func checkInvertedSlices(index string) error {
+	for _, member := range strings.Split(index, ",") {
+		subscr := strings.Split(strings.TrimSpace(member), ":")
+		if len(subscr) < 2 {
+			continue
+		}
+		from, err := strconv.Atoi(strings.TrimSpace(subscr[0]))
+		if err != nil {
+			continue // start is omitted
+		}
+		to, err := strconv.Atoi(strings.TrimSpace(subscr[1]))
+		if err != nil {
+			continue // end is omitted
+		}
+		step := 1
+		if len(subscr) == 3 {
+			if s, err := strconv.Atoi(strings.TrimSpace(subscr[2])); err == nil {
+				step = s
+			}
+		}
+		if (from < 0) != (to < 0) {
+			continue // whether the slice is empty depends on the length of the array
+		}
+		if step > 0 && from > to {
+			return fmt.Errorf("slice %s is empty since its start is greater than its end and its step is positive", strings.TrimSpace(member))
+		}
+		if step < 0 && from < to {
+			return fmt.Errorf("slice %s is empty since its start is less than its end and its step is negative", strings.TrimSpace(member))
+		}
+	}
+	return nil
+}
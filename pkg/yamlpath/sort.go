@@ -0,0 +1,116 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FindSortedBy applies the Path to the given node, as Find does, and sorts the matches by the value of the given
+// subpath, such as `.spec.priority` or `name`, applied to each match. The subpath is applied with the same options
+// as the Path and its root, `$`, refers to the match.
+//
+// The first node matched by the subpath is the sort key of a match. Integer and floating point keys are compared
+// numerically and sort before any other scalar keys, which are compared lexically. Matches which have no key, or
+// whose key is a mapping or sequence, sort last in either direction. Matches with equal keys keep the order in which
+// Find returns them.
+func (p *Path) FindSortedBy(root *yaml.Node, subpath string, ascending bool) ([]*yaml.Node, error) {
+	key, err := compile(subpath, p.opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sort subpath %q: %w", subpath, err)
+	}
+
+	results, err := p.Find(root)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]sortKey, len(results))
+	for i, r := range results {
+		k, err := key.Find(r)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = newSortKey(k)
+	}
+
+	indices := make([]int, len(results))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return keys[indices[i]].less(keys[indices[j]], ascending)
+	})
+
+	sorted := make([]*yaml.Node, len(results))
+	for i, index := range indices {
+		sorted[i] = results[index]
+	}
+	return sorted, nil
+}
+
+// sortKeyClass orders sort keys of different kinds.
+type sortKeyClass int
+
+const (
+	numericSortKey sortKeyClass = iota
+	scalarSortKey
+	missingSortKey
+)
+
+type sortKey struct {
+	class sortKeyClass
+	value typedValue
+}
+
+// newSortKey returns the sort key corresponding to the nodes matched by a sort subpath.
+func newSortKey(nodes []*yaml.Node) sortKey {
+	if len(nodes) == 0 || nodes[0].Kind != yaml.ScalarNode {
+		return sortKey{class: missingSortKey}
+	}
+	v := typedValueOfNode(nodes[0])
+	if v.typ.isNumeric() {
+		return sortKey{class: numericSortKey, value: v}
+	}
+	return sortKey{class: scalarSortKey, value: v}
+}
+
+// less returns true if and only if the sort key k sorts before the other sort key in the given direction.
+func (k sortKey) less(other sortKey, ascending bool) bool {
+	if k.class != other.class {
+		if k.class == missingSortKey || other.class == missingSortKey {
+			return other.class == missingSortKey
+		}
+		return (k.class < other.class) == ascending
+	}
+
+	var c comparison
+	switch k.class {
+	case numericSortKey:
+		c = compareNodeValues(k.value, other.value)
+
+	case scalarSortKey:
+		switch {
+		case k.value.val < other.value.val:
+			c = compareLessThan
+		case k.value.val > other.value.val:
+			c = compareGreaterThan
+		default:
+			c = compareEqual
+		}
+
+	default:
+		return false
+	}
+	if ascending {
+		return c == compareLessThan
+	}
+	return c == compareGreaterThan
+}
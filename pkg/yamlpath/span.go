@@ -0,0 +1,295 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Span is the text of a node in the YAML source from which the node was parsed. Start is the byte offset of the
+// start of the node, including its anchor and tag if any, and End is the byte offset just past the end of the node, so
+// that the text of the node is source[Start:End]. The span of a mapping or sequence extends to the end of its last
+// element, or, for a flow mapping or sequence, to its closing bracket. The span of an empty scalar, such as the
+// value of `a:`, is empty.
+type Span struct {
+	Start int
+	End   int
+}
+
+// FindSpans applies the Path to the given root node, which must have been parsed from the given source, and returns
+// the span in the source of each match, in the order in which Find returns the matches. Since yaml.v3 records only
+// the line and column at which a node starts, the end of each node is found by scanning the source.
+//
+// An error is returned if a match does not correspond to the source, for example because it was not parsed from the
+// source.
+func (p *Path) FindSpans(source []byte, root *yaml.Node) ([]Span, error) {
+	results, err := p.Find(root)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSpanScanner(source)
+	spans := make([]Span, 0, len(results))
+	for _, r := range results {
+		sp, err := s.span(r)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, sp)
+	}
+	return spans, nil
+}
+
+type spanScanner struct {
+	source     []byte
+	lineStarts []int
+}
+
+func newSpanScanner(source []byte) *spanScanner {
+	lineStarts := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &spanScanner{
+		source:     source,
+		lineStarts: lineStarts,
+	}
+}
+
+func (s *spanScanner) span(n *yaml.Node) (Span, error) {
+	start, ok := s.offset(n.Line, n.Column)
+	if !ok {
+		return Span{}, fmt.Errorf("node at line %d, column %d is not in the source", n.Line, n.Column)
+	}
+	end, ok := s.end(n, start)
+	if !ok {
+		return Span{}, fmt.Errorf("cannot find the end of the node at line %d, column %d in the source", n.Line, n.Column)
+	}
+	return Span{Start: start, End: end}, nil
+}
+
+// offset returns the byte offset of the given line and column, both of which start at 1 and the latter of which
+// counts characters rather than bytes.
+func (s *spanScanner) offset(line, column int) (int, bool) {
+	if line < 1 || line > len(s.lineStarts) || column < 1 {
+		return 0, false
+	}
+	i := s.lineStarts[line-1]
+	for c := 1; c < column; c++ {
+		if i >= len(s.source) || s.source[i] == '\n' {
+			return 0, false
+		}
+		_, width := utf8.DecodeRune(s.source[i:])
+		i += width
+	}
+	return i, true
+}
+
+// end returns the byte offset just past the end of the given node, which starts at the given offset.
+func (s *spanScanner) end(n *yaml.Node, start int) (int, bool) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return start, true
+		}
+		return s.endOfLastChild(n)
+
+	case yaml.AliasNode:
+		end := start + len("*") + len(n.Value)
+		return end, end <= len(s.source) && string(s.source[start:end]) == "*"+n.Value
+
+	case yaml.ScalarNode:
+		i, propertiesEnd := s.skipProperties(start)
+		switch {
+		case n.Style&yaml.DoubleQuotedStyle != 0:
+			return s.endOfQuoted(i, '"')
+
+		case n.Style&yaml.SingleQuotedStyle != 0:
+			return s.endOfQuoted(i, '\'')
+
+		case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			return s.endOfBlockScalar(i)
+
+		case n.Value == "":
+			return propertiesEnd, true
+
+		default:
+			return s.endOfPlain(i, n.Value)
+		}
+
+	case yaml.MappingNode, yaml.SequenceNode:
+		if n.Style&yaml.FlowStyle == 0 {
+			return s.endOfLastChild(n)
+		}
+		closing := byte(']')
+		if n.Kind == yaml.MappingNode {
+			closing = '}'
+		}
+		i, _ := s.skipProperties(start)
+		i++ // the opening bracket
+		if len(n.Content) > 0 {
+			var ok bool
+			if i, ok = s.endOfLastChild(n); !ok {
+				return 0, false
+			}
+		}
+		return s.endOfFlow(i, closing)
+
+	default:
+		return 0, false
+	}
+}
+
+func (s *spanScanner) endOfLastChild(n *yaml.Node) (int, bool) {
+	sp, err := s.span(n.Content[len(n.Content)-1])
+	return sp.End, err == nil
+}
+
+// skipProperties returns the offset of the first character of a node, which starts at the given offset, following
+// its anchor and tag, if any, together with the offset just past the anchor and tag.
+func (s *spanScanner) skipProperties(i int) (int, int) {
+	propertiesEnd := i
+	for i < len(s.source) && (s.source[i] == '&' || s.source[i] == '!') {
+		for i < len(s.source) && !isSpaceByte(s.source[i]) && s.source[i] != '\n' && s.source[i] != '\r' {
+			i++
+		}
+		propertiesEnd = i
+		for i < len(s.source) && (isSpaceByte(s.source[i]) || s.source[i] == '\n' || s.source[i] == '\r') {
+			i++
+		}
+	}
+	return i, propertiesEnd
+}
+
+// endOfQuoted returns the offset just past the closing quote of a quoted scalar starting at the given offset.
+func (s *spanScanner) endOfQuoted(i int, quote byte) (int, bool) {
+	if i >= len(s.source) || s.source[i] != quote {
+		return 0, false
+	}
+	for i++; i < len(s.source); i++ {
+		switch {
+		case quote == '"' && s.source[i] == '\\':
+			i++
+
+		case s.source[i] == quote:
+			if quote == '\'' && i+1 < len(s.source) && s.source[i+1] == '\'' {
+				i++ // escaped single quote
+				continue
+			}
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// endOfBlockScalar returns the offset just past the last non-blank line of a literal or folded scalar whose header
+// starts at the given offset or, if the scalar has no content, just past its header.
+func (s *spanScanner) endOfBlockScalar(i int) (int, bool) {
+	end := i + 1 // the "|" or ">" indicator
+	for end < len(s.source) && bytes.IndexByte([]byte("+-0123456789"), s.source[end]) >= 0 {
+		end++
+	}
+
+	// The content of the scalar consists of the following lines which are more indented than the header line,
+	// ignoring any "- " indicators on the header line preceding a mapping key, together with any blank lines.
+	lineStart := s.lineStart(i)
+	indent := 0
+	for j := lineStart; j < i; {
+		for j < i && s.source[j] == ' ' {
+			j++
+		}
+		if j < i {
+			indent = j - lineStart
+		}
+		if j+1 < i && s.source[j] == '-' && isSpaceByte(s.source[j+1]) {
+			j++
+			continue
+		}
+		break
+	}
+
+	line := bytes.IndexByte(s.source[i:], '\n')
+	for line >= 0 {
+		next := i + line + 1
+		lineEnd := len(s.source)
+		if nl := bytes.IndexByte(s.source[next:], '\n'); nl >= 0 {
+			lineEnd = next + nl
+		}
+		text := bytes.TrimRight(s.source[next:lineEnd], " \t\r")
+		if len(bytes.TrimLeft(text, " ")) > 0 {
+			if len(text)-len(bytes.TrimLeft(text, " ")) <= indent {
+				break
+			}
+			end = next + len(text)
+		}
+		i = next
+		line = bytes.IndexByte(s.source[i:], '\n')
+	}
+	return end, true
+}
+
+// endOfPlain returns the offset just past the end of a plain scalar with the given value which starts at the given
+// offset. The scalar may span several lines, each line break and surrounding whitespace of which is folded into
+// whitespace in the value.
+func (s *spanScanner) endOfPlain(i int, value string) (int, bool) {
+	v := []byte(value)
+	for len(v) > 0 {
+		if isSpaceByte(v[0]) || v[0] == '\n' {
+			for len(v) > 0 && (isSpaceByte(v[0]) || v[0] == '\n') {
+				v = v[1:]
+			}
+			for i < len(s.source) && (isSpaceByte(s.source[i]) || s.source[i] == '\n' || s.source[i] == '\r') {
+				i++
+			}
+			continue
+		}
+		if i >= len(s.source) || s.source[i] != v[0] {
+			return 0, false
+		}
+		i++
+		v = v[1:]
+	}
+	return i, true
+}
+
+// endOfFlow returns the offset just past the given closing bracket of a flow mapping or sequence, which follows the
+// given offset, possibly after whitespace, commas, and comments.
+func (s *spanScanner) endOfFlow(i int, closing byte) (int, bool) {
+	for i < len(s.source) {
+		switch b := s.source[i]; {
+		case b == closing:
+			return i + 1, true
+
+		case b == '#':
+			for i < len(s.source) && s.source[i] != '\n' {
+				i++
+			}
+
+		case isSpaceByte(b), b == '\n', b == '\r', b == ',':
+			i++
+
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// lineStart returns the offset of the start of the line containing the given offset.
+func (s *spanScanner) lineStart(i int) int {
+	return bytes.LastIndexByte(s.source[:i], '\n') + 1
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t'
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFindSpans(t *testing.T) {
+	y := `# comment
+name: plain value  # trailing comment
+quoted: "a \"b\" c"
+single: 'it''s'
+anchored: &a !!str tagged
+empty:
+multiline: first
+  second
+folded: >-
+  one
+  two
+
+literal: |
+  text
+    indented
+  # not a comment
+
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.19
+  - name: ünïcode
+    args: [a, "b, c", {d: 'e'}]
+  - *a
+  - - nested: |
+        block
+      after: 1
+flow: {x: [1, 2] , y: {} }  # comment
+end: x
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		path     string
+		expected []string
+	}{
+		{
+			name:     "plain scalar followed by comment",
+			path:     "$.name",
+			expected: []string{"plain value"},
+		},
+		{
+			name:     "double quoted scalar",
+			path:     "$.quoted",
+			expected: []string{`"a \"b\" c"`},
+		},
+		{
+			name:     "single quoted scalar",
+			path:     "$.single",
+			expected: []string{`'it''s'`},
+		},
+		{
+			name:     "scalar with anchor and tag",
+			path:     "$.anchored",
+			expected: []string{"&a !!str tagged"},
+		},
+		{
+			name:     "empty scalar",
+			path:     "$.empty",
+			expected: []string{""},
+		},
+		{
+			name:     "multiline plain scalar",
+			path:     "$.multiline",
+			expected: []string{"first\n  second"},
+		},
+		{
+			name:     "folded scalar",
+			path:     "$.folded",
+			expected: []string{">-\n  one\n  two"},
+		},
+		{
+			name:     "literal scalar",
+			path:     "$.literal",
+			expected: []string{"|\n  text\n    indented\n  # not a comment"},
+		},
+		{
+			name:     "key",
+			path:     "$.spec~",
+			expected: []string{"spec"},
+		},
+		{
+			name: "block mapping",
+			path: "$.spec.containers[0]",
+			expected: []string{`name: nginx
+    image: nginx:1.19`},
+		},
+		{
+			name: "block sequence",
+			path: "$.spec.containers[3]",
+			expected: []string{`- nested: |
+        block
+      after: 1`},
+		},
+		{
+			name:     "block scalar in sequence",
+			path:     "$.spec.containers[3][0].nested",
+			expected: []string{"|\n        block"},
+		},
+		{
+			name:     "non-ASCII characters",
+			path:     "$.spec.containers[1].name",
+			expected: []string{"ünïcode"},
+		},
+		{
+			name:     "flow sequence",
+			path:     "$.spec.containers[1].args",
+			expected: []string{`[a, "b, c", {d: 'e'}]`},
+		},
+		{
+			name:     "elements of flow sequence",
+			path:     "$.spec.containers[1].args[*]",
+			expected: []string{"a", `"b, c"`, "{d: 'e'}"},
+		},
+		{
+			name:     "flow mapping",
+			path:     "$.flow",
+			expected: []string{"{x: [1, 2] , y: {} }"},
+		},
+		{
+			name:     "empty flow mapping",
+			path:     "$.flow.y",
+			expected: []string{"{}"},
+		},
+		{
+			name:     "alias",
+			path:     "$.spec.containers[2]",
+			expected: []string{"*a"},
+		},
+		{
+			name:     "several matches",
+			path:     "$.spec.containers[*].name",
+			expected: []string{"nginx", "ünïcode"},
+		},
+		{
+			name:     "no matches",
+			path:     "$.nosuch",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			spans, err := p.FindSpans([]byte(y), &n)
+			require.NoError(t, err)
+
+			actual := []string{}
+			for _, s := range spans {
+				actual = append(actual, y[s.Start:s.End])
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("document", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$")
+		require.NoError(t, err)
+		spans, err := p.FindSpans([]byte(y), &n)
+		require.NoError(t, err)
+		require.Equal(t, []yamlpath.Span{{Start: len("# comment\n"), End: len(y) - len("\n")}}, spans)
+	})
+
+	t.Run("node not parsed from source", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.name")
+		require.NoError(t, err)
+		_, err = p.FindSpans([]byte("name: x\n"), &n)
+		require.EqualError(t, err, "node at line 2, column 7 is not in the source")
+	})
+}
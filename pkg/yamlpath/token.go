@@ -0,0 +1,264 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TokenKind is the kind of a Token.
+type TokenKind int
+
+// The value of each token kind is independent of the lexer, so that it is the same from one release to the next. New
+// kinds must therefore be added at the end, after TokenEOF.
+const (
+	// TokenError is an error detected while tokenizing. Tokenize returns this as an error rather than a Token.
+	TokenError TokenKind = iota
+	// TokenIdentity is the empty subpath which ends a path.
+	TokenIdentity
+	// TokenRoot is the root node `$`, which may be synthesized at the start of a path.
+	TokenRoot
+	// TokenDotChild is a named child, such as `.child`, or all children, `.*`.
+	TokenDotChild
+	// TokenUndottedChild is a named child at the start of a path without a preceding `.`.
+	TokenUndottedChild
+	// TokenBracketChild is one or more quoted child names in brackets, such as `['child']`.
+	TokenBracketChild
+	// TokenRecursiveDescent is a recursive descent, such as `..child`.
+	TokenRecursiveDescent
+	// TokenArraySubscript is an array index, slice, union, or wildcard, such as `[0]` or `[1:3]`.
+	TokenArraySubscript
+	// TokenFilterBegin is the start of a filter, `[?(`.
+	TokenFilterBegin
+	// TokenFilterEnd is the end of a filter, `)]`.
+	TokenFilterEnd
+	// TokenFilterOpenBracket is an opening parenthesis in a filter.
+	TokenFilterOpenBracket
+	// TokenFilterCloseBracket is a closing parenthesis in a filter.
+	TokenFilterCloseBracket
+	// TokenFilterNot is the negation operator `!`.
+	TokenFilterNot
+	// TokenFilterAt is the current node `@` in a filter or at the start of a relative path. See NewRelativePath.
+	TokenFilterAt
+	// TokenFilterAnd is the conjunction operator `&&`.
+	TokenFilterAnd
+	// TokenFilterOr is the disjunction operator `||`.
+	TokenFilterOr
+	// TokenFilterEquality is the equality operator `==`.
+	TokenFilterEquality
+	// TokenFilterInequality is the inequality operator `!=`.
+	TokenFilterInequality
+	// TokenFilterGreaterThan is the operator `>`.
+	TokenFilterGreaterThan
+	// TokenFilterGreaterThanOrEqual is the operator `>=`.
+	TokenFilterGreaterThanOrEqual
+	// TokenFilterLessThanOrEqual is the operator `<=`.
+	TokenFilterLessThanOrEqual
+	// TokenFilterLessThan is the operator `<`.
+	TokenFilterLessThan
+	// TokenFilterMatchesRegularExpression is the regular expression match operator `=~`.
+	TokenFilterMatchesRegularExpression
+	// TokenFilterIntegerLiteral is an integer literal in a filter.
+	TokenFilterIntegerLiteral
+	// TokenFilterFloatLiteral is a floating point literal in a filter.
+	TokenFilterFloatLiteral
+	// TokenFilterStringLiteral is a quoted string literal in a filter, including its quotes.
+	TokenFilterStringLiteral
+	// TokenFilterBooleanLiteral is the literal `true` or `false` in a filter.
+	TokenFilterBooleanLiteral
+	// TokenFilterNullLiteral is the literal `null` in a filter.
+	TokenFilterNullLiteral
+	// TokenFilterRegularExpressionLiteral is a regular expression literal, such as `/a.*/`, in a filter.
+	TokenFilterRegularExpressionLiteral
+	// TokenPropertyName is a named child followed by the property name operator, such as `.child~`.
+	TokenPropertyName
+	// TokenBracketPropertyName is a bracket child followed by the property name operator, such as `['child']~`.
+	TokenBracketPropertyName
+	// TokenArraySubscriptPropertyName is `[*]` followed by the property name operator, `[*]~`.
+	TokenArraySubscriptPropertyName
+	// TokenRecursiveFilterBegin is the start of a filter, `[?(`, following a recursive descent.
+	TokenRecursiveFilterBegin
+	// TokenFilterBinding is a reference to a named binding, such as `$params`, in a filter. See Path.FindWithBindings.
+	TokenFilterBinding
+	// TokenFilterIndex is `@index`, the index of the current node in the sequence being filtered.
+	TokenFilterIndex
+	// TokenFilterModulo is the modulo operator `%`.
+	TokenFilterModulo
+	// TokenFilterParent is `@^`, the parent of the current node in a filter, or `@^^`, its grandparent, and so on.
+	TokenFilterParent
+	// TokenFilterEqualityIgnoringCase is the case-insensitive equality operator `==~`.
+	TokenFilterEqualityIgnoringCase
+	// TokenTag is a tag selector, such as `<!!int>` or `<!custom>`.
+	TokenTag
+	// TokenFilterFunctionCall is the name and opening parenthesis of a function call in a filter, such as `lower(`.
+	// The arguments follow and the call ends with a TokenFilterCloseBracket. See RegisterFilterFunc.
+	TokenFilterFunctionCall
+	// TokenFilterArgumentSeparator is the `,` between the arguments of a function call in a filter.
+	TokenFilterArgumentSeparator
+	// TokenFilterNotMatchesRegularExpression is the regular expression non-match operator `!~`.
+	TokenFilterNotMatchesRegularExpression
+	// TokenFilterArrayLiteral is an array literal in a filter, such as `['a', 'b']`, including its brackets.
+	TokenFilterArrayLiteral
+	// TokenFilterKey is `@#`, the key of the current node in the mapping containing it.
+	TokenFilterKey
+	// TokenFilterBetween is `between` in a range test, such as `@.port between 1024 and 65535`.
+	TokenFilterBetween
+	// TokenFilterBetweenAnd is the `and` separating the bounds of a range test.
+	TokenFilterBetweenAnd
+	// TokenKeyRegularExpression is a regular expression selecting the values of a mapping by key, such as
+	// `.~/^feature_/`, including its `.~` prefix and delimiters.
+	TokenKeyRegularExpression
+	// TokenFirstOrLastElement is `.first()` or `.last()`, the first or last element of a sequence.
+	TokenFirstOrLastElement
+	// TokenEOF is the end of a path. Tokenize does not return this as a Token.
+	TokenEOF
+)
+
+// tokenKinds maps each lexeme type to the kind of the tokens produced from lexemes of that type.
+var tokenKinds = map[lexemeType]TokenKind{
+	lexemeError:                             TokenError,
+	lexemeIdentity:                          TokenIdentity,
+	lexemeRoot:                              TokenRoot,
+	lexemeDotChild:                          TokenDotChild,
+	lexemeUndottedChild:                     TokenUndottedChild,
+	lexemeBracketChild:                      TokenBracketChild,
+	lexemeRecursiveDescent:                  TokenRecursiveDescent,
+	lexemeArraySubscript:                    TokenArraySubscript,
+	lexemeFilterBegin:                       TokenFilterBegin,
+	lexemeFilterEnd:                         TokenFilterEnd,
+	lexemeFilterOpenBracket:                 TokenFilterOpenBracket,
+	lexemeFilterCloseBracket:                TokenFilterCloseBracket,
+	lexemeFilterNot:                         TokenFilterNot,
+	lexemeFilterAt:                          TokenFilterAt,
+	lexemeFilterAnd:                         TokenFilterAnd,
+	lexemeFilterOr:                          TokenFilterOr,
+	lexemeFilterEquality:                    TokenFilterEquality,
+	lexemeFilterInequality:                  TokenFilterInequality,
+	lexemeFilterGreaterThan:                 TokenFilterGreaterThan,
+	lexemeFilterGreaterThanOrEqual:          TokenFilterGreaterThanOrEqual,
+	lexemeFilterLessThanOrEqual:             TokenFilterLessThanOrEqual,
+	lexemeFilterLessThan:                    TokenFilterLessThan,
+	lexemeFilterMatchesRegularExpression:    TokenFilterMatchesRegularExpression,
+	lexemeFilterIntegerLiteral:              TokenFilterIntegerLiteral,
+	lexemeFilterFloatLiteral:                TokenFilterFloatLiteral,
+	lexemeFilterStringLiteral:               TokenFilterStringLiteral,
+	lexemeFilterBooleanLiteral:              TokenFilterBooleanLiteral,
+	lexemeFilterNullLiteral:                 TokenFilterNullLiteral,
+	lexemeFilterRegularExpressionLiteral:    TokenFilterRegularExpressionLiteral,
+	lexemePropertyName:                      TokenPropertyName,
+	lexemeBracketPropertyName:               TokenBracketPropertyName,
+	lexemeArraySubscriptPropertyName:        TokenArraySubscriptPropertyName,
+	lexemeRecursiveFilterBegin:              TokenRecursiveFilterBegin,
+	lexemeFilterBinding:                     TokenFilterBinding,
+	lexemeFilterIndex:                       TokenFilterIndex,
+	lexemeFilterModulo:                      TokenFilterModulo,
+	lexemeFilterParent:                      TokenFilterParent,
+	lexemeFilterEqualityIgnoringCase:        TokenFilterEqualityIgnoringCase,
+	lexemeTag:                               TokenTag,
+	lexemeFilterFunctionCall:                TokenFilterFunctionCall,
+	lexemeFilterArgumentSeparator:           TokenFilterArgumentSeparator,
+	lexemeFilterNotMatchesRegularExpression: TokenFilterNotMatchesRegularExpression,
+	lexemeFilterArrayLiteral:                TokenFilterArrayLiteral,
+	lexemeFilterKey:                         TokenFilterKey,
+	lexemeFilterBetween:                     TokenFilterBetween,
+	lexemeFilterBetweenAnd:                  TokenFilterBetweenAnd,
+	lexemeKeyRegularExpression:              TokenKeyRegularExpression,
+	lexemeFirstOrLastElement:                TokenFirstOrLastElement,
+	lexemeEOF:                               TokenEOF,
+}
+
+// tokenKindOf returns the kind of the tokens produced from lexemes of the given type.
+func tokenKindOf(typ lexemeType) TokenKind {
+	return tokenKinds[typ]
+}
+
+var tokenKindNames = map[TokenKind]string{
+	TokenError:                             "Error",
+	TokenIdentity:                          "Identity",
+	TokenRoot:                              "Root",
+	TokenDotChild:                          "DotChild",
+	TokenUndottedChild:                     "UndottedChild",
+	TokenBracketChild:                      "BracketChild",
+	TokenRecursiveDescent:                  "RecursiveDescent",
+	TokenArraySubscript:                    "ArraySubscript",
+	TokenFilterBegin:                       "FilterBegin",
+	TokenFilterEnd:                         "FilterEnd",
+	TokenFilterOpenBracket:                 "FilterOpenBracket",
+	TokenFilterCloseBracket:                "FilterCloseBracket",
+	TokenFilterNot:                         "FilterNot",
+	TokenFilterAt:                          "FilterAt",
+	TokenFilterAnd:                         "FilterAnd",
+	TokenFilterOr:                          "FilterOr",
+	TokenFilterEquality:                    "FilterEquality",
+	TokenFilterInequality:                  "FilterInequality",
+	TokenFilterGreaterThan:                 "FilterGreaterThan",
+	TokenFilterGreaterThanOrEqual:          "FilterGreaterThanOrEqual",
+	TokenFilterLessThanOrEqual:             "FilterLessThanOrEqual",
+	TokenFilterLessThan:                    "FilterLessThan",
+	TokenFilterMatchesRegularExpression:    "FilterMatchesRegularExpression",
+	TokenFilterIntegerLiteral:              "FilterIntegerLiteral",
+	TokenFilterFloatLiteral:                "FilterFloatLiteral",
+	TokenFilterStringLiteral:               "FilterStringLiteral",
+	TokenFilterBooleanLiteral:              "FilterBooleanLiteral",
+	TokenFilterNullLiteral:                 "FilterNullLiteral",
+	TokenFilterRegularExpressionLiteral:    "FilterRegularExpressionLiteral",
+	TokenPropertyName:                      "PropertyName",
+	TokenBracketPropertyName:               "BracketPropertyName",
+	TokenArraySubscriptPropertyName:        "ArraySubscriptPropertyName",
+	TokenRecursiveFilterBegin:              "RecursiveFilterBegin",
+	TokenFilterBinding:                     "FilterBinding",
+	TokenFilterIndex:                       "FilterIndex",
+	TokenFilterModulo:                      "FilterModulo",
+	TokenFilterParent:                      "FilterParent",
+	TokenFilterEqualityIgnoringCase:        "FilterEqualityIgnoringCase",
+	TokenTag:                               "Tag",
+	TokenFilterFunctionCall:                "FilterFunctionCall",
+	TokenFilterArgumentSeparator:           "FilterArgumentSeparator",
+	TokenFilterNotMatchesRegularExpression: "FilterNotMatchesRegularExpression",
+	TokenFilterArrayLiteral:                "FilterArrayLiteral",
+	TokenFilterKey:                         "FilterKey",
+	TokenFilterBetween:                     "FilterBetween",
+	TokenFilterBetweenAnd:                  "FilterBetweenAnd",
+	TokenKeyRegularExpression:              "KeyRegularExpression",
+	TokenFirstOrLastElement:                "FirstOrLastElement",
+	TokenEOF:                               "EOF",
+}
+
+func (k TokenKind) String() string {
+	if name, ok := tokenKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token is a lexical token of a path expression.
+type Token struct {
+	Kind  TokenKind
+	Value string // the text of the token, which is empty for the identity at the end of a path
+}
+
+// Tokenize splits a path expression into tokens. If the path is syntactically invalid, Tokenize returns the tokens
+// preceding the error together with the error.
+func Tokenize(path string) ([]Token, error) {
+	tokens := []Token{}
+	for _, lx := range lexAll(path) {
+		switch lx.typ {
+		case lexemeError:
+			return tokens, errors.New(lx.val)
+
+		case lexemeEOF:
+
+		default:
+			tokens = append(tokens, Token{
+				Kind:  tokenKindOf(lx.typ),
+				Value: lx.val,
+			})
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenKindString(t *testing.T) {
+	kinds := []struct {
+		kind     TokenKind
+		lexeme   lexemeType
+		expected string
+	}{
+		{TokenError, lexemeError, "Error"},
+		{TokenIdentity, lexemeIdentity, "Identity"},
+		{TokenRoot, lexemeRoot, "Root"},
+		{TokenDotChild, lexemeDotChild, "DotChild"},
+		{TokenUndottedChild, lexemeUndottedChild, "UndottedChild"},
+		{TokenBracketChild, lexemeBracketChild, "BracketChild"},
+		{TokenRecursiveDescent, lexemeRecursiveDescent, "RecursiveDescent"},
+		{TokenArraySubscript, lexemeArraySubscript, "ArraySubscript"},
+		{TokenFilterBegin, lexemeFilterBegin, "FilterBegin"},
+		{TokenFilterEnd, lexemeFilterEnd, "FilterEnd"},
+		{TokenFilterOpenBracket, lexemeFilterOpenBracket, "FilterOpenBracket"},
+		{TokenFilterCloseBracket, lexemeFilterCloseBracket, "FilterCloseBracket"},
+		{TokenFilterNot, lexemeFilterNot, "FilterNot"},
+		{TokenFilterAt, lexemeFilterAt, "FilterAt"},
+		{TokenFilterAnd, lexemeFilterAnd, "FilterAnd"},
+		{TokenFilterOr, lexemeFilterOr, "FilterOr"},
+		{TokenFilterEquality, lexemeFilterEquality, "FilterEquality"},
+		{TokenFilterInequality, lexemeFilterInequality, "FilterInequality"},
+		{TokenFilterGreaterThan, lexemeFilterGreaterThan, "FilterGreaterThan"},
+		{TokenFilterGreaterThanOrEqual, lexemeFilterGreaterThanOrEqual, "FilterGreaterThanOrEqual"},
+		{TokenFilterLessThanOrEqual, lexemeFilterLessThanOrEqual, "FilterLessThanOrEqual"},
+		{TokenFilterLessThan, lexemeFilterLessThan, "FilterLessThan"},
+		{TokenFilterMatchesRegularExpression, lexemeFilterMatchesRegularExpression, "FilterMatchesRegularExpression"},
+		{TokenFilterIntegerLiteral, lexemeFilterIntegerLiteral, "FilterIntegerLiteral"},
+		{TokenFilterFloatLiteral, lexemeFilterFloatLiteral, "FilterFloatLiteral"},
+		{TokenFilterStringLiteral, lexemeFilterStringLiteral, "FilterStringLiteral"},
+		{TokenFilterBooleanLiteral, lexemeFilterBooleanLiteral, "FilterBooleanLiteral"},
+		{TokenFilterNullLiteral, lexemeFilterNullLiteral, "FilterNullLiteral"},
+		{TokenFilterRegularExpressionLiteral, lexemeFilterRegularExpressionLiteral, "FilterRegularExpressionLiteral"},
+		{TokenPropertyName, lexemePropertyName, "PropertyName"},
+		{TokenBracketPropertyName, lexemeBracketPropertyName, "BracketPropertyName"},
+		{TokenArraySubscriptPropertyName, lexemeArraySubscriptPropertyName, "ArraySubscriptPropertyName"},
+		{TokenRecursiveFilterBegin, lexemeRecursiveFilterBegin, "RecursiveFilterBegin"},
+		{TokenFilterBinding, lexemeFilterBinding, "FilterBinding"},
+		{TokenFilterIndex, lexemeFilterIndex, "FilterIndex"},
+		{TokenFilterModulo, lexemeFilterModulo, "FilterModulo"},
+		{TokenFilterParent, lexemeFilterParent, "FilterParent"},
+		{TokenFilterEqualityIgnoringCase, lexemeFilterEqualityIgnoringCase, "FilterEqualityIgnoringCase"},
+		{TokenTag, lexemeTag, "Tag"},
+		{TokenFilterFunctionCall, lexemeFilterFunctionCall, "FilterFunctionCall"},
+		{TokenFilterArgumentSeparator, lexemeFilterArgumentSeparator, "FilterArgumentSeparator"},
+		{TokenFilterNotMatchesRegularExpression, lexemeFilterNotMatchesRegularExpression, "FilterNotMatchesRegularExpression"},
+		{TokenFilterArrayLiteral, lexemeFilterArrayLiteral, "FilterArrayLiteral"},
+		{TokenFilterKey, lexemeFilterKey, "FilterKey"},
+		{TokenFilterBetween, lexemeFilterBetween, "FilterBetween"},
+		{TokenFilterBetweenAnd, lexemeFilterBetweenAnd, "FilterBetweenAnd"},
+		{TokenKeyRegularExpression, lexemeKeyRegularExpression, "KeyRegularExpression"},
+		{TokenFirstOrLastElement, lexemeFirstOrLastElement, "FirstOrLastElement"},
+		{TokenEOF, lexemeEOF, "EOF"},
+	}
+
+	// every lexeme type must have a corresponding token kind
+	require.Len(t, kinds, int(lexemeEOF)+1)
+	require.Len(t, tokenKindNames, int(lexemeEOF)+1)
+	require.Len(t, tokenKinds, int(lexemeEOF)+1)
+
+	for _, k := range kinds {
+		require.Equal(t, k.kind, tokenKindOf(k.lexeme), k.expected)
+		require.Equal(t, k.expected, k.kind.String())
+	}
+
+	require.Equal(t, "TokenKind(-1)", TokenKind(-1).String())
+}
+
+func TestTokenKindValues(t *testing.T) {
+	// the values of token kinds are part of the API and must not change when lexeme types are added
+	require.Equal(t, TokenKind(0), TokenError)
+	require.Equal(t, TokenKind(2), TokenRoot)
+	require.Equal(t, TokenKind(8), TokenFilterBegin)
+	require.Equal(t, TokenKind(47), TokenFirstOrLastElement)
+	require.Equal(t, TokenKind(48), TokenEOF)
+}
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		expected    []Token
+		expectedErr string
+	}{
+		{
+			name: "identity",
+			path: "",
+			expected: []Token{
+				{Kind: TokenIdentity, Value: ""},
+			},
+		},
+		{
+			name: "implicit root",
+			path: "a.b",
+			expected: []Token{
+				{Kind: TokenRoot, Value: "$"},
+				{Kind: TokenUndottedChild, Value: "a"},
+				{Kind: TokenDotChild, Value: ".b"},
+				{Kind: TokenIdentity, Value: ""},
+			},
+		},
+		{
+			name: "filter",
+			path: "$.a[?(@.b>1)]",
+			expected: []Token{
+				{Kind: TokenRoot, Value: "$"},
+				{Kind: TokenDotChild, Value: ".a"},
+				{Kind: TokenFilterBegin, Value: "[?("},
+				{Kind: TokenFilterAt, Value: "@"},
+				{Kind: TokenDotChild, Value: ".b"},
+				{Kind: TokenFilterGreaterThan, Value: ">"},
+				{Kind: TokenFilterIntegerLiteral, Value: "1"},
+				{Kind: TokenFilterEnd, Value: ")]"},
+				{Kind: TokenIdentity, Value: ""},
+			},
+		},
+		{
+			name: "error",
+			path: "$.a.",
+			expected: []Token{
+				{Kind: TokenRoot, Value: "$"},
+				{Kind: TokenDotChild, Value: ".a"},
+			},
+			expectedErr: `child name missing at position 4, following ".a."`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens, err := Tokenize(tc.path)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+			require.Equal(t, tc.expected, tokens)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SegmentStat describes the application of one segment of a Path by Trace.
+type SegmentStat struct {
+	Segment  string        // the canonical form of the segment, such as `.spec` or `[?(@.replicas>1)]`
+	In       int           // the number of nodes to which the segment was applied
+	Out      int           // the number of nodes produced by the segment
+	Duration time.Duration // the time spent applying the segment
+}
+
+// Trace applies the Path to a YAML node, as Find does, one segment at a time and returns, for each segment following
+// the root, the number of nodes to which the segment was applied, the number of nodes it produced, and the time spent
+// applying it, so that the segment responsible for a slow path, such as a recursive descent feeding a filter, may be
+// identified. Segments are those counted by MatchDepth, so `$.spec.containers[*].name` has four segments, except that
+// a filter which immediately follows a recursive descent, as in `$..containers[?(@.name)]`, applies to the nodes
+// selected by the recursive descent, rather than to their children, and so belongs to the same segment.
+//
+// The nodes produced by each segment are the nodes to which the next segment is applied and those produced by the
+// last segment are the matches of the Path, except that they are not made distinct by WithDistinctResults. Since
+// Trace measures each segment separately, it may take longer than Find.
+func (p *Path) Trace(root *yaml.Node) ([]SegmentStat, error) {
+	lexemes := lexAll(p.expr)
+	ends := segmentEnds(lexemes)
+	e := newEvaluation(context.Background())
+
+	start, err := compile(canonical(lexemes[:ends[0]]), p.opts)
+	if err != nil {
+		return nil, err
+	}
+	nodes := start.find(root, root, e)
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	stats := []SegmentStat{}
+	o := p.opts
+	for i, from := 1, ends[0]; i < len(ends); i++ {
+		for i+1 < len(ends) && lexemes[ends[i]].typ == lexemeRecursiveFilterBegin {
+			i++
+		}
+
+		// apply the segment as a relative path, so that it does not skip over document nodes as $ does
+		segment := canonical(lexemes[from:ends[i]])
+		s, err := compile(filterAt+segment, o)
+		if err != nil {
+			return nil, err
+		}
+		if lexemes[from].typ == lexemeRecursiveDescent {
+			// as in newPath, the rest of the path is lenient
+			o = lenient(o)
+		}
+		from = ends[i]
+
+		began := time.Now()
+		results := []*yaml.Node{}
+		for _, n := range nodes {
+			results = append(results, s.find(n, root, e)...)
+		}
+		if e.err != nil {
+			return nil, e.err
+		}
+		stats = append(stats, SegmentStat{
+			Segment:  segment,
+			In:       len(nodes),
+			Out:      len(results),
+			Duration: time.Since(began),
+		})
+		nodes = results
+	}
+	return stats, nil
+}
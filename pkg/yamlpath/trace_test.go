@@ -0,0 +1,163 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTrace(t *testing.T) {
+	y := `---
+spec:
+  replicas: 2
+  containers:
+  - name: nginx
+    ports: [80, 443]
+  - name: sidecar
+  - name: logger
+    ports: [9000]
+`
+	var n yaml.Node
+	err := yaml.Unmarshal([]byte(y), &n)
+	require.NoError(t, err)
+
+	type counts struct {
+		segment string
+		in, out int
+	}
+	cases := []struct {
+		name     string
+		path     string
+		opts     []yamlpath.Option
+		expected []counts
+	}{
+		{
+			name: "children",
+			path: "$.spec.containers[*].name",
+			expected: []counts{
+				{".spec", 1, 1},
+				{".containers", 1, 1},
+				{"[*]", 1, 3},
+				{".name", 3, 3},
+			},
+		},
+		{
+			name: "filter with modulo",
+			path: "$.spec.containers[?(@.ports[0] % 2 == 0)].name",
+			expected: []counts{
+				{".spec", 1, 1},
+				{".containers", 1, 1},
+				{"[?(@.ports[0] % 2==0)]", 1, 2},
+				{".name", 2, 2},
+			},
+		},
+		{
+			name: "recursive descent feeding a filter",
+			path: "$..[?(@.ports)].ports[*]",
+			expected: []counts{
+				{"..[?(@.ports)]", 1, 2},
+				{".ports", 2, 2},
+				{"[*]", 2, 3},
+			},
+		},
+		{
+			name: "filter following recursive descent",
+			path: "$..containers[?(@[0].name)][*].name",
+			expected: []counts{
+				{"..containers[?(@[0].name)]", 1, 1},
+				{"[*]", 1, 3},
+				{".name", 3, 3},
+			},
+		},
+		{
+			name: "segment matching nothing",
+			path: "$.spec.containers[1].ports[0]",
+			expected: []counts{
+				{".spec", 1, 1},
+				{".containers", 1, 1},
+				{"[1]", 1, 1},
+				{".ports", 1, 0},
+				{"[0]", 0, 0},
+			},
+		},
+		{
+			name: "filter referring to root",
+			path: "$.spec.containers[?(@.ports.size <= $.spec.replicas)].name",
+			expected: []counts{
+				{".spec", 1, 1},
+				{".containers", 1, 1},
+				{"[?(@.ports.size<=$.spec.replicas)]", 1, 2},
+				{".name", 2, 2},
+			},
+		},
+		{
+			name: "duplicate matches",
+			path: "$.spec.containers[0,0].name",
+			opts: []yamlpath.Option{yamlpath.WithDistinctResults()},
+			expected: []counts{
+				{".spec", 1, 1},
+				{".containers", 1, 1},
+				{"[0,0]", 1, 2},
+				{".name", 2, 2},
+			},
+		},
+		{
+			name: "strict types after recursive descent",
+			path: "$..name",
+			opts: []yamlpath.Option{yamlpath.WithStrictTypes()},
+			expected: []counts{
+				{"..name", 1, 3},
+			},
+		},
+		{
+			name:     "root only",
+			path:     "$",
+			expected: []counts{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			stats, err := p.Trace(&n)
+			require.NoError(t, err)
+			actual := []counts{}
+			for _, s := range stats {
+				require.True(t, s.Duration >= 0)
+				actual = append(actual, counts{s.Segment, s.In, s.Out})
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("last segment produces the matches", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$..[?(@.name != 'sidecar')].ports..[*]")
+		require.NoError(t, err)
+
+		stats, err := p.Trace(&n)
+		require.NoError(t, err)
+		results, err := p.Find(&n)
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		require.Len(t, stats, 3)
+		require.Equal(t, len(results), stats[len(stats)-1].Out)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		p, err := yamlpath.NewPath("$.spec.containers[?(@.name == $x)]")
+		require.NoError(t, err)
+
+		_, err = p.Trace(&n)
+		require.EqualError(t, err, "no binding for $x")
+	})
+}
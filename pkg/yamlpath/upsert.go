@@ -0,0 +1,220 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// upsertStep is a named child or a single array index of a singular path. See Upsert.
+type upsertStep struct {
+	text    string // the text of the step in the path, such as `.a` or `[0]`
+	name    string // the name of a child
+	index   int    // the index of an element
+	isIndex bool
+}
+
+// Upsert sets the node matched by the Path, which must be singular, in the given root node to the given value,
+// creating any missing nodes on the way. So, for example, upserting `$.a.b.c` to 1 in the document `a: {}` produces
+// `a: {b: {c: 1}}`. A missing node, or a null scalar such as the value of `b:`, which is followed by a named child
+// becomes an empty mapping and one which is followed by an array index becomes an empty sequence. A missing child is
+// appended to its mapping and, if the Path matches an existing node, the value replaces that node in its parent.
+// Upserting the root replaces the content of a document node and is otherwise an error.
+//
+// An array index equal to the length of a sequence appends an element to the sequence. A larger index is an error
+// unless the Path was constructed with WithSequenceExtension, in which case the sequence is first extended with null
+// elements up to the index. A negative index counts back from the end of the sequence and never extends it.
+//
+// If the Path is not singular, Upsert returns an error wrapping ErrNotSingular. If a named child is applied to a
+// node which is not a mapping or an array index is applied to a node which is not a sequence, including an alias,
+// which Upsert does not follow any more than Find does, Upsert returns an error wrapping ErrTypeMismatch. Nodes are
+// modified only if Upsert succeeds.
+func (p *Path) Upsert(root, value *yaml.Node) error {
+	if !p.IsSingular() {
+		return fmt.Errorf("%w: %q cannot be upserted", ErrNotSingular, p.expr)
+	}
+	steps := upsertSteps(lexAll(p.expr))
+
+	if root.Kind == yaml.DocumentNode {
+		if len(steps) == 0 {
+			root.Content = []*yaml.Node{value}
+			return nil
+		}
+		if len(root.Content) == 0 {
+			// check against the missing content, as for any other missing node, before creating it
+			if err := checkUpsert(nil, steps, p.opts); err != nil {
+				return err
+			}
+			root.Content = []*yaml.Node{newContainer(steps[0])}
+		}
+		root = root.Content[0]
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("cannot upsert the root %s node", kindOf(root))
+	}
+	if err := checkUpsert(root, steps, p.opts); err != nil {
+		return err
+	}
+
+	node := root
+	for i, step := range steps {
+		leaf := value
+		if i+1 < len(steps) {
+			leaf = nil
+		}
+		node = upsertChild(node, step, steps[i+1:], leaf)
+	}
+	return nil
+}
+
+// upsertSteps returns the named children and array indices of the given lexemes of a singular path.
+func upsertSteps(lexemes []lexeme) []upsertStep {
+	steps := []upsertStep{}
+	for _, lx := range lexemes {
+		switch lx.typ {
+		case lexemeDotChild:
+			steps = append(steps, upsertStep{text: lx.val, name: unescape(strings.TrimPrefix(lx.val, dot))})
+
+		case lexemeUndottedChild:
+			steps = append(steps, upsertStep{text: lx.val, name: unescape(lx.val)})
+
+		case lexemeBracketChild:
+			steps = append(steps, upsertStep{text: lx.val, name: bracketChildNamesOf(lx.val)[0]})
+
+		case lexemeArraySubscript:
+			index, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(lx.val, leftBracket), rightBracket)))
+			if err != nil {
+				panic(err) // should not happen, since the path is singular
+			}
+			steps = append(steps, upsertStep{text: lx.val, index: index, isIndex: true})
+		}
+	}
+	return steps
+}
+
+// checkUpsert returns an error if upserting the given steps in the given node would fail, so that Upsert does not
+// modify the node in that case.
+func checkUpsert(node *yaml.Node, steps []upsertStep, o *options) error {
+	for _, step := range steps {
+		if node != nil && isNull(node) {
+			node = nil // the null node will be replaced by an empty node
+		}
+		if step.isIndex {
+			length := 0
+			if node != nil {
+				if node.Kind != yaml.SequenceNode {
+					return upsertTypeMismatch(step, "a sequence", node)
+				}
+				length = len(node.Content)
+			}
+			i := step.index
+			if i < 0 {
+				i += length
+			}
+			if i < 0 || i > length && !o.sequenceExtension {
+				position := ""
+				if node != nil {
+					position = positionOf(node)
+				}
+				return fmt.Errorf("array index %s is out of range for a sequence of length %d%s", step.text, length, position)
+			}
+			if i < length {
+				node = node.Content[i]
+			} else {
+				node = nil // the element will be created
+			}
+			continue
+		}
+		if node == nil {
+			continue // the mapping and its child will be created
+		}
+		if node.Kind != yaml.MappingNode {
+			return upsertTypeMismatch(step, "a mapping", node)
+		}
+		node = childOf(node, step.name)
+	}
+	return nil
+}
+
+// upsertChild sets or creates the child of the given node selected by the given step, which is followed by the given
+// steps, and returns the child. If the given leaf is not nil, it becomes the child. Otherwise an existing child is
+// kept unless it is null, in which case it is replaced, as is a missing child, by an empty node to which the next step
+// may be applied. The node, if not null, must have been checked by checkUpsert.
+func upsertChild(node *yaml.Node, step upsertStep, rest []upsertStep, leaf *yaml.Node) *yaml.Node {
+	if isNull(node) {
+		*node = *newContainer(step)
+	}
+	child := leaf
+	if step.isIndex {
+		i := step.index
+		if i < 0 {
+			i += len(node.Content)
+		}
+		for len(node.Content) <= i {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: nullTag, Value: "null"})
+		}
+		if child == nil {
+			child = keepOrCreate(node.Content[i], rest[0])
+		}
+		node.Content[i] = child
+		return child
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == step.name {
+			if child == nil {
+				child = keepOrCreate(node.Content[i+1], rest[0])
+			}
+			node.Content[i+1] = child
+			return child
+		}
+	}
+	if child == nil {
+		child = newContainer(rest[0])
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: strTag, Value: step.name}, child)
+	return child
+}
+
+// keepOrCreate returns the given existing node or, if it is null, an empty node to which the given step may be
+// applied.
+func keepOrCreate(existing *yaml.Node, next upsertStep) *yaml.Node {
+	if isNull(existing) {
+		return newContainer(next)
+	}
+	return existing
+}
+
+// newContainer returns an empty sequence if the given step is an array index and otherwise an empty mapping.
+func newContainer(step upsertStep) *yaml.Node {
+	if step.isIndex {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// childOf returns the value of the first child of the given mapping with the given name or, if there is none, nil.
+func childOf(mapping *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == nullTag
+}
+
+func upsertTypeMismatch(step upsertStep, expected string, node *yaml.Node) error {
+	return fmt.Errorf("%w: %s requires %s but was applied to %s node%s", ErrTypeMismatch, step.text, expected, kindOf(node).withArticle(), positionOf(node))
+}
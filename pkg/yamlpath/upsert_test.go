@@ -0,0 +1,263 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestUpsert(t *testing.T) {
+	cases := []struct {
+		name          string
+		yaml          string
+		path          string
+		opts          []yamlpath.Option
+		value         string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "new deep key",
+			yaml:     "a: {x: 1}\n",
+			path:     "$.a.b.c",
+			value:    "1",
+			expected: "a: {x: 1, b: {c: 1}}\n",
+		},
+		{
+			name:     "new deep key in empty document",
+			yaml:     "",
+			path:     "$.a.b.c",
+			value:    "1",
+			expected: "a:\n  b:\n    c: 1\n",
+		},
+		{
+			name:     "existing key",
+			yaml:     "a:\n  b: old\n  c: other\n",
+			path:     "$.a.b",
+			value:    "new",
+			expected: "a:\n  b: new\n  c: other\n",
+		},
+		{
+			name:     "implicit root",
+			yaml:     "a: {}\n",
+			path:     "a.b",
+			value:    "1",
+			expected: "a: {b: 1}\n",
+		},
+		{
+			name:     "bracket child",
+			yaml:     "a: {}\n",
+			path:     "$['a']['b c']",
+			value:    "1",
+			expected: "a: {b c: 1}\n",
+		},
+		{
+			name:     "null intermediate node",
+			yaml:     "a:\nb: 2\n",
+			path:     "$.a.c",
+			value:    "1",
+			expected: "a:\n  c: 1\nb: 2\n",
+		},
+		{
+			name:     "mapping value",
+			yaml:     "a: 1\n",
+			path:     "$.b",
+			value:    "{c: [d]}",
+			expected: "a: 1\nb: {c: [d]}\n",
+		},
+		{
+			name:     "existing element",
+			yaml:     "a: [x, y]\n",
+			path:     "$.a[1]",
+			value:    "z",
+			expected: "a: [x, z]\n",
+		},
+		{
+			name:     "negative index",
+			yaml:     "a: [x, y]\n",
+			path:     "$.a[-2]",
+			value:    "z",
+			expected: "a: [z, y]\n",
+		},
+		{
+			name:     "key in existing element",
+			yaml:     "a:\n- name: x\n",
+			path:     "$.a[0].image",
+			value:    "nginx",
+			expected: "a:\n  - name: x\n    image: nginx\n",
+		},
+		{
+			name:     "new sequence",
+			yaml:     "a: {}\n",
+			path:     "$.a.b[0].c",
+			value:    "1",
+			expected: "a: {b: [{c: 1}]}\n",
+		},
+		{
+			name:          "index beyond end of sequence",
+			yaml:          "a: [x]\n",
+			path:          "$.a[2]",
+			value:         "z",
+			expectedError: "array index [2] is out of range for a sequence of length 1 at line 1, column 4",
+		},
+		{
+			name:     "index beyond end of sequence with extension",
+			yaml:     "a: [x]\n",
+			path:     "$.a[2]",
+			opts:     []yamlpath.Option{yamlpath.WithSequenceExtension()},
+			value:    "z",
+			expected: "a: [x, null, z]\n",
+		},
+		{
+			name:     "index of new sequence with extension",
+			yaml:     "a: {}\n",
+			path:     "$.a.b[1].c",
+			opts:     []yamlpath.Option{yamlpath.WithSequenceExtension()},
+			value:    "1",
+			expected: "a: {b: [null, {c: 1}]}\n",
+		},
+		{
+			name:          "index of new sequence without extension",
+			yaml:          "a: {}\n",
+			path:          "$.a.b[1]",
+			value:         "1",
+			expectedError: "array index [1] is out of range for a sequence of length 0",
+		},
+		{
+			name:          "index beyond end of sequence in empty document",
+			yaml:          "",
+			path:          "$[3]",
+			value:         "z",
+			expectedError: "array index [3] is out of range for a sequence of length 0",
+		},
+		{
+			name:          "negative index beyond start of sequence",
+			yaml:          "a: [x]\n",
+			path:          "$.a[-2]",
+			opts:          []yamlpath.Option{yamlpath.WithSequenceExtension()},
+			value:         "z",
+			expectedError: "array index [-2] is out of range for a sequence of length 1 at line 1, column 4",
+		},
+		{
+			name:          "child of scalar",
+			yaml:          "a: {b: 1}\n",
+			path:          "$.a.b.c",
+			value:         "1",
+			expectedError: "node type mismatch: .c requires a mapping but was applied to a scalar node at line 1, column 8",
+		},
+		{
+			name:          "index of mapping",
+			yaml:          "a: {b: 1}\n",
+			path:          "$.a[0]",
+			value:         "1",
+			expectedError: "node type mismatch: [0] requires a sequence but was applied to a mapping node at line 1, column 4",
+		},
+		{
+			name:          "path which is not singular",
+			yaml:          "a: [x]\n",
+			path:          "$.a[*]",
+			value:         "z",
+			expectedError: `path is not singular: "$.a[*]" cannot be upserted`,
+		},
+		{
+			name:     "root of document",
+			yaml:     "a: 1\n",
+			path:     "$",
+			value:    "[b]",
+			expected: "[b]\n",
+		},
+		{
+			name:          "through alias",
+			yaml:          "a: &x {b: 1}\nc: *x\n",
+			path:          "$.c.d",
+			value:         "2",
+			expectedError: "node type mismatch: .d requires a mapping but was applied to an alias node at line 2, column 4",
+		},
+		{
+			name:     "replacing alias",
+			yaml:     "a: &x {b: 1}\nc: *x\n",
+			path:     "$.c",
+			value:    "2",
+			expected: "a: &x {b: 1}\nc: 2\n",
+		},
+		{
+			name:     "appending element",
+			yaml:     "a: [x]\n",
+			path:     "$.a[1]",
+			value:    "y",
+			expected: "a: [x, y]\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var n yaml.Node
+			err := yaml.Unmarshal([]byte(tc.yaml), &n)
+			require.NoError(t, err)
+			if n.Kind == 0 {
+				n.Kind = yaml.DocumentNode // an empty document
+			}
+			var value yaml.Node
+			err = yaml.Unmarshal([]byte(tc.value), &value)
+			require.NoError(t, err)
+
+			p, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+
+			err = p.Upsert(&n, value.Content[0])
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+
+				var original yaml.Node
+				err = yaml.Unmarshal([]byte(tc.yaml), &original)
+				require.NoError(t, err)
+				if original.Kind == 0 {
+					require.Empty(t, n.Content, "node was modified")
+					return
+				}
+				require.Equal(t, encodeNodes(t, []*yaml.Node{&original}), encodeNodes(t, []*yaml.Node{&n}), "node was modified")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, encodeNodes(t, []*yaml.Node{&n})[0])
+
+			results, err := p.Find(&n)
+			require.NoError(t, err)
+			require.Equal(t, []*yaml.Node{value.Content[0]}, results)
+		})
+	}
+
+	t.Run("errors are typed", func(t *testing.T) {
+		var n yaml.Node
+		err := yaml.Unmarshal([]byte("a: 1\n"), &n)
+		require.NoError(t, err)
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "2"}
+
+		p, err := yamlpath.NewPath("$.a.b")
+		require.NoError(t, err)
+		require.True(t, errors.Is(p.Upsert(&n, value), yamlpath.ErrTypeMismatch))
+
+		p, err = yamlpath.NewPath("$..a")
+		require.NoError(t, err)
+		require.True(t, errors.Is(p.Upsert(&n, value), yamlpath.ErrNotSingular))
+	})
+
+	t.Run("root of top level node", func(t *testing.T) {
+		var n yaml.Node
+		err := yaml.Unmarshal([]byte("a: 1\n"), &n)
+		require.NoError(t, err)
+		p, err := yamlpath.NewPath("$")
+		require.NoError(t, err)
+		err = p.Upsert(n.Content[0], &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "2"})
+		require.EqualError(t, err, "cannot upsert the root mapping node")
+	})
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"context"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MatchContext is a node matched by a Path together with its position in the document. See Walk.
+type MatchContext struct {
+	Node   *yaml.Node // the matched node
+	Parent *yaml.Node // the mapping, sequence, or document containing the node, or nil if there is none
+	Key    *yaml.Node // the key of the node if the parent is a mapping and the node is a value, otherwise nil
+	Index  int        // the index of the node if the parent is a sequence, otherwise -1
+}
+
+// Walk applies the Path to the given root node and calls visit with the context of each match, in the order in
+// which Find returns the matches. If visit returns an error, Walk stops and returns the error.
+//
+// Since all the matches are found before visit is first called, visit may modify the document, for example to
+// replace the matched node in its parent. The parent of a match is its parent in the document before any such
+// modifications, whereas the key and index reflect any modifications of the parent by previous calls of visit. A
+// node reached only through an alias has no parent.
+func (p *Path) Walk(root *yaml.Node, visit func(ctx MatchContext) error) error {
+	e := newEvaluation(context.Background())
+	results, err := p.evaluate(root, e)
+	if err != nil {
+		return err
+	}
+	if len(results) > 0 {
+		e.ancestor(root, root, 0) // record the parent of each node before visit modifies the document
+	}
+
+	for _, r := range results {
+		ctx := MatchContext{
+			Node:   r,
+			Parent: e.ancestor(r, root, 1),
+			Index:  -1,
+		}
+		if i := indexOf(r, ctx.Parent); i >= 0 {
+			switch ctx.Parent.Kind {
+			case yaml.MappingNode:
+				if i%2 == 1 {
+					ctx.Key = ctx.Parent.Content[i-1]
+				}
+
+			case yaml.SequenceNode:
+				ctx.Index = i
+			}
+		}
+		if err := visit(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexOf returns the index of the given node in the content of the given parent or, if the parent is nil or does
+// not contain the node, -1.
+func indexOf(node, parent *yaml.Node) int {
+	if parent == nil {
+		return -1
+	}
+	for i, c := range parent.Content {
+		if c == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// Entry is a node matched by a Path together with its key. See FindEntries.
+type Entry struct {
+	Key   *yaml.Node // the key of the node if the node is a value in a mapping, otherwise nil
+	Value *yaml.Node // the matched node
+}
+
+// FindEntries applies the Path to the given root node and returns an Entry for each match, in the order in which
+// Find returns the matches, so that, for example, `$.config.*` produces the key and value of each member of the
+// config mapping. The key of a match which is not a value in a mapping, such as a sequence element or a key, is nil.
+func (p *Path) FindEntries(root *yaml.Node) ([]Entry, error) {
+	entries := []Entry{}
+	err := p.Walk(root, func(ctx MatchContext) error {
+		entries = append(entries, Entry{Key: ctx.Key, Value: ctx.Node})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
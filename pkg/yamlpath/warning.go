@@ -0,0 +1,113 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WarningKind is the kind of a Warning.
+type WarningKind int
+
+const (
+	// WarningNestedRecursiveDescent is a recursive descent following a recursive descent with a wildcard, such as
+	// `$..*..name` or `$..*[?(@..name)]`. Since the wildcard selects every node of the document and the second
+	// recursive descent is applied to each of them, the path visits each node once for each of its ancestors, which
+	// may be very expensive for a large document.
+	WarningNestedRecursiveDescent WarningKind = iota + 1
+	// WarningStringOrdering is an ordering comparison with a string literal, such as `@.created > '2023-01-01'`,
+	// in a path compiled without WithTimeComparison. Such a comparison compares strings lexically, which may not
+	// be what was intended. A string literal which parses as a number, such as '10', is compared numerically and so
+	// produces no warning.
+	WarningStringOrdering
+	// WarningIndexOfMapping is a single array index, such as `[0]`, which was applied to a mapping without matching
+	// any of its keys. An array index matches only an integer key, such as the key of `0: x`, so the key of `'0': x`
+	// is selected by a bracket child, `['0']`, instead. This warning is reported only by FindWithWarnings, since it
+	// depends on the document.
+	WarningIndexOfMapping
+)
+
+// Warning is a construct in a path which is valid but suspicious. See CompileWithWarnings.
+type Warning struct {
+	Kind    WarningKind
+	Message string // a description of the construct, including its text
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// CompileWithWarnings constructs a Path from a string expression, as NewPath does, and also returns warnings about
+// any constructs in the path which are valid but suspicious, such as those described by the constants of type
+// WarningKind. Warnings do not affect the Path, which behaves in the same way as a Path constructed by NewPath. If
+// the path is invalid, CompileWithWarnings returns the same error as NewPath and no warnings.
+func CompileWithWarnings(path string, opts ...Option) (*Path, []Warning, error) {
+	p, err := NewPath(path, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, warningsOf(lexAll(p.expr), p.opts), nil
+}
+
+// FindWithWarnings applies the Path to a YAML node, as Find does, and also returns the warnings which CompileWithWarnings
+// returns for the Path followed by warnings about the application of the Path to the node, such as a
+// WarningIndexOfMapping when `$[0]` is applied to a mapping. Each warning is returned once, however many nodes it
+// applies to.
+func (p *Path) FindWithWarnings(node *yaml.Node) ([]*yaml.Node, []Warning, error) {
+	warnings := warningsOf(lexAll(p.expr), p.opts)
+	e := newEvaluation(context.Background())
+	e.warnings = &warnings
+	results, err := p.evaluate(node, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, warnings, nil
+}
+
+// warningsOf returns the warnings about the given lexemes of a valid path.
+func warningsOf(lexemes []lexeme, o *options) []Warning {
+	warnings := []Warning{}
+	wildcardDescent := "" // the first recursive descent with a wildcard, if any
+	for i, lx := range lexemes {
+		switch {
+		case lx.typ == lexemeRecursiveDescent:
+			descent := descentText(lexemes, i)
+			if wildcardDescent != "" {
+				warnings = append(warnings, Warning{
+					Kind:    WarningNestedRecursiveDescent,
+					Message: fmt.Sprintf("recursive descent %s follows recursive descent %s and so visits each node once for each of its ancestors", descent, wildcardDescent),
+				})
+			} else if descent == "..*" || descent == "..[*]" {
+				wildcardDescent = descent
+			}
+
+		case lx.typ.isOrdering() && !o.timeComparison:
+			for _, operand := range []int{i - 1, i + 1} {
+				if operand >= 0 && operand < len(lexemes) && lexemes[operand].typ == lexemeFilterStringLiteral &&
+					!isNumericLiteral(lexemes[operand].val) {
+					warnings = append(warnings, Warning{
+						Kind:    WarningStringOrdering,
+						Message: fmt.Sprintf("comparison %s with string literal %s compares strings lexically rather than as timestamps", lx.val, lexemes[operand].val),
+					})
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// descentText returns the text of the recursive descent at the given index of the lexemes, including a following
+// array subscript if the recursive descent has no child name.
+func descentText(lexemes []lexeme, i int) string {
+	if lexemes[i].val == recursiveDescent && i+1 < len(lexemes) && lexemes[i+1].typ == lexemeArraySubscript {
+		return lexemes[i].val + lexemes[i+1].val
+	}
+	return lexemes[i].val
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2020 VMware, Inc.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package yamlpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-labs/yaml-jsonpath/pkg/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCompileWithWarnings(t *testing.T) {
+	cases := []struct {
+		name             string
+		path             string
+		opts             []yamlpath.Option
+		expectedWarnings []yamlpath.Warning
+		expectedError    string
+	}{
+		{
+			name:             "no warnings",
+			path:             "$..spec.containers[?(@.image=='nginx')].name",
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:             "single recursive descent with wildcard",
+			path:             "$..*",
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:             "recursive descents without wildcard",
+			path:             "$..spec..image",
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name: "recursive descent following recursive descent with wildcard",
+			path: "$..*..name",
+			expectedWarnings: []yamlpath.Warning{
+				{
+					Kind:    yamlpath.WarningNestedRecursiveDescent,
+					Message: "recursive descent ..name follows recursive descent ..* and so visits each node once for each of its ancestors",
+				},
+			},
+		},
+		{
+			name: "recursive descent in filter following recursive descent with bracketed wildcard",
+			path: "$..[*][?(@..image)]",
+			expectedWarnings: []yamlpath.Warning{
+				{
+					Kind:    yamlpath.WarningNestedRecursiveDescent,
+					Message: "recursive descent ..image follows recursive descent ..[*] and so visits each node once for each of its ancestors",
+				},
+			},
+		},
+		{
+			name: "ordering comparison with string literal",
+			path: "$[?(@.created > '2023-01-01' && '2024-01-01' >= @.created)]",
+			expectedWarnings: []yamlpath.Warning{
+				{
+					Kind:    yamlpath.WarningStringOrdering,
+					Message: "comparison > with string literal '2023-01-01' compares strings lexically rather than as timestamps",
+				},
+				{
+					Kind:    yamlpath.WarningStringOrdering,
+					Message: "comparison >= with string literal '2024-01-01' compares strings lexically rather than as timestamps",
+				},
+			},
+		},
+		{
+			name:             "ordering comparison with string literal and time comparison",
+			path:             "$[?(@.created > '2023-01-01')]",
+			opts:             []yamlpath.Option{yamlpath.WithTimeComparison()},
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:             "ordering comparison with numeric string literal",
+			path:             "$[?(@.price > '10')]",
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:             "ordering comparison with number",
+			path:             "$[?(@.price > 10)]",
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:          "invalid path",
+			path:          "$[?(@.price > 'cheap')]",
+			expectedError: `strings cannot be compared using > at position 14, following "> "`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, warnings, err := yamlpath.CompileWithWarnings(tc.path, tc.opts...)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				require.Nil(t, p)
+				require.Nil(t, warnings)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedWarnings, warnings)
+
+			q, err := yamlpath.NewPath(tc.path, tc.opts...)
+			require.NoError(t, err)
+			require.True(t, p.Equal(q))
+		})
+	}
+}
+
+func TestFindWithWarnings(t *testing.T) {
+	cases := []struct {
+		name             string
+		yaml             string
+		path             string
+		expectedStrings  []string
+		expectedWarnings []yamlpath.Warning
+	}{
+		{
+			name:             "index of sequence",
+			yaml:             "[a, b]\n",
+			path:             "$[0]",
+			expectedStrings:  []string{"a\n"},
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:            "index of mapping",
+			yaml:            "'0': a\n'1': b\n",
+			path:            "$[0]",
+			expectedStrings: []string{},
+			expectedWarnings: []yamlpath.Warning{
+				{
+					Kind:    yamlpath.WarningIndexOfMapping,
+					Message: "array index [0] applied to the mapping at line 1, column 1 matches only integer keys; use ['0'] to select the string key '0'",
+				},
+			},
+		},
+		{
+			name:             "index of mapping with integer key",
+			yaml:             "0: a\n1: b\n",
+			path:             "$[0]",
+			expectedStrings:  []string{"a\n"},
+			expectedWarnings: []yamlpath.Warning{},
+		},
+		{
+			name:            "index of several mappings",
+			yaml:            "[{x: 1}, {x: 2}, [y]]\n",
+			path:            "$[*][0]",
+			expectedStrings: []string{"y\n"},
+			expectedWarnings: []yamlpath.Warning{
+				{
+					Kind:    yamlpath.WarningIndexOfMapping,
+					Message: "array index [0] applied to the mapping at line 1, column 2 matches only integer keys; use ['0'] to select the string key '0'",
+				},
+				{
+					Kind:    yamlpath.WarningIndexOfMapping,
+					Message: "array index [0] applied to the mapping at line 1, column 10 matches only integer keys; use ['0'] to select the string key '0'",
+				},
+			},
+		},
+		{
+			name:            "warnings about the path",
+			yaml:            "{a: {'2': b}}\n",
+			path:            "$..*..[2]",
+			expectedStrings: []string{},
+			expectedWarnings: []yamlpath.Warning{
+				{
+					Kind:    yamlpath.WarningNestedRecursiveDescent,
+					Message: "recursive descent ..[2] follows recursive descent ..* and so visits each node once for each of its ancestors",
+				},
+				{
+					Kind:    yamlpath.WarningIndexOfMapping,
+					Message: "array index [2] applied to the mapping at line 1, column 5 matches only integer keys; use ['2'] to select the string key '2'",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var n yaml.Node
+			err := yaml.Unmarshal([]byte(tc.yaml), &n)
+			require.NoError(t, err)
+
+			p, err := yamlpath.NewPath(tc.path)
+			require.NoError(t, err)
+
+			actual, warnings, err := p.FindWithWarnings(&n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStrings, encodeNodes(t, actual))
+			require.Equal(t, tc.expectedWarnings, warnings)
+		})
+	}
+}